@@ -0,0 +1,147 @@
+package singleflight
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// AdaptiveTimeout 配置按 key 类别跟踪执行耗时并推荐执行超时，见 WithAdaptiveTimeout。
+type AdaptiveTimeout[K comparable] struct {
+	// Class 把 key 归入耗时特征相近的类别，例如按接口或租户分组。
+	// nil 时所有 key 属于同一个类别。
+	Class func(K) string
+
+	// Quantile 为推荐所依据的耗时分位数，默认 0.99。
+	Quantile float64
+
+	// Factor 为推荐超时相对该分位数的倍数，默认 2。
+	Factor float64
+
+	// Min 与 Max 限定推荐值的范围，0 表示不限制。
+	Min, Max time.Duration
+
+	// Apply 为 true 时自动以推荐值作为执行超时，取代 WithExecTimeout 的固定值；
+	// 类别的样本不足时仍使用固定值。为 false 时只通过 SuggestedTimeout 提供推荐。
+	Apply bool
+}
+
+// WithAdaptiveTimeout 记录每个 key 类别最近的执行耗时，据此推荐执行超时
+// （分位数 × 倍数）。固定的超时对某些 key 总是不合适的：太短会误杀慢而正常的执行，
+// 太长则无法及时发现卡死。隐含 WithTiming。
+//
+// 成功的执行与因执行超时失败的执行都计入样本：后者让推荐值在负载整体变慢时
+// 能够随之增长，而不会被自身的超时困住。
+func WithAdaptiveTimeout[K comparable](a AdaptiveTimeout[K]) Option {
+	return func(o *options) {
+		o.rawAdaptive = a
+		o.timing = true
+	}
+}
+
+const (
+	// adaptiveSamples 为每个类别保留的最近样本数。
+	adaptiveSamples = 256
+	// adaptiveMinSamples 为给出推荐前至少需要的样本数，样本太少时高分位数没有意义。
+	adaptiveMinSamples = 32
+	// adaptiveRecompute 为重新计算推荐值的样本间隔，排序的代价由此分摊。
+	adaptiveRecompute = 16
+)
+
+// latencyTracker 使用独立的锁，记录与查询都不与 Do 争用 g.mu。
+type latencyTracker[K comparable] struct {
+	AdaptiveTimeout[K]
+
+	mu      sync.Mutex
+	classes map[string]*latencyClass
+}
+
+type latencyClass struct {
+	samples [adaptiveSamples]time.Duration
+	n       int
+	suggest time.Duration
+}
+
+func newLatencyTracker[K comparable](a AdaptiveTimeout[K]) *latencyTracker[K] {
+	if a.Quantile <= 0 || a.Quantile > 1 {
+		a.Quantile = 0.99
+	}
+	if a.Factor <= 0 {
+		a.Factor = 2
+	}
+	return &latencyTracker[K]{AdaptiveTimeout: a, classes: make(map[string]*latencyClass)}
+}
+
+func (t *latencyTracker[K]) class(key K) string {
+	if t.Class == nil {
+		return ""
+	}
+	return t.Class(key)
+}
+
+func (t *latencyTracker[K]) record(key K, d time.Duration) {
+	name := t.class(key)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	c := t.classes[name]
+	if c == nil {
+		c = new(latencyClass)
+		t.classes[name] = c
+	}
+	c.samples[c.n%adaptiveSamples] = d
+	c.n++
+	if c.n >= adaptiveMinSamples && c.n%adaptiveRecompute == 0 {
+		c.suggest = t.compute(c.samples[:min(c.n, adaptiveSamples)])
+	}
+}
+
+// compute 返回样本的分位数乘以倍数并限定在 [Min, Max] 内的结果。
+func (t *latencyTracker[K]) compute(samples []time.Duration) time.Duration {
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+	i := int(math.Ceil(t.Quantile*float64(len(sorted)))) - 1
+	d := time.Duration(float64(sorted[max(i, 0)]) * t.Factor)
+	if t.Min > 0 && d < t.Min {
+		d = t.Min
+	}
+	if t.Max > 0 && d > t.Max {
+		d = t.Max
+	}
+	return d
+}
+
+func (t *latencyTracker[K]) suggest(key K) (time.Duration, bool) {
+	name := t.class(key)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if c := t.classes[name]; c != nil && c.suggest > 0 {
+		return c.suggest, true
+	}
+	return 0, false
+}
+
+// SuggestedTimeout 返回 key 所属类别的推荐执行超时，需开启 WithAdaptiveTimeout。
+// 类别的样本不足时返回 false。
+func (g *Group[K, V]) SuggestedTimeout(key K) (time.Duration, bool) {
+	if g.cfg == nil || g.cfg.adaptive == nil {
+		return 0, false
+	}
+	return g.cfg.adaptive.suggest(g.canonical(key))
+}
+
+// execTimeout 返回 key 本次执行的超时，0 表示不限制。
+func (g *Group[K, V]) execTimeout(key K) time.Duration {
+	if a := g.cfg.adaptive; a != nil && a.Apply {
+		if d, ok := a.suggest(key); ok {
+			return d
+		}
+	}
+	return g.cfg.execTimeout
+}
+
+// adaptiveSample 报告一次执行的耗时是否应计入 WithAdaptiveTimeout 的样本。
+func adaptiveSample(panicked bool, err error) bool {
+	return !panicked && (err == nil || errors.Is(err, ErrExecTimeout))
+}
@@ -0,0 +1,47 @@
+package singleflight
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveTimeout(t *testing.T) {
+	clock := NewFakeClock(time.Unix(100, 0))
+	g := NewGroup[string, int](WithClock(clock), WithAdaptiveTimeout(AdaptiveTimeout[string]{
+		Class: func(k string) string { c, _, _ := strings.Cut(k, "/"); return c },
+		Apply: true,
+		Max:   time.Minute,
+	}))
+	ctx := context.Background()
+	run := func(key string, d time.Duration) {
+		g.Do(ctx, key, func(context.Context) (int, error) {
+			clock.Advance(d)
+			return 0, nil
+		})
+	}
+
+	if _, ok := g.SuggestedTimeout("fast/1"); ok {
+		t.Fatal("suggestion without samples")
+	}
+	for i := range adaptiveMinSamples {
+		run("fast/"+string(rune('a'+i%26)), 10*time.Millisecond)
+		run("slow/x", time.Hour)
+	}
+	if d, ok := g.SuggestedTimeout("fast/z"); !ok || d != 20*time.Millisecond {
+		t.Fatalf("fast suggestion = %v, %v; want 20ms", d, ok)
+	}
+	if d, _ := g.SuggestedTimeout("slow/y"); d != time.Minute {
+		t.Fatalf("slow suggestion = %v, want clamped to Max", d)
+	}
+
+	// Apply 模式下推荐值成为执行的超时。
+	g.Do(ctx, "fast/q", func(ctx context.Context) (int, error) {
+		deadline, ok := ctx.Deadline()
+		if left := time.Until(deadline); !ok || left <= 0 || left > 20*time.Millisecond {
+			t.Errorf("execution deadline in %v, %v; want within 20ms", left, ok)
+		}
+		return 0, nil
+	})
+}
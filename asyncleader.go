@@ -0,0 +1,28 @@
+package singleflight
+
+import "context"
+
+// WithAsyncLeader 让每次执行的 fn 都在新的 goroutine 上运行（配置了 WithWorkers 时
+// 交给执行池），发起执行的调用者与 Follower 一样只是等待结果。
+//
+// 所有调用者的取消语义因此对称：任何一个调用者的 ctx 结束都只让它自己以
+// ErrWaiterCancelled 返回，执行不受影响并把结果交给其余等待者；fn 阻塞在系统调用上时
+// 也不会占住发起者的 goroutine。fn 的 ctx 与 DoDetachedWait 相同，脱离发起者的取消
+// 与截止时间（值的复制见 WithContextValues），应配合 WithExecTimeout 限制执行时长。
+// 发起者的取消不再导致执行失败，WithHandoff / WithCancelHandoff 在此模式下不会触发。
+func WithAsyncLeader() Option {
+	return func(o *options) { o.asyncLeader = true }
+}
+
+// asyncLeader 报告 Leader 是否总是异步执行。
+func (g *Group[K, V]) asyncLeader() bool {
+	return g.cfg != nil && g.cfg.asyncLeader
+}
+
+// leaderContext 返回 fn 使用的 ctx：分离执行与 WithAsyncLeader 脱离发起者的取消。
+func (g *Group[K, V]) leaderContext(ctx context.Context, co *callOpts[V]) context.Context {
+	if co != nil && co.detach || g.asyncLeader() {
+		return g.detach(ctx)
+	}
+	return ctx
+}
@@ -0,0 +1,54 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAsyncLeader(t *testing.T) {
+	joined := make(chan struct{})
+	g := NewGroup[string, int](WithAsyncLeader(), WithHooks(Hooks[string]{
+		FollowerJoined: func(string) { close(joined) },
+	}))
+
+	caller := goroutineID()
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fnErr := make(chan error, 1)
+	leader := make(chan error, 1)
+	go func() {
+		_, err, _ := g.Do(ctx, "k", func(fctx context.Context) (int, error) {
+			if goroutineID() == caller {
+				t.Error("fn ran on the caller's goroutine")
+			}
+			close(started)
+			<-release
+			fnErr <- fctx.Err()
+			return 1, nil
+		})
+		leader <- err
+	}()
+	<-started
+
+	follower := make(chan Result[int], 1)
+	go func() {
+		v, err, shared := g.Do(context.Background(), "k", nil)
+		follower <- Result[int]{Val: v, Err: err, Shared: shared}
+	}()
+	<-joined
+
+	// 发起者取消只让它自己返回，执行照常完成并交给 Follower。
+	cancel()
+	if err := <-leader; !errors.Is(err, ErrWaiterCancelled) {
+		t.Fatalf("leader err = %v, want ErrWaiterCancelled", err)
+	}
+	close(release)
+	if err := <-fnErr; err != nil {
+		t.Fatalf("fn ctx ended with %v", err)
+	}
+	if r := <-follower; r.Err != nil || r.Val != 1 || !r.Shared {
+		t.Fatalf("follower = %+v", r)
+	}
+}
@@ -0,0 +1,97 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Backoff 配置按 key 的失败退避。
+//
+// key 的执行失败后，Base 时长内到达的调用不再执行 fn，而是立即收到这次失败的错误；
+// 之后每次连续失败窗口翻倍，至多为 Max。窗口结束后放行一次执行，
+// 其余调用者照常合并到它上面，成功即清除退避。
+// 没有退避时，失败结果不会被缓存，一个坏掉的 key 会变成持续的重试风暴。
+type Backoff struct {
+	Base time.Duration
+	Max  time.Duration
+
+	// ServeStale 让窗口内的调用者优先拿到该 key 失败前保留的最近一次成功结果，
+	// 仅在 Group 因 WithMinExecInterval、WithDebounce 等保留结果时可用。
+	ServeStale bool
+
+	// IsFailure 判断执行错误是否触发退避，nil 时除 context.Canceled 外的错误都触发。
+	// panic 总是触发退避，窗口内的调用者收到 panic 对应的错误而不会重新 panic。
+	IsFailure func(error) bool
+}
+
+// WithFailureBackoff 为 Group 开启按 key 的失败退避。Base <= 0 时不生效。
+func WithFailureBackoff(b Backoff) Option {
+	return func(o *options) {
+		if b.Base > 0 {
+			o.backoff = &b
+		}
+	}
+}
+
+// backoffState 是 keyState 中失败退避的部分。
+type backoffState[V any] struct {
+	streak int
+	until  time.Time
+	err    error
+
+	stale    V
+	staleAt  time.Time
+	hasStale bool
+}
+
+func (s *backoffState[V]) idle(now time.Time) bool {
+	return s.streak == 0 && !now.Before(s.until)
+}
+
+// window 返回第 streak 次连续失败后的退避时长。
+func (b *Backoff) window(streak int) time.Duration {
+	d := b.Base
+	for i := 1; i < streak; i++ {
+		if b.Max > 0 && d >= b.Max || d > d<<1 {
+			break
+		}
+		d <<= 1
+	}
+	if b.Max > 0 && d > b.Max {
+		d = b.Max
+	}
+	return d
+}
+
+func (b *Backoff) failed(err error, panicked bool) bool {
+	if panicked {
+		return true
+	}
+	if err == nil {
+		return false
+	}
+	if b.IsFailure != nil {
+		return b.IsFailure(err)
+	}
+	return !errors.Is(err, context.Canceled)
+}
+
+// recordBackoffLocked 在 settleLocked 覆盖保留结果之前更新退避状态，
+// 以便首次失败时把仍然保留的成功结果留作陈旧值。
+func (g *Group[K, V]) recordBackoffLocked(s *keyState[V], c *call[V], now time.Time, failed bool) {
+	b := &s.backoff
+	if !failed {
+		*b = backoffState[V]{}
+		return
+	}
+	if b.streak == 0 && g.cfg.backoff.ServeStale && s.last.ok && s.last.err == nil {
+		b.stale, b.staleAt, b.hasStale = s.last.val, s.last.at, true
+	}
+	b.streak++
+	b.until = now.Add(g.cfg.backoff.window(b.streak))
+	b.err = c.err
+	if c.panicErr != nil {
+		b.err = c.panicErr
+	}
+}
@@ -0,0 +1,62 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFailureBackoff_ExponentialWindow(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	g := NewGroup[string, int](
+		WithClock(clock),
+		WithFailureBackoff(Backoff{Base: time.Second, Max: 3 * time.Second}),
+	)
+	ctx := context.Background()
+	boom := errors.New("boom")
+	runs := 0
+	fail := func(ctx context.Context) (int, error) { runs++; return 0, boom }
+
+	// 每次失败后在窗口内不再执行；窗口依次为 1s、2s、3s（封顶）。
+	for i, window := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second} {
+		if _, err, _ := g.Do(ctx, "k", fail); !errors.Is(err, boom) || runs != i+1 {
+			t.Fatalf("attempt %d: err = %v, runs = %d", i, err, runs)
+		}
+		clock.Advance(window - time.Millisecond)
+		if _, err, shared := g.Do(ctx, "k", fail); !errors.Is(err, boom) || !shared || runs != i+1 {
+			t.Fatalf("attempt %d inside window: err = %v, shared = %v, runs = %d", i, err, shared, runs)
+		}
+		clock.Advance(time.Millisecond)
+	}
+
+	if v, err, _ := g.Do(ctx, "k", func(context.Context) (int, error) { return 1, nil }); v != 1 || err != nil {
+		t.Fatalf("recovery = %d, %v", v, err)
+	}
+	g.mu.Lock()
+	n := len(g.states)
+	g.mu.Unlock()
+	if n != 0 {
+		t.Fatalf("states retained after recovery: %d", n)
+	}
+}
+
+func TestFailureBackoff_ServeStale(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	g := NewGroup[string, int](
+		WithClock(clock),
+		WithMinExecInterval(time.Millisecond),
+		WithFailureBackoff(Backoff{Base: time.Minute, ServeStale: true}),
+	)
+	ctx := context.Background()
+	g.Do(ctx, "k", func(context.Context) (int, error) { return 7, nil })
+	clock.Advance(time.Millisecond)
+	boom := errors.New("boom")
+	if _, err, _ := g.Do(ctx, "k", func(context.Context) (int, error) { return 0, boom }); !errors.Is(err, boom) {
+		t.Fatalf("err = %v", err)
+	}
+	clock.Advance(time.Second)
+	if v, err, _ := g.Do(ctx, "k", nil); v != 7 || err != nil {
+		t.Fatalf("inside window got %d, %v; want the stale value", v, err)
+	}
+}
@@ -0,0 +1,75 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrCircuitOpen 表示 key 的熔断器处于打开状态，调用被快速失败。
+var ErrCircuitOpen = newGroupError("singleflight: circuit open")
+
+// Breaker 配置按 key 的熔断器。
+//
+// 连续 Threshold 次执行失败后该 key 熔断 Cooldown 时长，期间新的调用
+// 立即收到 ErrCircuitOpen；冷却结束后放行一次试探执行（半开），
+// 其余调用者照常作为 Follower 合并到这次试探上，成功即恢复，失败则再次熔断。
+type Breaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	// IsFailure 判断执行错误是否计入失败，nil 时除 context.Canceled 外的错误都计入。
+	// panic 总是计入失败。
+	IsFailure func(error) bool
+}
+
+// WithCircuitBreaker 为 Group 开启按 key 的熔断。Threshold <= 0 时不生效。
+func WithCircuitBreaker(b Breaker) Option {
+	return func(o *options) {
+		if b.Threshold > 0 {
+			o.breaker = &b
+		}
+	}
+}
+
+// breakerState 是 keyState 中熔断器的部分。
+type breakerState struct {
+	failures  int
+	openUntil time.Time
+}
+
+func (s *breakerState) idle(now time.Time) bool {
+	return s.failures == 0 && !now.Before(s.openUntil)
+}
+
+func (b *Breaker) admit(s *breakerState, now time.Time) error {
+	if s.failures >= b.Threshold && now.Before(s.openUntil) {
+		return ErrCircuitOpen
+	}
+	return nil
+}
+
+func (b *Breaker) record(s *breakerState, now time.Time, failed bool) {
+	if !failed {
+		s.failures = 0
+		s.openUntil = time.Time{}
+		return
+	}
+	s.failures++
+	if s.failures >= b.Threshold {
+		s.openUntil = now.Add(b.Cooldown)
+	}
+}
+
+func (b *Breaker) failed(err error, panicked bool) bool {
+	if panicked {
+		return true
+	}
+	if err == nil {
+		return false
+	}
+	if b.IsFailure != nil {
+		return b.IsFailure(err)
+	}
+	return !errors.Is(err, context.Canceled)
+}
@@ -0,0 +1,53 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_OpenHalfOpenClose(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	g := NewGroup[string, int](
+		WithClock(clock),
+		WithCircuitBreaker(Breaker{Threshold: 2, Cooldown: time.Minute}),
+	)
+	ctx := context.Background()
+	boom := errors.New("boom")
+	fail := func(ctx context.Context) (int, error) { return 0, boom }
+	ok := func(ctx context.Context) (int, error) { return 1, nil }
+
+	for i := 0; i < 2; i++ {
+		if _, err, _ := g.Do(ctx, "k", fail); !errors.Is(err, boom) {
+			t.Fatalf("attempt %d: err = %v", i, err)
+		}
+	}
+	if _, err, _ := g.Do(ctx, "k", ok); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	// 其他 key 不受影响。
+	if v, err, _ := g.Do(ctx, "other", ok); v != 1 || err != nil {
+		t.Fatalf("other key = %d, %v", v, err)
+	}
+
+	// 冷却结束后的试探失败会立即再次熔断。
+	clock.Advance(time.Minute)
+	if _, err, _ := g.Do(ctx, "k", fail); !errors.Is(err, boom) {
+		t.Fatalf("half-open trial err = %v", err)
+	}
+	if _, err, _ := g.Do(ctx, "k", ok); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen after failed trial", err)
+	}
+
+	clock.Advance(time.Minute)
+	if v, err, _ := g.Do(ctx, "k", ok); v != 1 || err != nil {
+		t.Fatalf("recovery = %d, %v", v, err)
+	}
+	g.mu.Lock()
+	n := len(g.states)
+	g.mu.Unlock()
+	if n != 0 {
+		t.Fatalf("states retained after recovery: %d", n)
+	}
+}
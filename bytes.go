@@ -0,0 +1,58 @@
+package singleflight
+
+import (
+	"context"
+	"hash/maphash"
+)
+
+// bytesSeed 在进程内固定，零值 BytesGroup 因此可以直接使用。
+var bytesSeed = maphash.MakeSeed()
+
+// BytesGroup 以 []byte 为 key 合并调用，调用方不必为每次调用把 key 转换为 string。
+// 适用于以序列化的请求内容为 key 的场景。零值可用。
+//
+// 内部以 key 的 64 位哈希作为 Group 的 key，Leader 保存一份 key 的副本，
+// Follower 拿到结果后与自己的 key 逐字节比较；遇到哈希冲突时 Follower
+// 不共享结果，而是独立执行自己的 fn。因此只有 Leader 会为 key 分配内存。
+type BytesGroup[V any] struct {
+	g Group[uint64, bytesResult[V]]
+}
+
+// bytesResult 携带 Leader 的 key，ran 表示结果确实来自 fn 的执行，
+// 而不是 Group 在执行之外产生的错误。
+type bytesResult[V any] struct {
+	key string
+	val V
+	ran bool
+}
+
+// NewBytesGroup 创建带选项的 BytesGroup。依赖 key 类型的选项以 uint64
+// 哈希值为 key，例如 Hooks 收到的是哈希而不是原始的 key。
+func NewBytesGroup[V any](opts ...Option) *BytesGroup[V] {
+	b := new(BytesGroup[V])
+	b.g.cfg = NewGroup[uint64, bytesResult[V]](opts...).cfg
+	return b
+}
+
+// Do 与 Group.Do 相同。key 只在调用期间被读取，返回后可由调用方复用。
+func (b *BytesGroup[V]) Do(
+	ctx context.Context,
+	key []byte,
+	fn func(ctx context.Context) (V, error),
+) (v V, err error, shared bool) {
+	r, err, shared := b.g.Do(ctx, maphash.Bytes(bytesSeed, key), func(ctx context.Context) (bytesResult[V], error) {
+		v, err := fn(ctx)
+		return bytesResult[V]{key: string(key), val: v, ran: true}, err
+	})
+	// string(key) 用于比较时不分配内存。
+	if r.ran && r.key != string(key) {
+		v, err = fn(ctx)
+		return v, err, false
+	}
+	return r.val, err, shared
+}
+
+// Forget 使 BytesGroup 忘记 key。哈希相同的其他 key 的执行也会被忘记。
+func (b *BytesGroup[V]) Forget(key []byte) {
+	b.g.Forget(maphash.Bytes(bytesSeed, key))
+}
@@ -0,0 +1,46 @@
+package singleflight
+
+import (
+	"context"
+	"hash/maphash"
+	"runtime"
+	"testing"
+)
+
+func TestBytesGroup(t *testing.T) {
+	var b BytesGroup[string]
+	ctx := context.Background()
+	key := []byte("payload")
+	v, err, _ := b.Do(ctx, key, func(context.Context) (string, error) { return "v", nil })
+	if err != nil || v != "v" {
+		t.Fatalf("Do = %q, %v", v, err)
+	}
+
+	// 模拟哈希冲突：正在进行的执行属于另一个 key，Follower 应独立执行。
+	started, release := make(chan struct{}), make(chan struct{})
+	other := b.g.DoChan(ctx, maphash.Bytes(bytesSeed, key), func(context.Context) (bytesResult[string], error) {
+		close(started)
+		<-release
+		return bytesResult[string]{key: "other", val: "wrong", ran: true}, nil
+	})
+	<-started
+	res := make(chan string)
+	go func() {
+		v, _, shared := b.Do(ctx, key, func(context.Context) (string, error) { return "mine", nil })
+		if shared {
+			t.Error("colliding key reported a shared result")
+		}
+		res <- v
+	}()
+	for {
+		if info, _ := b.g.Inspect(maphash.Bytes(bytesSeed, key)); info.Waiters > 0 {
+			break
+		}
+		runtime.Gosched()
+	}
+	close(release)
+	<-other
+	if v := <-res; v != "mine" {
+		t.Fatalf("colliding key got %q", v)
+	}
+}
@@ -0,0 +1,45 @@
+package singleflight
+
+import "context"
+
+// DoOrDefault 与 Do 相同，但调用者的 ctx 在拿到结果前结束时不返回错误，
+// 而是返回 key 最近一次成功的结果（需要开启了保留结果的选项，如 WithMinExecInterval），
+// 没有时返回 def。
+//
+// 由本调用发起的执行脱离调用者的取消在后台继续，完成后照常交给其余等待者
+// 并更新保留的结果，供降级路径之后使用。fn 本身的错误照常返回。
+func (g *Group[K, V]) DoOrDefault(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) (V, error),
+	def V,
+) (v V, err error, shared bool) {
+	v, err, f := g.do(ctx, key, fn, &callOpts[V]{detach: true, hasDef: true, def: def})
+	return v, err, f.shared
+}
+
+// cancelled 生成调用者 ctx 结束时的返回值。
+func (g *Group[K, V]) cancelled(ctx context.Context, key K, co *callOpts[V], f flight) (V, error, flight) {
+	if co != nil && co.hasDef {
+		if v, ok := g.lastGood(key); ok {
+			return v, nil, f
+		}
+		return co.def, nil, f
+	}
+	var zero V
+	return zero, waitError(ctx), f
+}
+
+// lastGood 返回 key 保留的最近一次成功结果。
+func (g *Group[K, V]) lastGood(key K) (V, bool) {
+	var zero V
+	if g.cfg == nil || !g.cfg.keepLast {
+		return zero, false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if s, ok := g.states[key]; ok && s.last.ok && s.last.err == nil && g.freshEnough(s.last.at, g.now(), nil) {
+		return s.last.val, true
+	}
+	return zero, false
+}
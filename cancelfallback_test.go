@@ -0,0 +1,50 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDoOrDefault_LeaderContinuesAfterCancel(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	g := NewGroup[string, string](WithClock(clock), WithMinExecInterval(time.Nanosecond))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fnCtx := make(chan context.Context, 1)
+	res := make(chan string)
+	go func() {
+		v, err, _ := g.DoOrDefault(ctx, "k", func(ctx context.Context) (string, error) {
+			fnCtx <- ctx
+			close(started)
+			<-release
+			return "fresh", nil
+		}, "default")
+		if err != nil {
+			t.Errorf("DoOrDefault err = %v", err)
+		}
+		res <- v
+	}()
+	<-started
+	cancel()
+	if v := <-res; v != "default" {
+		t.Fatalf("cancelled caller got %q, want default", v)
+	}
+	if err := (<-fnCtx).Err(); err != nil {
+		t.Fatalf("leader execution was cancelled with its caller: %v", err)
+	}
+
+	// 后台执行完成后，降级路径拿到的是它的结果而不是默认值。
+	follower := g.DoChan(context.Background(), "k", nil)
+	close(release)
+	if r := <-follower; r.Val != "fresh" {
+		t.Fatalf("follower got %+v", r)
+	}
+	done, cancelDone := context.WithCancel(context.Background())
+	cancelDone()
+	if v, err, _ := g.DoOrDefault(done, "k", nil, "default"); v != "fresh" || err != nil {
+		t.Fatalf("after completion got %q, %v; want last result", v, err)
+	}
+}
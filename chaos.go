@@ -0,0 +1,58 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// ErrChaos 是 Chaos 未指定 Err 时注入的错误。
+var ErrChaos = errors.New("singleflight: chaos injected error")
+
+// Chaos 描述对 Leader 执行注入的故障，用于对构建在 Group 之上的代码做韧性测试。
+//
+// 每次 Leader 执行以 Fraction 的概率被选中；被选中的执行先延迟
+// [0, Latency) 的随机时长（按 WithClock 设置的时钟计时），然后以 PanicRate 的概率 panic，
+// 否则以 ErrorRate 的概率直接返回 Err 而不调用 fn。
+// 注入的 panic 与 fn 自身的 panic 走同一条传播路径。
+type Chaos struct {
+	Fraction  float64
+	Latency   time.Duration
+	ErrorRate float64
+	PanicRate float64
+
+	// Err 为注入的错误，nil 时使用 ErrChaos。
+	Err error
+}
+
+// WithChaos 为 Group 开启故障注入。不要在生产环境中使用。
+func WithChaos(c Chaos) Option {
+	return func(o *options) { o.chaos = &c }
+}
+
+// inject 在 Leader 执行 fn 之前调用，返回非 nil 时跳过 fn。延迟由 Group 的时钟计时。
+func (c *Chaos) inject(ctx context.Context, clock Clock) error {
+	if rand.Float64() >= c.Fraction {
+		return nil
+	}
+	if c.Latency > 0 {
+		t := clock.NewTimer(rand.N(c.Latency))
+		select {
+		case <-t.C():
+		case <-ctx.Done():
+			t.Stop()
+			return context.Cause(ctx)
+		}
+	}
+	if rand.Float64() < c.PanicRate {
+		panic(ErrChaos)
+	}
+	if rand.Float64() < c.ErrorRate {
+		if c.Err != nil {
+			return c.Err
+		}
+		return ErrChaos
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestChaos_InjectsErrorsAndPanics(t *testing.T) {
+	boom := errors.New("boom")
+	g := NewGroup[int, int](WithChaos(Chaos{Fraction: 1, ErrorRate: 1, Err: boom}))
+	_, err, _ := g.Do(context.Background(), 1, func(ctx context.Context) (int, error) {
+		t.Error("fn must be skipped when an error is injected")
+		return 0, nil
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want %v", err, boom)
+	}
+
+	g = NewGroup[int, int](WithChaos(Chaos{Fraction: 1, PanicRate: 1}))
+	func() {
+		defer func() {
+			r := recover()
+			if err, ok := r.(error); !ok || !errors.Is(err, ErrChaos) {
+				t.Fatalf("recover() = %v, want ErrChaos panic", r)
+			}
+		}()
+		g.Do(context.Background(), 1, func(ctx context.Context) (int, error) { return 0, nil })
+	}()
+
+	g = NewGroup[int, int](WithChaos(Chaos{Fraction: 0, ErrorRate: 1}))
+	if v, err, _ := g.Do(context.Background(), 1, func(ctx context.Context) (int, error) { return 7, nil }); v != 7 || err != nil {
+		t.Fatalf("unaffected call = %d, %v", v, err)
+	}
+}
+
+func TestChaos_LatencyUsesGroupClock(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	g := NewGroup[int, int](WithClock(clock), WithChaos(Chaos{Fraction: 1, Latency: time.Second}))
+	ch := g.DoChan(context.Background(), 1, func(ctx context.Context) (int, error) { return 7, nil })
+	for clock.Timers() == 0 {
+		runtime.Gosched()
+	}
+	select {
+	case r := <-ch:
+		t.Fatalf("returned before the fake clock advanced: %+v", r)
+	default:
+	}
+	clock.Advance(time.Second)
+	if r := <-ch; r.Val != 7 || r.Err != nil {
+		t.Fatalf("result = %+v", r)
+	}
+}
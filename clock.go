@@ -0,0 +1,218 @@
+package singleflight
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// Clock 抽象时间源。所有 TTL、租约、刷新类功能都通过它读取时间和创建定时器，
+// 测试中注入 FakeClock 即可确定性地推进时间，无需 sleep。
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	// AfterFunc 在 d 之后调用 f。返回的 Timer 的 C() 为 nil。
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer 是 *time.Timer 的可替换版本。
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// SystemClock 是基于 time 包的真实时钟。
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return systemTimer{time.AfterFunc(d, f)}
+}
+
+type systemTimer struct{ t *time.Timer }
+
+func (t systemTimer) C() <-chan time.Time        { return t.t.C }
+func (t systemTimer) Stop() bool                 { return t.t.Stop() }
+func (t systemTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
+
+// clockOrSystem 让零值配置退化为真实时钟。
+func clockOrSystem(c Clock) Clock {
+	if c == nil {
+		return SystemClock
+	}
+	return c
+}
+
+// WithClock 设置 Group 内部计时（执行耗时、冷却期、TTL 等）使用的时钟，
+// 默认 SystemClock。
+func WithClock(c Clock) Option {
+	return func(o *options) { o.clock = c }
+}
+
+// now 读取 Group 的时钟。未配置时直接调用 time.Now，避免接口调用开销。
+func (g *Group[K, V]) now() time.Time {
+	if g.cfg != nil && g.cfg.clock != nil {
+		return g.cfg.clock.Now()
+	}
+	return time.Now()
+}
+
+// FakeClock 是手动推进的时钟，并发安全。
+//
+// 到期的定时器在 Advance / Set 内按到期顺序触发：
+// 通道定时器非阻塞地发送当前时间，AfterFunc 回调在调用 Advance 的
+// goroutine 上同步执行（不持有 FakeClock 的锁）。与 Go 1.23 起的 time.Timer 一致，
+// Stop / Reset 返回后通道中不会残留此前触发的值。
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFakeClock 创建起始于 now 的 FakeClock。
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now 返回当前的模拟时间。
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer 创建在模拟时间 d 之后触发的定时器。
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{clock: c, ch: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// AfterFunc 创建在模拟时间 d 之后执行 f 的定时器。
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	t := &fakeTimer{clock: c, fn: f}
+	t.Reset(d)
+	return t
+}
+
+// Advance 把时间推进 d 并触发所有到期的定时器。
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	now := c.now.Add(d)
+	c.mu.Unlock()
+	c.Set(now)
+}
+
+// Set 把时间设置为 now 并触发所有到期的定时器。时间不会倒退。
+func (c *FakeClock) Set(now time.Time) {
+	for {
+		c.mu.Lock()
+		if now.Before(c.now) {
+			now = c.now
+		}
+		t := c.nextExpiredLocked(now)
+		if t == nil {
+			c.now = now
+			c.mu.Unlock()
+			return
+		}
+		// 逐个触发并推进到其到期时刻，回调中读到的 Now 与到期时间一致。
+		c.now = t.when
+		c.removeLocked(t)
+		// 通道在锁内发送，Stop / Reset 的清空不会与之交错。
+		if t.fn == nil {
+			t.sendLocked()
+			c.mu.Unlock()
+			continue
+		}
+		c.mu.Unlock()
+		t.fn()
+	}
+}
+
+// Timers 返回尚未触发的定时器数量，测试可据此等待被测代码进入等待状态。
+func (c *FakeClock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+func (c *FakeClock) nextExpiredLocked(now time.Time) *fakeTimer {
+	var next *fakeTimer
+	for _, t := range c.timers {
+		if t.when.After(now) {
+			continue
+		}
+		if next == nil || t.when.Before(next.when) {
+			next = t
+		}
+	}
+	return next
+}
+
+func (c *FakeClock) removeLocked(t *fakeTimer) bool {
+	i := slices.Index(c.timers, t)
+	if i < 0 {
+		return false
+	}
+	c.timers = slices.Delete(c.timers, i, i+1)
+	return true
+}
+
+type fakeTimer struct {
+	clock *FakeClock
+	when  time.Time
+	ch    chan time.Time
+	fn    func()
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.drainLocked()
+	return t.clock.removeLocked(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	c := t.clock
+	c.mu.Lock()
+	t.drainLocked()
+	active := c.removeLocked(t)
+	t.when = c.now.Add(d)
+	c.timers = append(c.timers, t)
+	c.mu.Unlock()
+
+	if d <= 0 {
+		c.Set(c.Now())
+	}
+	return active
+}
+
+// sendLocked 非阻塞地发送触发时间，调用时必须持有 clock.mu。
+func (t *fakeTimer) sendLocked() {
+	select {
+	case t.ch <- t.when:
+	default:
+	}
+}
+
+// drainLocked 丢弃已触发但未被读取的值，调用时必须持有 clock.mu。
+func (t *fakeTimer) drainLocked() {
+	if t.ch == nil {
+		return
+	}
+	select {
+	case <-t.ch:
+	default:
+	}
+}
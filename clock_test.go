@@ -0,0 +1,70 @@
+package singleflight
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClock_TimersFireInOrder(t *testing.T) {
+	c := NewFakeClock(time.Unix(0, 0))
+
+	var fired []int
+	c.AfterFunc(2*time.Second, func() { fired = append(fired, 2) })
+	c.AfterFunc(time.Second, func() { fired = append(fired, 1) })
+	stopped := c.AfterFunc(1500*time.Millisecond, func() { fired = append(fired, -1) })
+	ch := c.NewTimer(3 * time.Second)
+
+	if !stopped.Stop() {
+		t.Fatal("Stop on pending timer must report true")
+	}
+
+	c.Advance(2 * time.Second)
+	if len(fired) != 2 || fired[0] != 1 || fired[1] != 2 {
+		t.Fatalf("fired = %v, want [1 2]", fired)
+	}
+	select {
+	case <-ch.C():
+		t.Fatal("timer fired early")
+	default:
+	}
+
+	c.Advance(time.Second)
+	select {
+	case at := <-ch.C():
+		if !at.Equal(time.Unix(3, 0)) {
+			t.Fatalf("timer fired at %v", at)
+		}
+	default:
+		t.Fatal("timer did not fire")
+	}
+	if n := c.Timers(); n != 0 {
+		t.Fatalf("pending timers = %d", n)
+	}
+}
+
+func TestFakeClock_StopAndResetDrainFiredValue(t *testing.T) {
+	c := NewFakeClock(time.Unix(0, 0))
+	tm := c.NewTimer(time.Second)
+	c.Advance(time.Second)
+	if tm.Stop() {
+		t.Fatal("Stop reported an already fired timer as active")
+	}
+	select {
+	case v := <-tm.C():
+		t.Fatalf("stale value %v after Stop", v)
+	default:
+	}
+
+	tm.Reset(time.Second)
+	c.Advance(time.Second)
+	tm.Reset(time.Second)
+	select {
+	case v := <-tm.C():
+		t.Fatalf("stale value %v after Reset", v)
+	default:
+	}
+	c.Advance(time.Second)
+	if v := <-tm.C(); !v.Equal(time.Unix(3, 0)) {
+		t.Fatalf("fired at %v, want the reset deadline", v)
+	}
+}
@@ -0,0 +1,49 @@
+package singleflight
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec 在结果与其字节形式之间转换。跨进程的功能（例如在实例间传递结果）
+// 都通过它编码 V，使不同后端之间的编码保持一致；protobuf 等格式只需实现这两个方法。
+//
+// Marshal 返回的切片归调用方所有，实现不得在返回后继续修改它。
+type Codec[V any] interface {
+	Marshal(v V) ([]byte, error)
+	Unmarshal(data []byte) (V, error)
+}
+
+// JSONCodec 以 encoding/json 编码 V。
+type JSONCodec[V any] struct{}
+
+// Marshal 以 JSON 编码 v。
+func (JSONCodec[V]) Marshal(v V) ([]byte, error) { return json.Marshal(v) }
+
+// Unmarshal 解码 JSON 形式的 V。
+func (JSONCodec[V]) Unmarshal(data []byte) (V, error) {
+	var v V
+	err := json.Unmarshal(data, &v)
+	return v, err
+}
+
+// GobCodec 以 encoding/gob 编码 V。每个值独立编码并携带类型信息，
+// 比 JSON 大，但能保留 JSON 无法表示的类型（如非字符串 key 的 map）。
+type GobCodec[V any] struct{}
+
+// Marshal 以 gob 编码 v。
+func (GobCodec[V]) Marshal(v V) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal 解码 gob 形式的 V。
+func (GobCodec[V]) Unmarshal(data []byte) (V, error) {
+	var v V
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&v)
+	return v, err
+}
@@ -0,0 +1,27 @@
+package singleflight
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCodecs(t *testing.T) {
+	type user struct {
+		Name  string
+		Roles map[int]string
+	}
+	in := user{Name: "moon", Roles: map[int]string{1: "admin"}}
+	for name, c := range map[string]Codec[user]{"json": JSONCodec[user]{}, "gob": GobCodec[user]{}} {
+		data, err := c.Marshal(in)
+		if err != nil {
+			t.Fatalf("%s: Marshal: %v", name, err)
+		}
+		out, err := c.Unmarshal(data)
+		if err != nil || !reflect.DeepEqual(out, in) {
+			t.Fatalf("%s: Unmarshal = %+v, %v", name, out, err)
+		}
+	}
+	if _, err := (JSONCodec[user]{}).Unmarshal([]byte("{")); err == nil {
+		t.Fatal("truncated JSON decoded without error")
+	}
+}
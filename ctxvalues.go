@@ -0,0 +1,51 @@
+package singleflight
+
+import (
+	"context"
+	"time"
+)
+
+// WithContextValues 指定脱离调用者执行时（DoOrDefault、DoDetachedWait、
+// 防抖与间隔刷新的后台执行）从发起者 ctx 复制到执行 ctx 的值，例如认证信息、
+// 语言与租户。
+//
+// 未配置时，DoOrDefault 与 DoDetachedWait 的执行 ctx 保留发起者 ctx 的全部值
+// 但不继承取消，后台执行使用 context.Background()。配置后两者都只携带列出的值：
+// 发起者的 ctx 不会被保留，其中绑定到请求生命周期的其他值也不会泄漏到后台执行中。
+func WithContextValues(keys ...any) Option {
+	return func(o *options) { o.contextValues = append(o.contextValues, keys...) }
+}
+
+// detach 返回脱离 ctx 取消的执行 ctx。
+func (g *Group[K, V]) detach(ctx context.Context) context.Context {
+	if g.cfg == nil || len(g.cfg.contextValues) == 0 {
+		return context.WithoutCancel(ctx)
+	}
+	vc := &valuesContext{vals: make([]any, 0, 2*len(g.cfg.contextValues))}
+	for _, k := range g.cfg.contextValues {
+		if v := ctx.Value(k); v != nil {
+			vc.vals = append(vc.vals, k, v)
+		}
+	}
+	return vc
+}
+
+// valuesContext 是只携带一组复制来的值、永不取消的 ctx。
+type valuesContext struct {
+	vals []any // key, value 交替
+}
+
+func (*valuesContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (*valuesContext) Done() <-chan struct{}       { return nil }
+func (*valuesContext) Err() error                  { return nil }
+
+func (c *valuesContext) Value(key any) any {
+	for i := 0; i < len(c.vals); i += 2 {
+		if c.vals[i] == key {
+			return c.vals[i+1]
+		}
+	}
+	return nil
+}
+
+func (c *valuesContext) String() string { return "singleflight.detachedContext" }
@@ -0,0 +1,58 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type tenantKey struct{}
+type traceKey struct{}
+
+func TestWithContextValues_DebounceTrailing(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	g := NewGroup[string, string](
+		WithClock(clock),
+		WithDebounce(time.Second),
+		WithContextValues(tenantKey{}),
+	)
+	got := make(chan context.Context, 2)
+	fn := func(ctx context.Context) (string, error) {
+		got <- ctx
+		return "v", nil
+	}
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	ctx = context.WithValue(ctx, traceKey{}, "span-1")
+
+	g.Do(ctx, "k", fn)
+	<-got
+	g.Do(ctx, "k", fn) // 窗口内：预约尾沿执行
+	clock.Advance(time.Second)
+
+	trailing := <-got
+	if v := trailing.Value(tenantKey{}); v != "acme" {
+		t.Fatalf("tenant = %v, want value copied from caller", v)
+	}
+	if v := trailing.Value(traceKey{}); v != nil {
+		t.Fatalf("trace = %v, values outside the allowlist must not leak", v)
+	}
+	if trailing.Done() != nil {
+		t.Fatal("detached context must not be cancellable")
+	}
+}
+
+func TestWithContextValues_DetachedLeader(t *testing.T) {
+	g := NewGroup[string, string](WithContextValues(tenantKey{}))
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	ctx = context.WithValue(ctx, traceKey{}, "span-1")
+	v, _, _ := g.DoOrDefault(ctx, "k", func(ctx context.Context) (string, error) {
+		if ctx.Value(traceKey{}) != nil {
+			t.Error("values outside the allowlist leaked into the detached leader")
+		}
+		tenant, _ := ctx.Value(tenantKey{}).(string)
+		return tenant, nil
+	}, "default")
+	if v != "acme" {
+		t.Fatalf("got %q, want tenant copied into the detached leader", v)
+	}
+}
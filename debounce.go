@@ -0,0 +1,16 @@
+package singleflight
+
+import "time"
+
+// WithDebounce 为每个 key 开启防抖。
+//
+// key 有已完成的结果、且距上次调用不足 window 时，调用者立即拿到该结果
+// （shared 为 true），同时把一次后台执行推迟到最后一次调用之后的 window；
+// 持续到达的调用不断推迟它，只有安静下来后的尾沿才真正执行，
+// 其结果供之后的调用者使用。没有可用结果或已安静超过 window 时照常执行。
+//
+// 尾沿执行使用最近一次调用传入的 fn，并以 context.Background 运行。
+// window <= 0 表示不开启。
+func WithDebounce(window time.Duration) Option {
+	return func(o *options) { o.debounce = window }
+}
@@ -0,0 +1,76 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDebounce_TrailingEdge(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	g := NewGroup[string, string](WithClock(clock), WithDebounce(100*time.Millisecond))
+	ctx := context.Background()
+	var execs []string
+	query := func(q string) func(ctx context.Context) (string, error) {
+		return func(ctx context.Context) (string, error) {
+			execs = append(execs, q)
+			return q, nil
+		}
+	}
+
+	// 没有可用结果时立即执行。
+	if v, _, _ := g.Do(ctx, "k", query("a")); v != "a" {
+		t.Fatalf("first = %q", v)
+	}
+	// 窗口内的连续调用拿到旧结果，并不断推迟尾沿。
+	for _, q := range []string{"ab", "abc", "abcd"} {
+		clock.Advance(50 * time.Millisecond)
+		if v, _, shared := g.Do(ctx, "k", query(q)); v != "a" || !shared {
+			t.Fatalf("%s: got %q, shared=%v", q, v, shared)
+		}
+	}
+	if len(execs) != 1 {
+		t.Fatalf("executed during burst: %v", execs)
+	}
+
+	// 安静 window 之后只执行最后一次调用的 fn。
+	clock.Advance(100 * time.Millisecond)
+	if len(execs) != 2 || execs[1] != "abcd" {
+		t.Fatalf("execs = %v, want trailing abcd", execs)
+	}
+	if v, _, _ := g.Do(ctx, "k", query("x")); v != "abcd" {
+		t.Fatalf("after trailing = %q", v)
+	}
+}
+
+func TestDebounce_CloseCancelsTrailing(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	g := NewGroup[string, int](WithClock(clock), WithDebounce(time.Second))
+	var execs int
+	fn := func(ctx context.Context) (int, error) {
+		execs++
+		return execs, nil
+	}
+	g.Do(context.Background(), "k", fn)
+	g.Do(context.Background(), "k", fn)
+	g.Close()
+	clock.Advance(time.Second)
+	if execs != 1 {
+		t.Fatalf("execs = %d after Close", execs)
+	}
+}
+
+func TestDebounce_TrailingPanicIsContained(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	g := NewGroup[string, int](WithClock(clock), WithDebounce(time.Second))
+	ctx := context.Background()
+	g.Do(ctx, "k", func(context.Context) (int, error) { return 1, nil })
+	g.Do(ctx, "k", func(context.Context) (int, error) { panic("boom") })
+
+	// 尾沿在计时器回调中执行，panic 不能逃出 Advance。
+	clock.Advance(time.Second)
+	clock.Advance(time.Second)
+	if v, err, shared := g.Do(ctx, "k", func(context.Context) (int, error) { return 2, nil }); v != 2 || err != nil || shared {
+		t.Fatalf("after panic = %d, %v, shared=%v", v, err, shared)
+	}
+}
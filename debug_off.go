@@ -0,0 +1,17 @@
+//go:build !singleflightdebug
+
+package singleflight
+
+// debugBuild 报告是否为 singleflightdebug 构建，供测试跳过对分配次数的断言。
+const debugBuild = false
+
+// debugCall 在默认构建下为空结构体，不占用 call 的空间。
+type debugCall struct{}
+
+// 以下空实现会被内联消除，默认构建没有任何额外开销。
+
+func (c *call[V]) debugReuse(key any)   {}
+func (c *call[V]) debugRecycle(key any) {}
+func (c *call[V]) debugJoin(key any)    {}
+func (c *call[V]) debugLeave(key any)   {}
+func (c *call[V]) debugClose(key any)   {}
@@ -0,0 +1,52 @@
+//go:build singleflightdebug
+
+package singleflight
+
+import "fmt"
+
+// debugBuild 报告是否为 singleflightdebug 构建，供测试跳过对分配次数的断言。
+const debugBuild = true
+
+// debugCall 记录 call 的生命周期状态，仅在 singleflightdebug 构建下存在。
+type debugCall struct {
+	pooled     bool
+	doneClosed bool
+}
+
+func invariantf(format string, args ...any) {
+	panic(fmt.Sprintf("singleflight: invariant violated: "+format, args...))
+}
+
+func (c *call[V]) debugReuse(key any) {
+	c.dbg.pooled = false
+	c.dbg.doneClosed = false
+}
+
+func (c *call[V]) debugRecycle(key any) {
+	if c.dbg.pooled {
+		invariantf("call for key %v recycled twice", key)
+	}
+	if c.dups.Load() != 0 {
+		invariantf("call for key %v recycled while %d waiters exist (forgotten=%v)", key, c.dups.Load(), c.forgotten)
+	}
+	c.dbg.pooled = true
+}
+
+func (c *call[V]) debugJoin(key any) {
+	if c.dbg.pooled {
+		invariantf("follower joined recycled call for key %v (dups=%d)", key, c.dups.Load())
+	}
+}
+
+func (c *call[V]) debugLeave(key any) {
+	if c.dups.Load() < 0 {
+		invariantf("negative dups %d for key %v after waiter left", c.dups.Load(), key)
+	}
+}
+
+func (c *call[V]) debugClose(key any) {
+	if c.dbg.doneClosed {
+		invariantf("done channel for key %v closed twice", key)
+	}
+	c.dbg.doneClosed = true
+}
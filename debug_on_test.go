@@ -0,0 +1,32 @@
+//go:build singleflightdebug
+
+package singleflight
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDebug_DoubleCloseDetected(t *testing.T) {
+	defer func() {
+		r, _ := recover().(string)
+		if !strings.Contains(r, "closed twice") {
+			t.Fatalf("recover() = %q", r)
+		}
+	}()
+	c := new(call[int])
+	c.debugClose("k")
+	c.debugClose("k")
+}
+
+func TestDebug_RecycleWithWaitersDetected(t *testing.T) {
+	defer func() {
+		r, _ := recover().(string)
+		if !strings.Contains(r, "waiters exist") {
+			t.Fatalf("recover() = %q", r)
+		}
+	}()
+	c := new(call[int])
+	c.dups.Store(2)
+	c.debugRecycle("k")
+}
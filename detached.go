@@ -0,0 +1,80 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrMaxWaitExceeded 是 DoDetachedWait 等待超过 maxWait 时返回的 *WaitError 的 Cause。
+var ErrMaxWaitExceeded = errors.New("singleflight: max wait exceeded")
+
+// DoDetachedWait 与 Do 相同，但调用者至多等待 maxWait。超时后调用者收到
+// Cause 为 ErrMaxWaitExceeded 的 *WaitError，而执行脱离调用者的取消继续进行；
+// 其成功结果在完成后保留 maxWait，期间到达的调用者直接取用而不再执行，
+// 使慢速的首次加载不会因为每个调用者都超时而完全白费。
+//
+// 保留的结果只在有 DoDetachedWait 调用者放弃等待时产生，
+// 且对该 key 的任何调用（包括 Do）都可取用。maxWait <= 0 时与 Do 相同。
+func (g *Group[K, V]) DoDetachedWait(
+	ctx context.Context,
+	key K,
+	maxWait time.Duration,
+	fn func(ctx context.Context) (V, error),
+) (v V, err error, shared bool) {
+	if maxWait <= 0 {
+		return g.Do(ctx, key, fn)
+	}
+	ctx, cancel := context.WithTimeoutCause(ctx, maxWait, ErrMaxWaitExceeded)
+	defer cancel()
+	v, err, f := g.do(ctx, key, fn, &callOpts[V]{detach: true, park: maxWait})
+	return v, err, f.shared
+}
+
+// parkedResult 是一个等待被取用的后台执行结果。at 为结果产生的时刻。
+type parkedResult[V any] struct {
+	val     V
+	at      time.Time
+	expires time.Time
+}
+
+// parkLocked 保留 key 的结果 d 时长。
+func (g *Group[K, V]) parkLocked(key K, v V, d time.Duration) {
+	now := g.now()
+	if g.parked == nil {
+		g.parked = make(map[K]parkedResult[V])
+	}
+	// 与 sweepLocked 相同，翻倍时清理过期项，兜底不再被访问的 key。
+	if len(g.parked) >= 2*g.parkedSwept+16 {
+		for k, p := range g.parked {
+			if !now.Before(p.expires) {
+				delete(g.parked, k)
+			}
+		}
+		g.parkedSwept = len(g.parked)
+	}
+	g.parked[key] = parkedResult[V]{val: v, at: now, expires: now.Add(d)}
+}
+
+// parkedLocked 返回 key 未过期的保留结果，过期的顺带删除。
+// 结果超出 co 要求的 maxAge 时不返回，但仍留给其他调用者。
+func (g *Group[K, V]) parkedLocked(key K, co *callOpts[V]) (V, bool) {
+	var zero V
+	// 串行模式下每个调用都要观察到一次在它到达之后开始的执行，保留的结果不适用。
+	if g.cfg != nil && g.cfg.serial {
+		return zero, false
+	}
+	p, ok := g.parked[key]
+	if !ok {
+		return zero, false
+	}
+	now := g.now()
+	if !now.Before(p.expires) {
+		delete(g.parked, key)
+		return zero, false
+	}
+	if co != nil && co.hasMaxAge && now.After(p.at.Add(co.maxAge)) {
+		return zero, false
+	}
+	return p.val, true
+}
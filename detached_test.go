@@ -0,0 +1,80 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDoDetachedWait_ParksResultForLaterCallers(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	completed := make(chan struct{}, 2)
+	g := NewGroup[string, string](
+		WithClock(clock),
+		WithHooks(Hooks[string]{BeforeWake: func(string) { completed <- struct{}{} }}),
+	)
+
+	release := make(chan struct{})
+	_, err, _ := g.DoDetachedWait(context.Background(), "k", 10*time.Millisecond, func(ctx context.Context) (string, error) {
+		<-release
+		return "slow", ctx.Err()
+	})
+	var we *WaitError
+	if !errors.As(err, &we) || !errors.Is(err, ErrMaxWaitExceeded) {
+		t.Fatalf("err = %v, want *WaitError caused by ErrMaxWaitExceeded", err)
+	}
+
+	close(release)
+	<-completed
+	v, err, shared := g.Do(context.Background(), "k", func(ctx context.Context) (string, error) {
+		t.Error("parked result must be reused")
+		return "", nil
+	})
+	if v != "slow" || err != nil || !shared {
+		t.Fatalf("retry got %q, %v, shared=%v", v, err, shared)
+	}
+
+	// 保留期过后重新执行。
+	clock.Advance(10 * time.Millisecond)
+	if v, _, _ := g.Do(context.Background(), "k", func(ctx context.Context) (string, error) { return "new", nil }); v != "new" {
+		t.Fatalf("after expiry got %q", v)
+	}
+}
+
+func TestDoDetachedWait_ForgetDropsParkedResult(t *testing.T) {
+	completed := make(chan struct{}, 2)
+	g := NewGroup[string, string](WithHooks(Hooks[string]{BeforeWake: func(string) { completed <- struct{}{} }}))
+	ctx := context.Background()
+	fresh := func(context.Context) (string, error) { return "fresh", nil }
+
+	detach := func() chan struct{} {
+		release := make(chan struct{})
+		_, err, _ := g.DoDetachedWait(ctx, "k", time.Millisecond, func(context.Context) (string, error) {
+			<-release
+			return "stale", nil
+		})
+		if !errors.Is(err, ErrMaxWaitExceeded) {
+			t.Fatalf("err = %v, want ErrMaxWaitExceeded", err)
+		}
+		return release
+	}
+
+	// 执行完成前被 Forget：结果不保留。
+	release := detach()
+	g.Forget("k")
+	close(release)
+	<-completed
+	if v, _, _ := g.Do(ctx, "k", fresh); v != "fresh" {
+		t.Fatalf("after Forget during execution got %q", v)
+	}
+
+	// 执行完成后被 Forget：已保留的结果被丢弃。
+	release = detach()
+	close(release)
+	<-completed
+	g.Forget("k")
+	if v, _, _ := g.Do(ctx, "k", fresh); v != "fresh" {
+		t.Fatalf("after Forget of a parked result got %q", v)
+	}
+}
@@ -0,0 +1,178 @@
+// Package dnssf 提供与 net.Resolver 方法签名兼容的 DNS 查询合并层。
+//
+// 连接风暴时大量 goroutine 会对同一主机发起相同的查询，
+// Resolver 将并发的同名查询合并为一次，并可选地在 TTL 内缓存成功结果。
+package dnssf
+
+import (
+	"context"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+// Resolver 包装 *net.Resolver，零值可用（使用 net.DefaultResolver，不缓存）。
+//
+// 返回的切片均为副本（包括 net.IP 的地址字节），调用方可以自由修改而不影响其他等待者。
+type Resolver struct {
+	// Resolver 为实际执行查询的解析器，nil 时使用 net.DefaultResolver。
+	Resolver *net.Resolver
+
+	// TTL 为成功结果的缓存时长，<= 0 表示仅合并并发查询而不缓存。
+	// 失败结果从不缓存，避免把瞬时故障放大为持续故障。
+	TTL time.Duration
+
+	// Jitter 把每个条目的 TTL 随机缩短至多 Jitter 比例（0 到 1），
+	// 使同时写入的条目不会在同一时刻过期并再次同时打到 DNS 服务器。
+	// 只缩短不延长，缓存不会超过 TTL。
+	Jitter float64
+
+	// Clock 用于缓存过期判断，nil 时使用 singleflight.SystemClock。
+	Clock singleflight.Clock
+
+	host  lookupGroup[string, string]
+	ipa   lookupGroup[string, net.IPAddr]
+	ip    lookupGroup[netHost, net.IP]
+	netip lookupGroup[netHost, netip.Addr]
+}
+
+// netHost 是带 network 参数的查询 key（"ip"/"ip4"/"ip6"）。
+type netHost struct {
+	network string
+	host    string
+}
+
+func (r *Resolver) now() time.Time {
+	if r.Clock != nil {
+		return r.Clock.Now()
+	}
+	return time.Now()
+}
+
+// ttl 返回一个新条目的缓存时长，已按 Jitter 随机化。
+func (r *Resolver) ttl() time.Duration {
+	if r.Jitter <= 0 {
+		return r.TTL
+	}
+	return r.TTL - time.Duration(rand.Float64()*min(r.Jitter, 1)*float64(r.TTL))
+}
+
+func (r *Resolver) resolver() *net.Resolver {
+	if r.Resolver != nil {
+		return r.Resolver
+	}
+	return net.DefaultResolver
+}
+
+// LookupHost 同 net.Resolver.LookupHost。
+func (r *Resolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	return r.host.lookup(ctx, r, host, func(ctx context.Context) ([]string, error) {
+		return r.resolver().LookupHost(ctx, host)
+	}, slices.Clone)
+}
+
+// LookupIPAddr 同 net.Resolver.LookupIPAddr。
+func (r *Resolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	return r.ipa.lookup(ctx, r, host, func(ctx context.Context) ([]net.IPAddr, error) {
+		return r.resolver().LookupIPAddr(ctx, host)
+	}, cloneIPAddrs)
+}
+
+// LookupIP 同 net.Resolver.LookupIP。
+func (r *Resolver) LookupIP(ctx context.Context, network, host string) ([]net.IP, error) {
+	return r.ip.lookup(ctx, r, netHost{network, host}, func(ctx context.Context) ([]net.IP, error) {
+		return r.resolver().LookupIP(ctx, network, host)
+	}, cloneIPs)
+}
+
+// LookupNetIP 同 net.Resolver.LookupNetIP。
+func (r *Resolver) LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error) {
+	return r.netip.lookup(ctx, r, netHost{network, host}, func(ctx context.Context) ([]netip.Addr, error) {
+		return r.resolver().LookupNetIP(ctx, network, host)
+	}, slices.Clone)
+}
+
+// lookupGroup 是单一查询类型的合并组与短期缓存。
+type lookupGroup[K comparable, E any] struct {
+	group singleflight.Group[K, []E]
+
+	mu    sync.Mutex
+	cache map[K]entry[E]
+}
+
+type entry[E any] struct {
+	val     []E
+	expires time.Time
+}
+
+func (l *lookupGroup[K, E]) lookup(
+	ctx context.Context,
+	r *Resolver,
+	key K,
+	fn func(context.Context) ([]E, error),
+	clone func([]E) []E,
+) ([]E, error) {
+	if r.TTL > 0 {
+		if v, ok := l.get(key, r.now()); ok {
+			return clone(v), nil
+		}
+	}
+
+	v, err, _ := l.group.Do(ctx, key, func(ctx context.Context) ([]E, error) {
+		v, err := fn(ctx)
+		if err == nil && r.TTL > 0 {
+			l.set(key, v, r.now().Add(r.ttl()))
+		}
+		return v, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return clone(v), nil
+}
+
+// cloneIPs 深拷贝 ips：net.IP 本身是切片，只复制外层切片仍会共享地址字节。
+func cloneIPs(ips []net.IP) []net.IP {
+	out := make([]net.IP, len(ips))
+	for i, ip := range ips {
+		out[i] = slices.Clone(ip)
+	}
+	return out
+}
+
+// cloneIPAddrs 同 cloneIPs，用于 []net.IPAddr。
+func cloneIPAddrs(addrs []net.IPAddr) []net.IPAddr {
+	out := make([]net.IPAddr, len(addrs))
+	for i, a := range addrs {
+		out[i] = net.IPAddr{IP: slices.Clone(a.IP), Zone: a.Zone}
+	}
+	return out
+}
+
+func (l *lookupGroup[K, E]) get(key K, now time.Time) ([]E, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.cache[key]
+	if !ok {
+		return nil, false
+	}
+	if now.After(e.expires) {
+		delete(l.cache, key)
+		return nil, false
+	}
+	return e.val, true
+}
+
+func (l *lookupGroup[K, E]) set(key K, v []E, expires time.Time) {
+	l.mu.Lock()
+	if l.cache == nil {
+		l.cache = make(map[K]entry[E])
+	}
+	l.cache[key] = entry[E]{val: v, expires: expires}
+	l.mu.Unlock()
+}
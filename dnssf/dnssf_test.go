@@ -0,0 +1,170 @@
+package dnssf
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeDNS 是通过 net.Resolver.Dial 接入的进程内 DNS 服务器，
+// 对每个 A 查询回答 addr 并计数，gate 非 nil 时回答前等待它关闭。
+type fakeDNS struct {
+	addr    net.IP
+	gate    chan struct{}
+	queries atomic.Int32
+}
+
+func (f *fakeDNS) resolver() *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			client, server := net.Pipe()
+			go f.serve(server)
+			return client, nil
+		},
+	}
+}
+
+// serve 以 TCP 分帧（2 字节长度前缀）应答，net.Pipe 不是 PacketConn，解析器会这样发送。
+func (f *fakeDNS) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		var n [2]byte
+		if _, err := io.ReadFull(conn, n[:]); err != nil {
+			return
+		}
+		msg := make([]byte, binary.BigEndian.Uint16(n[:]))
+		if _, err := io.ReadFull(conn, msg); err != nil {
+			return
+		}
+		i := 12
+		for msg[i] != 0 {
+			i += int(msg[i]) + 1
+		}
+		qtype, qend := binary.BigEndian.Uint16(msg[i+1:]), i+5
+
+		var answers uint16
+		if qtype == 1 {
+			answers = 1
+			f.queries.Add(1)
+			if f.gate != nil {
+				<-f.gate
+			}
+		}
+		resp := append([]byte{msg[0], msg[1], 0x81, 0x80, 0, 1, 0, byte(answers), 0, 0, 0, 0}, msg[12:qend]...)
+		if answers > 0 {
+			resp = append(resp, 0xc0, 12, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4)
+			resp = append(resp, f.addr.To4()...)
+		}
+		binary.BigEndian.PutUint16(n[:], uint16(len(resp)))
+		if _, err := conn.Write(append(n[:], resp...)); err != nil {
+			return
+		}
+	}
+}
+
+func TestResolver_CoalescesConcurrentLookups(t *testing.T) {
+	const host, n = "sf.example.test", 8
+	dns := &fakeDNS{addr: net.IPv4(192, 0, 2, 1), gate: make(chan struct{})}
+	r := &Resolver{Resolver: dns.resolver()}
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	results := make([][]net.IP, n)
+	errs := make([]error, n)
+	for i := range n {
+		wg.Go(func() { results[i], errs[i] = r.LookupIP(ctx, "ip4", host) })
+	}
+	// 所有调用都加入同一次查询之后再放行上游。
+	for {
+		if info, ok := r.ip.group.Inspect(netHost{"ip4", host}); ok && info.Waiters == n-1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(dns.gate)
+	wg.Wait()
+
+	if q := dns.queries.Load(); q != 1 {
+		t.Fatalf("upstream queries = %d, want 1", q)
+	}
+	for i := range n {
+		if errs[i] != nil || len(results[i]) != 1 || !results[i][0].Equal(dns.addr) {
+			t.Fatalf("caller %d = %v, %v", i, results[i], errs[i])
+		}
+	}
+	// 每个调用方拿到的地址字节相互独立。
+	ip := results[0][0]
+	ip[len(ip)-1] = 0
+	for i := 1; i < n; i++ {
+		if !results[i][0].Equal(dns.addr) {
+			t.Fatalf("caller %d sees caller 0's mutation: %v", i, results[i][0])
+		}
+	}
+}
+
+func TestResolver_CachedIPsAreDeepCopies(t *testing.T) {
+	dns := &fakeDNS{addr: net.IPv4(192, 0, 2, 1)}
+	r := &Resolver{Resolver: dns.resolver(), TTL: time.Minute}
+	ctx := context.Background()
+
+	first, err := r.LookupIP(ctx, "ip4", "sf.example.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first[0][len(first[0])-1] = 0
+
+	second, err := r.LookupIP(ctx, "ip4", "sf.example.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second[0].Equal(dns.addr) {
+		t.Fatalf("cache was mutated through a returned net.IP: got %v, want %v", second[0], dns.addr)
+	}
+	if q := dns.queries.Load(); q != 1 {
+		t.Fatalf("upstream queries = %d, want 1 with a cached result", q)
+	}
+}
+
+func TestResolver_CachedCopiesAreIndependent(t *testing.T) {
+	r := &Resolver{TTL: time.Minute}
+	ctx := context.Background()
+
+	first, err := r.LookupHost(ctx, "localhost")
+	if err != nil {
+		t.Skipf("localhost not resolvable in this environment: %v", err)
+	}
+	want := first[0]
+	first[0] = "mutated"
+
+	second, err := r.LookupHost(ctx, "localhost")
+	if err != nil {
+		t.Fatalf("cached lookup failed: %v", err)
+	}
+	if second[0] != want {
+		t.Fatalf("cache was mutated through a returned slice: got %q, want %q", second[0], want)
+	}
+}
+
+func TestResolver_TTLJitter(t *testing.T) {
+	r := &Resolver{TTL: time.Minute, Jitter: 0.5}
+	seen := make(map[time.Duration]bool)
+	for range 100 {
+		d := r.ttl()
+		if d < 30*time.Second || d > time.Minute {
+			t.Fatalf("ttl %v outside [30s, 1m]", d)
+		}
+		seen[d] = true
+	}
+	if len(seen) < 2 {
+		t.Fatal("jittered TTLs are all identical")
+	}
+	if d := (&Resolver{TTL: time.Minute}).ttl(); d != time.Minute {
+		t.Fatalf("ttl without jitter = %v", d)
+	}
+}
@@ -0,0 +1,94 @@
+package singleflight
+
+import (
+	"context"
+	"time"
+)
+
+// Doer 是 Group 对外行为的抽象，便于在单元测试中替换为
+// singleflighttest.Mock 等实现。
+type Doer[K comparable, V any] interface {
+	Do(ctx context.Context, key K, fn func(ctx context.Context) (V, error)) (v V, err error, shared bool)
+	DoChan(ctx context.Context, key K, fn func(ctx context.Context) (V, error)) <-chan Result[V]
+	Forget(key K)
+}
+
+var _ Doer[string, any] = (*Group[string, any])(nil)
+
+// Result 是 DoResult / DoChan 返回的结果及其执行元信息。
+type Result[V any] struct {
+	Val    V
+	Err    error
+	Shared bool
+
+	// Leader 表示本调用者亲自执行了 fn。
+	Leader bool
+
+	// Waiters 为结果发布时共享该结果的 Follower 数量（不含 Leader，
+	// 不含提前退出者；发布后、key 移除前才加入的 Follower 同样共享结果但不计入）。
+	// 本调用者未拿到执行结果时为 0。
+	Waiters int
+
+	// LeaderDuration 为 fn 的执行耗时。本调用者未拿到执行结果，
+	// 或执行既非由 DoResult 发起、Group 也未开启 WithTiming 时为 0。
+	LeaderDuration time.Duration
+
+	// LockWait 为本调用等待 Group 内部锁的时长，WakeDelay 为结果就绪到本调用者
+	// 被唤醒的时长（Leader 为 0）。二者与 LeaderDuration 一起把调用耗时拆分为
+	// 协调开销与执行本身，仅在开启 WithLatencyBreakdown 时记录。
+	LockWait  time.Duration
+	WakeDelay time.Duration
+}
+
+func newResult[V any](v V, err error, f flight) Result[V] {
+	return Result[V]{
+		Val:            v,
+		Err:            err,
+		Shared:         f.shared,
+		Leader:         f.leader,
+		Waiters:        f.waiters,
+		LeaderDuration: f.dur,
+		LockWait:       f.lockWait,
+		WakeDelay:      f.wakeDelay,
+	}
+}
+
+// DoResult 与 Do 相同，但返回携带执行元信息的 Result，
+// 便于记录指标或排查问题，而不必在调用点自行计时。
+func (g *Group[K, V]) DoResult(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) (V, error),
+) Result[V] {
+	v, err, f := g.do(ctx, key, fn, &callOpts[V]{timed: true})
+	return newResult(v, err, f)
+}
+
+// DoValue 与 Do 相同，但省略绝大多数调用点都不关心的 shared。
+// 三者构成一个系列：DoValue 最简，Do 与 x/sync 对齐，DoResult 信息最全。
+func (g *Group[K, V]) DoValue(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) (V, error),
+) (V, error) {
+	v, err, _ := g.Do(ctx, key, fn)
+	return v, err
+}
+
+// DoChan 与 Do 相同，但立即返回一个接收结果的 channel，
+// 便于调用方与其他事件一起 select。channel 带一个缓冲，结果发送不会阻塞。
+//
+// 与 x/sync/singleflight 一致：fn 的 panic 发生在内部 goroutine 上，无法被调用方恢复，
+// 会使进程崩溃。
+func (g *Group[K, V]) DoChan(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) (V, error),
+) <-chan Result[V] {
+	ch := make(chan Result[V], 1)
+	go func() {
+		v, err, f := g.do(ctx, key, fn, nil)
+		ch <- newResult(v, err, f)
+	}()
+	return ch
+}
@@ -0,0 +1,79 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDoChan_SharesWithDo(t *testing.T) {
+	joined := make(chan struct{})
+	g := NewGroup[string, int](WithHooks(Hooks[string]{
+		FollowerJoined: func(string) { close(joined) },
+	}))
+	started := make(chan struct{})
+
+	leader := g.DoChan(context.Background(), "k", func(ctx context.Context) (int, error) {
+		close(started)
+		<-joined
+		return 7, nil
+	})
+	<-started
+	follower := g.DoChan(context.Background(), "k", func(ctx context.Context) (int, error) {
+		t.Error("follower must not execute fn")
+		return 0, nil
+	})
+
+	for _, ch := range []<-chan Result[int]{leader, follower} {
+		r := <-ch
+		if r.Val != 7 || r.Err != nil || !r.Shared {
+			t.Fatalf("result = %+v", r)
+		}
+	}
+}
+
+func TestDoValue(t *testing.T) {
+	var g Group[string, int]
+	v, err := g.DoValue(context.Background(), "k", func(ctx context.Context) (int, error) { return 3, nil })
+	if v != 3 || err != nil {
+		t.Fatalf("DoValue = %d, %v", v, err)
+	}
+}
+
+func TestDoResult_Metadata(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	installed := make(chan struct{})
+	joined := make(chan struct{}, 2)
+	g := NewGroup[string, int](
+		WithClock(clock),
+		WithTiming(),
+		WithHooks(Hooks[string]{
+			LeaderInstalled: func(string) { close(installed) },
+			FollowerJoined:  func(string) { joined <- struct{}{} },
+		}),
+	)
+
+	leader := make(chan Result[int])
+	go func() {
+		leader <- g.DoResult(context.Background(), "k", func(ctx context.Context) (int, error) {
+			<-joined
+			<-joined
+			clock.Advance(3 * time.Second)
+			return 9, nil
+		})
+	}()
+	<-installed
+	f1 := g.DoChan(context.Background(), "k", nil)
+	f2 := g.DoChan(context.Background(), "k", nil)
+
+	lr := <-leader
+	if !lr.Leader || !lr.Shared || lr.Waiters != 2 || lr.LeaderDuration != 3*time.Second || lr.Val != 9 {
+		t.Fatalf("leader result = %+v", lr)
+	}
+	for _, ch := range []<-chan Result[int]{f1, f2} {
+		r := <-ch
+		if r.Leader || !r.Shared || r.Waiters != 2 || r.LeaderDuration != 3*time.Second {
+			t.Fatalf("follower result = %+v", r)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+)
+
+// Drain 阻塞直到没有执行在进行（包括已被 Forget 但仍在运行的），
+// 或 ctx 结束时返回 context.Cause(ctx)。
+//
+// 与 Close 不同，Drain 不拒绝新的调用，返回时可能已有新的执行开始；
+// 需要真正静止的窗口（快照、迁移、有序关闭）时先调用 Pause。
+func (g *Group[K, V]) Drain(ctx context.Context) error {
+	g.mu.Lock()
+	if g.running == 0 {
+		g.mu.Unlock()
+		return nil
+	}
+	if g.quiet == nil {
+		g.quiet = make(chan struct{})
+	}
+	quiet := g.quiet
+	g.mu.Unlock()
+
+	select {
+	case <-quiet:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// Pause 暂停发起新的执行：调用 resume 之前，本应成为 Leader 的调用者
+// 等待恢复（或自身 ctx 结束），已在进行的执行照常完成并可被加入。
+// 多次 Pause 需要各自 resume，resume 可重复调用。
+//
+//	resume := g.Pause()
+//	defer resume()
+//	if err := g.Drain(ctx); err != nil { ... }
+func (g *Group[K, V]) Pause() (resume func()) {
+	g.mu.Lock()
+	if g.paused == 0 {
+		g.resumed = make(chan struct{})
+	}
+	g.paused++
+	g.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			g.mu.Lock()
+			if g.paused--; g.paused == 0 {
+				close(g.resumed)
+				g.resumed = nil
+			}
+			g.mu.Unlock()
+		})
+	}
+}
+
+// awaitResume 让暂停期间本应成为 Leader 的调用者等待恢复后重新调用。
+// 调用时必须持有 g.mu，返回前释放。
+func (g *Group[K, V]) awaitResume(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) (V, error),
+	co *callOpts[V],
+) (V, error, flight) {
+	resumed := g.resumed
+	g.mu.Unlock()
+	select {
+	case <-resumed:
+		return g.do(ctx, key, fn, co)
+	case <-ctx.Done():
+		return g.cancelled(ctx, key, co, flight{})
+	}
+}
@@ -0,0 +1,67 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDrain(t *testing.T) {
+	var g Group[string, int]
+	if err := g.Drain(context.Background()); err != nil {
+		t.Fatalf("idle Drain = %v", err)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	res := g.DoChan(context.Background(), "k", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	// 被 Forget 的执行仍在运行，Drain 同样要等待它。
+	g.Forget("k")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := g.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Drain with a running call = %v", err)
+	}
+
+	drained := make(chan error, 1)
+	go func() { drained <- g.Drain(context.Background()) }()
+	close(release)
+	<-res
+	if err := <-drained; err != nil {
+		t.Fatalf("Drain = %v", err)
+	}
+}
+
+func TestPause(t *testing.T) {
+	var g Group[string, int]
+	resume := g.Pause()
+
+	ran := make(chan struct{})
+	res := g.DoChan(context.Background(), "k", func(context.Context) (int, error) {
+		close(ran)
+		return 2, nil
+	})
+	select {
+	case <-ran:
+		t.Fatal("new execution started while paused")
+	case <-time.After(10 * time.Millisecond):
+	}
+	resume()
+	resume()
+	if r := <-res; r.Val != 2 {
+		t.Fatalf("after resume got %+v", r)
+	}
+	g.mu.Lock()
+	paused := g.paused
+	g.mu.Unlock()
+	if paused != 0 {
+		t.Fatalf("paused = %d after resume, want 0", paused)
+	}
+}
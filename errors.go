@@ -0,0 +1,79 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+)
+
+// Group 返回的哨兵错误，调用方应使用 errors.Is 判断。
+//
+// 除 ErrExecTimeout 外，它们都由 Group 自身产生而非来自 fn 的执行，
+// IsExecutionError 对它们返回 false。
+var (
+	// ErrWaiterCancelled 表示调用者自身的 context 在获得结果前结束。
+	// 返回的错误为 *WaitError，同时满足 errors.Is(err, context.Canceled / DeadlineExceeded)。
+	ErrWaiterCancelled = newGroupError("singleflight: waiter cancelled")
+
+	// ErrGroupClosed 表示 Group 已被 Close。
+	ErrGroupClosed = newGroupError("singleflight: group closed")
+
+	// ErrInFlight 表示 key 已有执行在进行，由 TryDo 返回。
+	ErrInFlight = newGroupError("singleflight: call in flight")
+
+	// ErrTooManyWaiters 表示等待者数量已达到 WithMaxWaiters 的上限。
+	ErrTooManyWaiters = newGroupError("singleflight: too many waiters")
+
+	// ErrForgotten 表示等待期间 key 被 Forget，且策略为 ForgetSignal。
+	ErrForgotten = newGroupError("singleflight: key forgotten while waiting")
+
+	// ErrExecTimeout 是 WithExecTimeout 取消 Leader 时设置的 context cause。
+	// 它描述的是共享执行本身的失败，因此属于执行错误。
+	ErrExecTimeout = errors.New("singleflight: execution timeout")
+)
+
+// groupError 标记由 Group 自身产生的哨兵错误。
+type groupError struct{ msg string }
+
+func newGroupError(msg string) error { return &groupError{msg: msg} }
+
+func (e *groupError) Error() string { return e.msg }
+
+// IsExecutionError 报告 err 是否来自共享的 fn 执行（包括执行超时），
+// 而非调用者的等待被取消或 Group 拒绝了调用。
+//
+// 两者的处理方式通常截然不同：执行错误可以重试，
+// 而等待错误意味着应当尊重调用者自己的取消。
+func IsExecutionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var ge *groupError
+	return !errors.As(err, &ge)
+}
+
+// WaitError 表示调用者自身的 context 在等待结果期间结束，
+// 共享的执行可能仍在进行并最终成功。
+type WaitError struct {
+	// Err 为 ctx.Err()。
+	Err error
+	// Cause 为 context.Cause(ctx)，未设置 cause 时与 Err 相同。
+	Cause error
+}
+
+// waitError 把调用者 context 的结束原因包装为 *WaitError。
+// 返回 context.Cause 而非裸的 ctx.Err()，使调用方设置的 cause 能穿透合并层；
+// 当 cause 与 ctx.Err() 不同时两者都可被 errors.Is 匹配。
+func waitError(ctx context.Context) error {
+	return &WaitError{Err: ctx.Err(), Cause: context.Cause(ctx)}
+}
+
+func (e *WaitError) Error() string {
+	return ErrWaiterCancelled.Error() + ": " + e.Cause.Error()
+}
+
+func (e *WaitError) Unwrap() []error {
+	if e.Cause == e.Err {
+		return []error{ErrWaiterCancelled, e.Err}
+	}
+	return []error{ErrWaiterCancelled, e.Cause, e.Err}
+}
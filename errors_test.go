@@ -0,0 +1,112 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestErrors_WaiterCancelled(t *testing.T) {
+	var g Group[string, int]
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err, _ := g.Do(ctx, "k", func(ctx context.Context) (int, error) { return 1, nil })
+	if !errors.Is(err, ErrWaiterCancelled) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestErrors_InFlightAndTooManyWaiters(t *testing.T) {
+	joined := make(chan struct{})
+	g := NewGroup[string, int](
+		WithMaxWaiters(1),
+		WithHooks(Hooks[string]{FollowerJoined: func(string) { close(joined) }}),
+	)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	g.DoChan(context.Background(), "k", func(ctx context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+
+	if _, err := g.TryDo(context.Background(), "k", func(ctx context.Context) (int, error) { return 0, nil }); !errors.Is(err, ErrInFlight) {
+		t.Fatalf("TryDo err = %v, want ErrInFlight", err)
+	}
+
+	g.DoChan(context.Background(), "k", nil)
+	<-joined
+	if _, err, _ := g.Do(context.Background(), "k", nil); !errors.Is(err, ErrTooManyWaiters) {
+		t.Fatalf("Do err = %v, want ErrTooManyWaiters", err)
+	}
+}
+
+func TestErrors_GroupClosed(t *testing.T) {
+	var g Group[string, int]
+	g.Close()
+	if _, err, _ := g.Do(context.Background(), "k", nil); !errors.Is(err, ErrGroupClosed) {
+		t.Fatalf("err = %v, want ErrGroupClosed", err)
+	}
+}
+
+func TestErrors_WaiterCause(t *testing.T) {
+	var g Group[string, int]
+	quota := errors.New("quota exhausted")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(quota)
+
+	_, err, _ := g.Do(ctx, "k", nil)
+	if !errors.Is(err, quota) || !errors.Is(err, context.Canceled) || !errors.Is(err, ErrWaiterCancelled) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestErrors_ExecTimeoutCause(t *testing.T) {
+	g := NewGroup[string, int](WithExecTimeout(time.Millisecond))
+	_, err, _ := g.Do(context.Background(), "k", func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		if context.Cause(ctx) != ErrExecTimeout {
+			t.Errorf("cause = %v", context.Cause(ctx))
+		}
+		return 0, ctx.Err()
+	})
+	if !errors.Is(err, ErrExecTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v", err)
+	}
+	if errors.Is(err, ErrWaiterCancelled) {
+		t.Fatal("execution timeout must not look like a waiter cancellation")
+	}
+}
+
+func TestIsExecutionError(t *testing.T) {
+	boom := errors.New("boom")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{boom, true},
+		{context.Canceled, true}, // fn 自己返回的取消属于执行结果
+		{waitError(ctx), false},
+		{ErrGroupClosed, false},
+		{ErrTooManyWaiters, false},
+		{ErrExecTimeout, true},
+	}
+	for _, c := range cases {
+		if got := IsExecutionError(c.err); got != c.want {
+			t.Errorf("IsExecutionError(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+
+	var we *WaitError
+	if !errors.As(waitError(ctx), &we) || we.Err != context.Canceled {
+		t.Fatalf("WaitError = %+v", we)
+	}
+}
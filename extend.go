@@ -0,0 +1,151 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WithDeadlineExtension 允许 fn 通过 ExtendDeadline 推迟 WithExecTimeout 的超时，
+// 但从执行开始起累计不超过 max。适用于大分页、分块下载等能够感知自身进度的执行：
+// 固定的超时要么太短而在中途误杀，要么太长而无法及时发现卡死。
+//
+// 只在同时配置了 WithExecTimeout 时生效，超时计时使用 Group 的 Clock。
+// max <= 0 表示不允许延长。
+func WithDeadlineExtension(max time.Duration) Option {
+	return func(o *options) { o.maxExtension = max }
+}
+
+// ExtendDeadline 把 ctx 所属执行的超时推迟到不早于 d 之后，返回是否推迟成功。
+// ctx 必须是 fn 收到的 ctx（或其派生），且 Group 开启了 WithDeadlineExtension；
+// 否则，或执行已超时、已达到上限时返回 false。
+func ExtendDeadline(ctx context.Context, d time.Duration) bool {
+	x, ok := ctx.Value(extendableKey{}).(*extendableCtx)
+	if !ok {
+		return false
+	}
+	return x.extend(d)
+}
+
+type extendableKey struct{}
+
+// extendableCtx 是截止时间可以推迟的执行 ctx。取消由内部的 cancel ctx 完成，
+// 以便 context.Cause 照常工作；超时后 Err 返回 DeadlineExceeded，
+// 与 context.WithTimeoutCause 一致。
+//
+// Done 返回自己的 channel 并实现 AfterFunc，派生的 ctx 因此不会直接挂到内部的
+// cancel ctx 上（那样它们的 Err 总是 Canceled），而是经 AfterFunc 以本 ctx 的 Err
+// 取消，超时时同样得到 DeadlineExceeded。
+type extendableCtx struct {
+	context.Context
+	cancel context.CancelCauseFunc
+	clock  Clock
+	done   chan struct{} // 内部 ctx 结束、Err 已确定之后关闭
+
+	mu       sync.Mutex
+	deadline time.Time
+	limit    time.Time
+	timer    Timer
+	expired  bool
+}
+
+func (g *Group[K, V]) withExtendableTimeout(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	clock := clockOrSystem(g.cfg.clock)
+	now := clock.Now()
+	inner, cancel := context.WithCancelCause(parent)
+	x := &extendableCtx{
+		Context:  inner,
+		cancel:   cancel,
+		clock:    clock,
+		deadline: now.Add(timeout),
+		limit:    now.Add(g.cfg.maxExtension),
+		done:     make(chan struct{}),
+	}
+	context.AfterFunc(inner, func() { close(x.done) })
+	x.mu.Lock()
+	x.timer = clock.AfterFunc(timeout, x.expire)
+	x.mu.Unlock()
+	return x, func() {
+		x.mu.Lock()
+		x.timer.Stop()
+		x.mu.Unlock()
+		cancel(context.Canceled)
+	}
+}
+
+func (x *extendableCtx) Deadline() (time.Time, bool) {
+	x.mu.Lock()
+	d := x.deadline
+	x.mu.Unlock()
+	if pd, ok := x.Context.Deadline(); ok && pd.Before(d) {
+		return pd, true
+	}
+	return d, true
+}
+
+func (x *extendableCtx) Done() <-chan struct{} { return x.done }
+
+func (x *extendableCtx) Err() error {
+	select {
+	case <-x.done:
+	default:
+		return nil
+	}
+	x.mu.Lock()
+	expired := x.expired
+	x.mu.Unlock()
+	if expired {
+		return context.DeadlineExceeded
+	}
+	return x.Context.Err()
+}
+
+// AfterFunc 供 context 包登记派生的 ctx，f 在 Done 关闭之后调用。
+func (x *extendableCtx) AfterFunc(f func()) func() bool {
+	return context.AfterFunc(x.Context, func() {
+		<-x.done
+		f()
+	})
+}
+
+func (x *extendableCtx) Value(key any) any {
+	if key == (extendableKey{}) {
+		return x
+	}
+	return x.Context.Value(key)
+}
+
+func (x *extendableCtx) expire() {
+	x.mu.Lock()
+	// 定时器触发与 extend 的 Reset 竞争时，以记录的截止时间为准。
+	if now := x.clock.Now(); now.Before(x.deadline) {
+		x.timer.Reset(x.deadline.Sub(now))
+		x.mu.Unlock()
+		return
+	}
+	x.expired = x.Context.Err() == nil
+	x.mu.Unlock()
+	x.cancel(ErrExecTimeout)
+}
+
+func (x *extendableCtx) extend(d time.Duration) bool {
+	if d <= 0 {
+		return false
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.expired || x.Context.Err() != nil {
+		return false
+	}
+	now := x.clock.Now()
+	next := now.Add(d)
+	if next.After(x.limit) {
+		next = x.limit
+	}
+	if !next.After(x.deadline) {
+		return false
+	}
+	x.deadline = next
+	x.timer.Reset(next.Sub(now))
+	return true
+}
@@ -0,0 +1,68 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExtendDeadline(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := NewFakeClock(start)
+	g := NewGroup[string, int](
+		WithClock(clock),
+		WithExecTimeout(time.Second),
+		WithDeadlineExtension(3*time.Second),
+	)
+	fnCtx := make(chan context.Context, 1)
+	derived := make(chan error, 1)
+	res := g.DoChan(context.Background(), "k", func(ctx context.Context) (int, error) {
+		fnCtx <- ctx
+		child, cancel := context.WithCancel(ctx)
+		defer cancel()
+		<-child.Done()
+		derived <- child.Err()
+		return 0, ctx.Err()
+	})
+	ctx := <-fnCtx
+
+	clock.Advance(900 * time.Millisecond)
+	if !ExtendDeadline(ctx, time.Second) {
+		t.Fatal("extension within the limit must succeed")
+	}
+	if d, _ := ctx.Deadline(); !d.Equal(start.Add(1900 * time.Millisecond)) {
+		t.Fatalf("deadline = %v", d.Sub(start))
+	}
+	clock.Advance(900 * time.Millisecond)
+	if ctx.Err() != nil {
+		t.Fatal("extended execution timed out at the original deadline")
+	}
+
+	// 延长不能超过从执行开始起的上限。
+	if !ExtendDeadline(ctx, 5*time.Second) {
+		t.Fatal("extension up to the limit must succeed")
+	}
+	if d, _ := ctx.Deadline(); !d.Equal(start.Add(3 * time.Second)) {
+		t.Fatalf("deadline = %v, want capped at 3s", d.Sub(start))
+	}
+	clock.Advance(1200 * time.Millisecond)
+
+	r := <-res
+	if !errors.Is(r.Err, ErrExecTimeout) || !errors.Is(r.Err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v", r.Err)
+	}
+	// 派生的 ctx 与 context.WithTimeoutCause 的派生一致。
+	if err := <-derived; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("derived ctx err = %v, want DeadlineExceeded", err)
+	}
+	if cause := context.Cause(ctx); !errors.Is(cause, ErrExecTimeout) {
+		t.Fatalf("cause = %v", cause)
+	}
+	if ExtendDeadline(ctx, time.Second) {
+		t.Fatal("extending an expired execution must fail")
+	}
+	if ExtendDeadline(context.Background(), time.Second) {
+		t.Fatal("extending a foreign context must fail")
+	}
+}
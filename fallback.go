@@ -0,0 +1,24 @@
+package singleflight
+
+import "context"
+
+// DoWithFallback 与 Do 相同，但 fn 返回错误时在同一次执行内调用 fallback，
+// 例如改读陈旧的副本或快照文件。fallback 收到 fn 的错误，其结果即本次执行的结果，
+// 因此与 fn 一样只执行一次并由所有等待者共享；在 Group 外组合则每个等待者都会各自执行一遍。
+//
+// fn 的 panic 不会触发 fallback。加入他人发起的执行时，使用的是该执行的 fn 与 fallback。
+func (g *Group[K, V]) DoWithFallback(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) (V, error),
+	fallback func(ctx context.Context, err error) (V, error),
+) (v V, err error, shared bool) {
+	v, err, f := g.do(ctx, key, func(ctx context.Context) (V, error) {
+		v, err := fn(ctx)
+		if err != nil {
+			return fallback(ctx, err)
+		}
+		return v, nil
+	}, nil)
+	return v, err, f.shared
+}
@@ -0,0 +1,64 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDoWithFallback_RunsOnceForAllWaiters(t *testing.T) {
+	const callers = 5
+	var joined sync.WaitGroup
+	joined.Add(callers - 1)
+	g := NewGroup[string, string](WithHooks(Hooks[string]{
+		FollowerJoined: func(string) { joined.Done() },
+	}))
+
+	primary := errors.New("primary down")
+	var fallbacks atomic.Int32
+	started := make(chan struct{})
+	fn := func(ctx context.Context) (string, error) {
+		close(started)
+		joined.Wait()
+		return "", primary
+	}
+	fallback := func(ctx context.Context, err error) (string, error) {
+		if !errors.Is(err, primary) {
+			t.Errorf("fallback got %v", err)
+		}
+		fallbacks.Add(1)
+		return "snapshot", nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]string, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i > 0 {
+				<-started
+			}
+			v, err, _ := g.DoWithFallback(context.Background(), "k", fn, fallback)
+			if err != nil {
+				t.Errorf("caller %d: %v", i, err)
+			}
+			results[i] = v
+		}()
+		if i == 0 {
+			<-started
+		}
+	}
+	wg.Wait()
+
+	if n := fallbacks.Load(); n != 1 {
+		t.Fatalf("fallback ran %d times", n)
+	}
+	for i, v := range results {
+		if v != "snapshot" {
+			t.Fatalf("caller %d got %q", i, v)
+		}
+	}
+}
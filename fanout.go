@@ -0,0 +1,79 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// FanOut 组合 errgroup 与 singleflight：按 key 扇出任务，
+// 重复的 key 只执行一次，并发度可通过 SetLimit 约束。
+//
+// 同一个 FanOut 内重复 Go 同一个 key 会被直接忽略；
+// 若多个 FanOut 共享同一个 Group，跨 FanOut 的并发执行也会被合并。
+type FanOut[K comparable, V any] struct {
+	group *Group[K, V]
+	eg    *errgroup.Group
+	ctx   context.Context
+
+	mu      sync.Mutex
+	seen    map[K]struct{}
+	results map[K]V
+}
+
+// NewFanOut 创建一个 FanOut。g 为 nil 时使用私有 Group。
+//
+// 与 errgroup.WithContext 一致：任一任务返回错误时 ctx 被取消，
+// 尚未开始的任务会以 ctx.Err() 快速失败。
+func NewFanOut[K comparable, V any](ctx context.Context, g *Group[K, V]) *FanOut[K, V] {
+	if g == nil {
+		g = new(Group[K, V])
+	}
+	eg, ctx := errgroup.WithContext(ctx)
+	return &FanOut[K, V]{
+		group:   g,
+		eg:      eg,
+		ctx:     ctx,
+		seen:    make(map[K]struct{}),
+		results: make(map[K]V),
+	}
+}
+
+// SetLimit 限制同时执行的任务数，n < 0 表示不限制。
+// 语义与 errgroup.Group.SetLimit 相同，必须在首次 Go 之前调用。
+func (f *FanOut[K, V]) SetLimit(n int) {
+	f.eg.SetLimit(n)
+}
+
+// Go 为 key 提交一个任务。同一 key 的后续提交被忽略。
+// 达到并发上限时 Go 会阻塞，直到有任务完成。
+func (f *FanOut[K, V]) Go(key K, fn func(ctx context.Context) (V, error)) {
+	f.mu.Lock()
+	if _, ok := f.seen[key]; ok {
+		f.mu.Unlock()
+		return
+	}
+	f.seen[key] = struct{}{}
+	f.mu.Unlock()
+
+	f.eg.Go(func() error {
+		v, err, _ := f.group.Do(f.ctx, key, fn)
+		if err != nil {
+			return err
+		}
+		f.mu.Lock()
+		f.results[key] = v
+		f.mu.Unlock()
+		return nil
+	})
+}
+
+// Wait 等待所有任务结束，返回成功任务的结果和首个错误。
+// 即使返回错误，已成功的 key 仍保留在结果中。
+func (f *FanOut[K, V]) Wait() (map[K]V, error) {
+	err := f.eg.Wait()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.results, err
+}
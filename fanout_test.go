@@ -0,0 +1,50 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFanOut_DedupAndCollect(t *testing.T) {
+	f := NewFanOut[int, int](context.Background(), nil)
+	f.SetLimit(2)
+
+	var execs atomic.Int32
+	for _, k := range []int{1, 2, 3, 1, 2, 3, 1} {
+		f.Go(k, func(ctx context.Context) (int, error) {
+			execs.Add(1)
+			return k * 10, nil
+		})
+	}
+
+	res, err := f.Wait()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := execs.Load(); n != 3 {
+		t.Fatalf("executions = %d, want 3", n)
+	}
+	for _, k := range []int{1, 2, 3} {
+		if res[k] != k*10 {
+			t.Fatalf("res[%d] = %d, want %d", k, res[k], k*10)
+		}
+	}
+}
+
+func TestFanOut_FirstError(t *testing.T) {
+	boom := errors.New("boom")
+	f := NewFanOut[string, string](context.Background(), nil)
+
+	f.Go("ok", func(ctx context.Context) (string, error) { return "v", nil })
+	f.Go("bad", func(ctx context.Context) (string, error) { return "", boom })
+
+	res, err := f.Wait()
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want %v", err, boom)
+	}
+	if _, ok := res["bad"]; ok {
+		t.Fatal("failed key must not appear in results")
+	}
+}
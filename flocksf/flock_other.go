@@ -0,0 +1,13 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package flocksf
+
+import (
+	"errors"
+	"os"
+)
+
+// tryLock 在没有 flock 的平台上总是失败，TieredGroup 可按 FailOpen 降级。
+func tryLock(*os.File) (bool, error) {
+	return false, errors.ErrUnsupported
+}
@@ -0,0 +1,73 @@
+// Package flocksf 为同一台主机上的多个进程提供 singleflight.LockBackend，
+// 基于共享目录中的 flock 文件锁，协调各进程的 Leader 而不经过任何网络。
+//
+// 适用于 prefork 服务器、sidecar 等单机多进程部署。flock 由内核维护，
+// 持锁进程退出（包括崩溃）时锁立即释放，因此不需要依赖 TTL 兜底。
+//
+// 只有锁时，未抢到锁的进程在锁释放后仍会自己执行一次 fn。把 Store 设为
+// TieredGroup.Results 后，持锁进程发布的结果经共享目录传给等待过的进程；
+// 目录位于 tmpfs（如 Linux 的 /dev/shm）时结果只经过共享内存，不落盘。
+//
+// 与共享内存段加 futex 的方案相比，这里没有唤醒机制：等待方仍按
+// TieredGroup.PollInterval 轮询锁，结果最多晚一个轮询间隔被看到。
+package flocksf
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Backend 是基于文件锁的 singleflight.LockBackend。
+//
+// key 按哈希分配到固定数量的锁文件上，目录中的文件数量因此有界；
+// 落在同一个桶上的不同 key 会互相等待，但不影响正确性。
+type Backend struct {
+	// Dir 为存放锁文件的目录，参与协调的进程必须使用同一个目录。
+	Dir string
+
+	// Buckets 为锁文件数量，所有进程必须一致。默认 1024。
+	Buckets int
+}
+
+// New 创建使用 dir 的 Backend，dir 不存在时创建。
+func New(dir string) (*Backend, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &Backend{Dir: dir}, nil
+}
+
+// TryLock 实现 singleflight.LockBackend。ttl 被忽略：锁随持有者的文件描述符释放。
+func (b *Backend) TryLock(ctx context.Context, key string, ttl time.Duration) (unlock func(context.Context) error, acquired bool, err error) {
+	f, err := os.OpenFile(b.path(key), os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, false, err
+	}
+	acquired, err = tryLock(f)
+	if err != nil || !acquired {
+		f.Close()
+		return nil, false, err
+	}
+	// 关闭描述符即释放锁，无需单独解锁。
+	return func(context.Context) error { return f.Close() }, true, nil
+}
+
+// path 返回 key 所在桶的锁文件。
+func (b *Backend) path(key string) string {
+	return bucketPath(b.Dir, b.Buckets, key, ".lock")
+}
+
+// bucketPath 返回 key 在 dir 中所在桶的文件，buckets <= 0 时取 1024。
+// 哈希必须跨进程稳定，因此不能使用 maphash。
+func bucketPath(dir string, buckets int, key, ext string) string {
+	if buckets <= 0 {
+		buckets = 1024
+	}
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return filepath.Join(dir, fmt.Sprintf("%04x", h.Sum64()%uint64(buckets))+ext)
+}
@@ -0,0 +1,130 @@
+package flocksf
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+var _ singleflight.LockBackend = (*Backend)(nil)
+
+func TestBackend(t *testing.T) {
+	dir := t.TempDir()
+	// 两个 Backend 模拟两个进程：各自打开锁文件，互相排斥。
+	a, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &Backend{Dir: dir}
+	ctx := context.Background()
+
+	unlock, ok, err := a.TryLock(ctx, "k", time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("first TryLock = %v, %v", ok, err)
+	}
+	if _, ok, err := b.TryLock(ctx, "k", time.Minute); err != nil || ok {
+		t.Fatalf("second TryLock = %v, %v; want contended", ok, err)
+	}
+	if err := unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	unlock, ok, err = b.TryLock(ctx, "k", time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("TryLock after unlock = %v, %v", ok, err)
+	}
+	unlock(ctx)
+}
+
+var _ singleflight.ResultStore = (*Store)(nil)
+
+func TestStore(t *testing.T) {
+	clock := singleflight.NewFakeClock(time.Unix(100, 0))
+	s, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Clock, s.Buckets = clock, 1
+	ctx := context.Background()
+
+	if _, ok, err := s.Lookup(ctx, "k"); ok || err != nil {
+		t.Fatalf("empty Lookup = %v, %v", ok, err)
+	}
+	if err := s.Publish(ctx, "k", []byte("moon"), time.Second); err != nil {
+		t.Fatal(err)
+	}
+	// 另一个 Store 模拟另一个进程。
+	other := &Store{Dir: s.Dir, Buckets: 1, Clock: clock}
+	if data, ok, err := other.Lookup(ctx, "k"); !ok || err != nil || string(data) != "moon" {
+		t.Fatalf("Lookup = %q, %v, %v", data, ok, err)
+	}
+	// 同一个桶上的其他 key 读不到它。
+	if _, ok, _ := other.Lookup(ctx, "other"); ok {
+		t.Fatal("result visible under a different key")
+	}
+	clock.Advance(time.Second)
+	if _, ok, _ := other.Lookup(ctx, "k"); ok {
+		t.Fatal("expired result still visible")
+	}
+}
+
+// waitingBackend 记录未抢到锁的次数，测试据此确认有进程在等锁。
+type waitingBackend struct {
+	*Backend
+	contended atomic.Int32
+}
+
+func (b *waitingBackend) TryLock(ctx context.Context, key string, ttl time.Duration) (func(context.Context) error, bool, error) {
+	unlock, ok, err := b.Backend.TryLock(ctx, key, ttl)
+	if err == nil && !ok {
+		b.contended.Add(1)
+	}
+	return unlock, ok, err
+}
+
+func TestStore_SharesResultAcrossProcesses(t *testing.T) {
+	dir := t.TempDir()
+	// 每个 TieredGroup 使用自己的 Backend 与 Store，模拟两个进程。
+	process := func() (*singleflight.TieredGroup[string, string], *waitingBackend) {
+		b := &waitingBackend{Backend: &Backend{Dir: dir}}
+		tg := singleflight.NewTieredGroup[string, string](b, func(s string) string { return s })
+		tg.PollInterval = time.Millisecond
+		tg.Results = &Store{Dir: dir}
+		return tg, b
+	}
+	a, _ := process()
+	b, bBackend := process()
+	ctx := context.Background()
+
+	started, release, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		a.Do(ctx, "k", func(context.Context) (string, error) {
+			close(started)
+			<-release
+			return "moon", nil
+		})
+	}()
+	<-started
+	res := make(chan string)
+	go func() {
+		v, err, _ := b.Do(ctx, "k", func(context.Context) (string, error) {
+			t.Error("waiting process executed fn despite a published result")
+			return "", nil
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		res <- v
+	}()
+	for bBackend.contended.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	<-done
+	if v := <-res; v != "moon" {
+		t.Fatalf("waiting process got %q", v)
+	}
+}
@@ -0,0 +1,97 @@
+package flocksf
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+// Store 是基于共享目录的 singleflight.ResultStore，与 Backend 配合使用：
+// 持锁进程把结果写入目录，等待过锁的进程从中读取，而不必各自再执行一次。
+//
+// key 与 Backend 一样按哈希分配到固定数量的结果文件上，每个文件只保存最近一次
+// 发布的结果，落在同一个桶上的其他 key 读不到它而各自执行，但不影响正确性。
+// 发布先写临时文件再改名，读取方不会看到写了一半的结果。
+type Store struct {
+	// Dir 为存放结果文件的目录，参与协调的进程必须使用同一个目录，
+	// 可以与 Backend 的目录相同。
+	Dir string
+
+	// Buckets 为结果文件数量，所有进程必须一致。默认 1024。
+	Buckets int
+
+	// Clock 用于判断结果是否过期，nil 时使用 singleflight.SystemClock。
+	// 各进程的时钟必须一致，同一台主机上的系统时钟即满足。
+	Clock singleflight.Clock
+}
+
+// NewStore 创建使用 dir 的 Store，dir 不存在时创建。
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &Store{Dir: dir}, nil
+}
+
+// Publish 实现 singleflight.ResultStore。
+//
+// 结果文件的格式为 8 字节过期时间（Unix 纳秒）、4 字节 key 长度、key 与 data，均为大端。
+func (s *Store) Publish(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	buf := make([]byte, 12, 12+len(key)+len(data))
+	binary.BigEndian.PutUint64(buf, uint64(s.now().Add(ttl).UnixNano()))
+	binary.BigEndian.PutUint32(buf[8:], uint32(len(key)))
+	buf = append(append(buf, key...), data...)
+
+	path := bucketPath(s.Dir, s.Buckets, key, ".result")
+	f, err := os.CreateTemp(s.Dir, filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(buf)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// Lookup 实现 singleflight.ResultStore。
+func (s *Store) Lookup(ctx context.Context, key string) (data []byte, ok bool, err error) {
+	buf, err := os.ReadFile(bucketPath(s.Dir, s.Buckets, key, ".result"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if len(buf) < 12 {
+		return nil, false, errors.New("flocksf: truncated result file")
+	}
+	expires := time.Unix(0, int64(binary.BigEndian.Uint64(buf)))
+	n := int(binary.BigEndian.Uint32(buf[8:]))
+	if len(buf)-12 < n {
+		return nil, false, errors.New("flocksf: truncated result file")
+	}
+	if !bytes.Equal(buf[12:12+n], []byte(key)) || !s.now().Before(expires) {
+		return nil, false, nil
+	}
+	return buf[12+n:], true, nil
+}
+
+func (s *Store) now() time.Time {
+	if s.Clock != nil {
+		return s.Clock.Now()
+	}
+	return time.Now()
+}
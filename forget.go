@@ -0,0 +1,23 @@
+package singleflight
+
+// ForgetPolicy 决定 key 在执行期间被 Forget 时，正在等待的 Follower 如何处理。
+type ForgetPolicy int
+
+const (
+	// ForgetShare 为默认策略：Follower 照常收到被遗忘那次执行的结果，
+	// 与 x/sync/singleflight 一致。
+	ForgetShare ForgetPolicy = iota
+
+	// ForgetSignal 让 Follower 立即收到 ErrForgotten，
+	// 适用于 Forget 表示"结果已失效"的严格失效语义。
+	ForgetSignal
+
+	// ForgetRetry 让 Follower 立即重新调用 Do：加入新的执行或自己成为 Leader。
+	ForgetRetry
+)
+
+// WithForgetPolicy 设置 Forget 对等待中 Follower 的影响。
+// 非默认策略下 Follower 总是通过 channel 等待，即使使用 context.Background()。
+func WithForgetPolicy(p ForgetPolicy) Option {
+	return func(o *options) { o.forgetPolicy = p }
+}
@@ -0,0 +1,46 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// forgetScenario 让一个 Follower 加入后 Forget，再放行原 Leader。
+func forgetScenario(t *testing.T, policy ForgetPolicy, follower func(ctx context.Context) (int, error)) (int, error) {
+	t.Helper()
+	joined := make(chan struct{})
+	g := NewGroup[string, int](
+		WithForgetPolicy(policy),
+		WithHooks(Hooks[string]{FollowerJoined: func(string) { close(joined) }}),
+	)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	g.DoChan(context.Background(), "k", func(ctx context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+
+	res := g.DoChan(context.Background(), "k", follower)
+	<-joined
+	g.Forget("k")
+	r := <-res
+	return r.Val, r.Err
+}
+
+func TestForgetPolicy_Signal(t *testing.T) {
+	_, err := forgetScenario(t, ForgetSignal, nil)
+	if !errors.Is(err, ErrForgotten) {
+		t.Fatalf("err = %v, want ErrForgotten", err)
+	}
+}
+
+func TestForgetPolicy_Retry(t *testing.T) {
+	v, err := forgetScenario(t, ForgetRetry, func(ctx context.Context) (int, error) { return 2, nil })
+	if err != nil || v != 2 {
+		t.Fatalf("retry = %d, %v; want re-executed value 2", v, err)
+	}
+}
@@ -0,0 +1,46 @@
+package singleflight
+
+import (
+	"context"
+	"time"
+)
+
+type minFreshnessKey struct{}
+
+// WithMinFreshness 返回要求结果不早于 t 的 ctx：以它调用 Do 时，只加入在 t
+// 或之后开始的执行，否则为本调用发起新的执行，取代旧执行成为该 key 的当前执行。
+// 已加入旧执行的调用者照常拿到旧结果，之后到达的调用者加入新执行。
+//
+// 适用于读己之写：写入后以写入时刻调用，跳过写入之前开始的计算，
+// 而不必为所有人 Forget 该 key。执行开始时间来自 Group 的时钟，由带 WithMinFreshness
+// 的调用发起的执行总会记录，同一时限的并发调用因此仍然合并；其他执行只在开启
+// WithTiming（或由 DoResult 发起）时记录，未记录的执行一律视为过旧。
+// DoDetachedWait 保留的结果会被跳过；熔断、限频等按 key 的策略照常生效。
+func WithMinFreshness(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, minFreshnessKey{}, t)
+}
+
+// MinFreshnessFrom 返回 ctx 上由 WithMinFreshness 设置的时间。
+func MinFreshnessFrom(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(minFreshnessKey{}).(time.Time)
+	return t, ok
+}
+
+// timedCall 返回要求记录执行开始时间的 co，新执行的开始时间是后来者判断能否加入它的依据。
+func timedCall[V any](co *callOpts[V]) *callOpts[V] {
+	if co != nil && co.timed {
+		return co
+	}
+	q := new(callOpts[V])
+	if co != nil {
+		*q = *co
+	}
+	q.timed = true
+	return q
+}
+
+// supersedeLocked 让 key 的当前执行 c 让位给即将发起的新执行。
+func (g *Group[K, V]) supersedeLocked(key K, c *call[V]) {
+	c.superseded = true
+	g.forgetLocked(key, c)
+}
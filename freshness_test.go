@@ -0,0 +1,115 @@
+package singleflight
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithMinFreshness(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	joined := make(chan struct{}, 1)
+	g := NewGroup[string, int](
+		WithClock(clock),
+		WithTiming(),
+		WithForgetPolicy(ForgetSignal),
+		WithHooks(Hooks[string]{FollowerJoined: func(string) { joined <- struct{}{} }}),
+	)
+	ctx := context.Background()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	old := g.DoChan(ctx, "k", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	oldFollower := g.DoChan(ctx, "k", nil)
+	<-joined
+
+	// 写入发生在旧执行开始之后：旧执行不满足新鲜度要求。
+	clock.Advance(time.Second)
+	write := clock.Now()
+	fresh, _, shared := g.Do(WithMinFreshness(ctx, write), "k", func(context.Context) (int, error) { return 2, nil })
+	if fresh != 2 || shared {
+		t.Fatalf("fresh call got %d, shared=%v; want a new execution", fresh, shared)
+	}
+
+	close(release)
+	if r := <-old; r.Val != 1 {
+		t.Fatalf("old leader got %+v", r)
+	}
+	// 被取代不是被 Forget，即使策略为 ForgetSignal，已加入的 Follower 也拿到旧结果。
+	if r := <-oldFollower; r.Val != 1 || r.Err != nil {
+		t.Fatalf("old follower got %+v", r)
+	}
+}
+
+func TestWithMinFreshness_JoinsRecentExecution(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	g := NewGroup[string, int](WithClock(clock), WithTiming())
+	ctx := context.Background()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	leader := g.DoChan(ctx, "k", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	follower := g.DoChan(WithMinFreshness(ctx, clock.Now()), "k", func(context.Context) (int, error) {
+		t.Error("an execution started at the required time must be joined")
+		return 0, nil
+	})
+	for {
+		if info, _ := g.Inspect("k"); info.Waiters == 1 {
+			break
+		}
+		runtime.Gosched()
+	}
+	close(release)
+	<-leader
+	if r := <-follower; r.Val != 1 || !r.Shared {
+		t.Fatalf("follower got %+v", r)
+	}
+}
+
+// 未开启 WithTiming 时，同一时限的并发调用仍然合并为一次执行。
+func TestWithMinFreshness_CoalescesWithoutTiming(t *testing.T) {
+	const n = 10
+	joined := make(chan struct{}, n)
+	g := NewGroup[string, int](WithHooks(Hooks[string]{FollowerJoined: func(string) { joined <- struct{}{} }}))
+	ctx := WithMinFreshness(context.Background(), time.Now())
+
+	var execs atomic.Int32
+	fn := func(context.Context) (int, error) {
+		execs.Add(1)
+		for range n - 1 {
+			<-joined
+		}
+		return 1, nil
+	}
+	var wg sync.WaitGroup
+	var shared atomic.Int32
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, s := g.Do(ctx, "k", fn); s {
+				shared.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if e := execs.Load(); e != 1 {
+		t.Fatalf("executions = %d, want 1", e)
+	}
+	if s := shared.Load(); s != n {
+		t.Fatalf("shared = %d, want %d", s, n)
+	}
+}
@@ -0,0 +1,33 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+)
+
+// defaults 保存包级 Do 使用的默认 Group，以 typeKey[K, V]{} 为 key。
+// typeKey 是零大小类型，装箱为 any 不分配内存，首次使用之后的查找无分配。
+var defaults sync.Map
+
+type typeKey[K comparable, V any] struct{}
+
+// Default 返回 (K, V) 对应的进程范围默认 Group，首次使用时创建（零值配置）。
+func Default[K comparable, V any]() *Group[K, V] {
+	if g, ok := defaults.Load(typeKey[K, V]{}); ok {
+		return g.(*Group[K, V])
+	}
+	g, _ := defaults.LoadOrStore(typeKey[K, V]{}, new(Group[K, V]))
+	return g.(*Group[K, V])
+}
+
+// Do 在 (K, V) 对应的默认 Group 上执行 Group.Do，
+// 供不值得声明并传递 Group 的脚本和小工具使用。
+// 同一进程中以相同 K、V 调用的所有代码共享同一个 Group，key 应带上足以区分用途的前缀。
+func Do[K comparable, V any](ctx context.Context, key K, fn func(ctx context.Context) (V, error)) (v V, err error, shared bool) {
+	return Default[K, V]().Do(ctx, key, fn)
+}
+
+// Forget 在 (K, V) 对应的默认 Group 上执行 Group.Forget。
+func Forget[K comparable, V any](key K) {
+	Default[K, V]().Forget(key)
+}
@@ -0,0 +1,34 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPackageDo(t *testing.T) {
+	type tag struct{ s string }
+	v, err, _ := Do(context.Background(), "k", func(context.Context) (tag, error) { return tag{"x"}, nil })
+	if err != nil || v.s != "x" {
+		t.Fatalf("Do = %v, %v", v, err)
+	}
+	if Default[string, tag]() != Default[string, tag]() {
+		t.Fatal("Default returned different groups for the same types")
+	}
+	if any(Default[string, tag]()) == any(Default[string, int]()) {
+		t.Fatal("different value types share a group")
+	}
+}
+
+func TestPackageDo_NoAllocs(t *testing.T) {
+	if debugBuild {
+		t.Skip("invariant checks box the key")
+	}
+	fn := func(context.Context) (int, error) { return 1, nil }
+	Do(context.Background(), "warm", fn)
+	allocs := testing.AllocsPerRun(100, func() {
+		Do(context.Background(), "k", fn)
+	})
+	if allocs != 0 {
+		t.Fatalf("package Do allocates %.1f times per call", allocs)
+	}
+}
@@ -0,0 +1,27 @@
+package grpcsf
+
+import (
+	"context"
+	"time"
+
+	"github.com/oy3o/singleflight/grpcsf/coordinatorpb"
+)
+
+// Backend 把协调服务用作 singleflight.LockBackend，供只需要集群锁的 TieredGroup 使用。
+// 释放锁时不发布结果，在同一个 key 上等待的 Group 会重新竞争，
+// 因此两者不应共用 key 的命名空间。
+type Backend struct {
+	Client coordinatorpb.CoordinatorClient
+}
+
+// TryLock 实现 singleflight.LockBackend，ttl 即 Leader 的租约。
+func (b *Backend) TryLock(ctx context.Context, key string, ttl time.Duration) (unlock func(context.Context) error, acquired bool, err error) {
+	resp, err := b.Client.AcquireFlight(ctx, &coordinatorpb.AcquireFlightRequest{Key: key, TtlMs: ttl.Milliseconds()})
+	if err != nil || !resp.Acquired {
+		return nil, false, err
+	}
+	return func(ctx context.Context) error {
+		_, err := b.Client.PublishResult(ctx, &coordinatorpb.PublishResultRequest{Key: key, Token: resp.Token, ReleaseOnly: true})
+		return err
+	}, true, nil
+}
@@ -0,0 +1,438 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: coordinatorpb/coordinator.proto
+
+// 跨服务的执行合并协调服务，参见 github.com/oy3o/singleflight/grpcsf。
+
+package coordinatorpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type AcquireFlightRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// ttl_ms 为 Leader 的租约时长，过期后执行被视为放弃。<= 0 时使用服务端默认值。
+	TtlMs         int64 `protobuf:"varint,2,opt,name=ttl_ms,json=ttlMs,proto3" json:"ttl_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AcquireFlightRequest) Reset() {
+	*x = AcquireFlightRequest{}
+	mi := &file_coordinatorpb_coordinator_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AcquireFlightRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AcquireFlightRequest) ProtoMessage() {}
+
+func (x *AcquireFlightRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coordinatorpb_coordinator_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AcquireFlightRequest.ProtoReflect.Descriptor instead.
+func (*AcquireFlightRequest) Descriptor() ([]byte, []int) {
+	return file_coordinatorpb_coordinator_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AcquireFlightRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *AcquireFlightRequest) GetTtlMs() int64 {
+	if x != nil {
+		return x.TtlMs
+	}
+	return 0
+}
+
+type AcquireFlightResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Acquired bool                   `protobuf:"varint,1,opt,name=acquired,proto3" json:"acquired,omitempty"`
+	// token 标识本次执行，PublishResult 时必须带上。
+	Token         string `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AcquireFlightResponse) Reset() {
+	*x = AcquireFlightResponse{}
+	mi := &file_coordinatorpb_coordinator_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AcquireFlightResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AcquireFlightResponse) ProtoMessage() {}
+
+func (x *AcquireFlightResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_coordinatorpb_coordinator_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AcquireFlightResponse.ProtoReflect.Descriptor instead.
+func (*AcquireFlightResponse) Descriptor() ([]byte, []int) {
+	return file_coordinatorpb_coordinator_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *AcquireFlightResponse) GetAcquired() bool {
+	if x != nil {
+		return x.Acquired
+	}
+	return false
+}
+
+func (x *AcquireFlightResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type PublishResultRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Token string                 `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	// value 为以调用方约定的编码序列化的结果。
+	Value []byte `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	// error 非空表示执行失败，等待者收到该错误信息。
+	Error string `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	// release_only 表示不发布结果，仅结束执行；等待者会重新竞争。
+	ReleaseOnly   bool `protobuf:"varint,5,opt,name=release_only,json=releaseOnly,proto3" json:"release_only,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishResultRequest) Reset() {
+	*x = PublishResultRequest{}
+	mi := &file_coordinatorpb_coordinator_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishResultRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishResultRequest) ProtoMessage() {}
+
+func (x *PublishResultRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coordinatorpb_coordinator_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishResultRequest.ProtoReflect.Descriptor instead.
+func (*PublishResultRequest) Descriptor() ([]byte, []int) {
+	return file_coordinatorpb_coordinator_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PublishResultRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *PublishResultRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *PublishResultRequest) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *PublishResultRequest) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *PublishResultRequest) GetReleaseOnly() bool {
+	if x != nil {
+		return x.ReleaseOnly
+	}
+	return false
+}
+
+type PublishResultResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishResultResponse) Reset() {
+	*x = PublishResultResponse{}
+	mi := &file_coordinatorpb_coordinator_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishResultResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishResultResponse) ProtoMessage() {}
+
+func (x *PublishResultResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_coordinatorpb_coordinator_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishResultResponse.ProtoReflect.Descriptor instead.
+func (*PublishResultResponse) Descriptor() ([]byte, []int) {
+	return file_coordinatorpb_coordinator_proto_rawDescGZIP(), []int{3}
+}
+
+type WatchResultRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchResultRequest) Reset() {
+	*x = WatchResultRequest{}
+	mi := &file_coordinatorpb_coordinator_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchResultRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchResultRequest) ProtoMessage() {}
+
+func (x *WatchResultRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_coordinatorpb_coordinator_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchResultRequest.ProtoReflect.Descriptor instead.
+func (*WatchResultRequest) Descriptor() ([]byte, []int) {
+	return file_coordinatorpb_coordinator_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *WatchResultRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type WatchResultResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Value []byte                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Error string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	// abandoned 表示执行未发布结果就结束了（租约过期或 release_only）。
+	Abandoned     bool `protobuf:"varint,3,opt,name=abandoned,proto3" json:"abandoned,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchResultResponse) Reset() {
+	*x = WatchResultResponse{}
+	mi := &file_coordinatorpb_coordinator_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchResultResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchResultResponse) ProtoMessage() {}
+
+func (x *WatchResultResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_coordinatorpb_coordinator_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchResultResponse.ProtoReflect.Descriptor instead.
+func (*WatchResultResponse) Descriptor() ([]byte, []int) {
+	return file_coordinatorpb_coordinator_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *WatchResultResponse) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *WatchResultResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *WatchResultResponse) GetAbandoned() bool {
+	if x != nil {
+		return x.Abandoned
+	}
+	return false
+}
+
+var File_coordinatorpb_coordinator_proto protoreflect.FileDescriptor
+
+const file_coordinatorpb_coordinator_proto_rawDesc = "" +
+	"\n" +
+	"\x1fcoordinatorpb/coordinator.proto\x12\x1bsingleflight.coordinator.v1\"?\n" +
+	"\x14AcquireFlightRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x15\n" +
+	"\x06ttl_ms\x18\x02 \x01(\x03R\x05ttlMs\"I\n" +
+	"\x15AcquireFlightResponse\x12\x1a\n" +
+	"\bacquired\x18\x01 \x01(\bR\bacquired\x12\x14\n" +
+	"\x05token\x18\x02 \x01(\tR\x05token\"\x8d\x01\n" +
+	"\x14PublishResultRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05token\x18\x02 \x01(\tR\x05token\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\fR\x05value\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\x12!\n" +
+	"\frelease_only\x18\x05 \x01(\bR\vreleaseOnly\"\x17\n" +
+	"\x15PublishResultResponse\"&\n" +
+	"\x12WatchResultRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"_\n" +
+	"\x13WatchResultResponse\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\fR\x05value\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12\x1c\n" +
+	"\tabandoned\x18\x03 \x01(\bR\tabandoned2\xf1\x02\n" +
+	"\vCoordinator\x12v\n" +
+	"\rAcquireFlight\x121.singleflight.coordinator.v1.AcquireFlightRequest\x1a2.singleflight.coordinator.v1.AcquireFlightResponse\x12v\n" +
+	"\rPublishResult\x121.singleflight.coordinator.v1.PublishResultRequest\x1a2.singleflight.coordinator.v1.PublishResultResponse\x12r\n" +
+	"\vWatchResult\x12/.singleflight.coordinator.v1.WatchResultRequest\x1a0.singleflight.coordinator.v1.WatchResultResponse0\x01B3Z1github.com/oy3o/singleflight/grpcsf/coordinatorpbb\x06proto3"
+
+var (
+	file_coordinatorpb_coordinator_proto_rawDescOnce sync.Once
+	file_coordinatorpb_coordinator_proto_rawDescData []byte
+)
+
+func file_coordinatorpb_coordinator_proto_rawDescGZIP() []byte {
+	file_coordinatorpb_coordinator_proto_rawDescOnce.Do(func() {
+		file_coordinatorpb_coordinator_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_coordinatorpb_coordinator_proto_rawDesc), len(file_coordinatorpb_coordinator_proto_rawDesc)))
+	})
+	return file_coordinatorpb_coordinator_proto_rawDescData
+}
+
+var file_coordinatorpb_coordinator_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_coordinatorpb_coordinator_proto_goTypes = []any{
+	(*AcquireFlightRequest)(nil),  // 0: singleflight.coordinator.v1.AcquireFlightRequest
+	(*AcquireFlightResponse)(nil), // 1: singleflight.coordinator.v1.AcquireFlightResponse
+	(*PublishResultRequest)(nil),  // 2: singleflight.coordinator.v1.PublishResultRequest
+	(*PublishResultResponse)(nil), // 3: singleflight.coordinator.v1.PublishResultResponse
+	(*WatchResultRequest)(nil),    // 4: singleflight.coordinator.v1.WatchResultRequest
+	(*WatchResultResponse)(nil),   // 5: singleflight.coordinator.v1.WatchResultResponse
+}
+var file_coordinatorpb_coordinator_proto_depIdxs = []int32{
+	0, // 0: singleflight.coordinator.v1.Coordinator.AcquireFlight:input_type -> singleflight.coordinator.v1.AcquireFlightRequest
+	2, // 1: singleflight.coordinator.v1.Coordinator.PublishResult:input_type -> singleflight.coordinator.v1.PublishResultRequest
+	4, // 2: singleflight.coordinator.v1.Coordinator.WatchResult:input_type -> singleflight.coordinator.v1.WatchResultRequest
+	1, // 3: singleflight.coordinator.v1.Coordinator.AcquireFlight:output_type -> singleflight.coordinator.v1.AcquireFlightResponse
+	3, // 4: singleflight.coordinator.v1.Coordinator.PublishResult:output_type -> singleflight.coordinator.v1.PublishResultResponse
+	5, // 5: singleflight.coordinator.v1.Coordinator.WatchResult:output_type -> singleflight.coordinator.v1.WatchResultResponse
+	3, // [3:6] is the sub-list for method output_type
+	0, // [0:3] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_coordinatorpb_coordinator_proto_init() }
+func file_coordinatorpb_coordinator_proto_init() {
+	if File_coordinatorpb_coordinator_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_coordinatorpb_coordinator_proto_rawDesc), len(file_coordinatorpb_coordinator_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_coordinatorpb_coordinator_proto_goTypes,
+		DependencyIndexes: file_coordinatorpb_coordinator_proto_depIdxs,
+		MessageInfos:      file_coordinatorpb_coordinator_proto_msgTypes,
+	}.Build()
+	File_coordinatorpb_coordinator_proto = out.File
+	file_coordinatorpb_coordinator_proto_goTypes = nil
+	file_coordinatorpb_coordinator_proto_depIdxs = nil
+}
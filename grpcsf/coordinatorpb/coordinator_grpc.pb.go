@@ -0,0 +1,223 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: coordinatorpb/coordinator.proto
+
+// 跨服务的执行合并协调服务，参见 github.com/oy3o/singleflight/grpcsf。
+
+package coordinatorpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Coordinator_AcquireFlight_FullMethodName = "/singleflight.coordinator.v1.Coordinator/AcquireFlight"
+	Coordinator_PublishResult_FullMethodName = "/singleflight.coordinator.v1.Coordinator/PublishResult"
+	Coordinator_WatchResult_FullMethodName   = "/singleflight.coordinator.v1.Coordinator/WatchResult"
+)
+
+// CoordinatorClient is the client API for Coordinator service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Coordinator 为 key 选出唯一的 Leader，并把 Leader 的结果转交给其他调用者。
+//
+// 调用方先 AcquireFlight：成功则执行并以 PublishResult 发布结果；
+// 失败则 WatchResult 等待。等待结束于 abandoned 或 NOT_FOUND 时重新 AcquireFlight。
+type CoordinatorClient interface {
+	// AcquireFlight 尝试成为 key 的 Leader。
+	AcquireFlight(ctx context.Context, in *AcquireFlightRequest, opts ...grpc.CallOption) (*AcquireFlightResponse, error)
+	// PublishResult 发布 Leader 的结果并结束执行。token 不匹配（租约已过期并被他人取得）
+	// 时返回 FAILED_PRECONDITION。
+	PublishResult(ctx context.Context, in *PublishResultRequest, opts ...grpc.CallOption) (*PublishResultResponse, error)
+	// WatchResult 等待 key 当前执行的结果，发送一条消息后结束。
+	// key 既没有进行中的执行、也没有保留的结果时返回 NOT_FOUND。
+	WatchResult(ctx context.Context, in *WatchResultRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchResultResponse], error)
+}
+
+type coordinatorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCoordinatorClient(cc grpc.ClientConnInterface) CoordinatorClient {
+	return &coordinatorClient{cc}
+}
+
+func (c *coordinatorClient) AcquireFlight(ctx context.Context, in *AcquireFlightRequest, opts ...grpc.CallOption) (*AcquireFlightResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AcquireFlightResponse)
+	err := c.cc.Invoke(ctx, Coordinator_AcquireFlight_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coordinatorClient) PublishResult(ctx context.Context, in *PublishResultRequest, opts ...grpc.CallOption) (*PublishResultResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PublishResultResponse)
+	err := c.cc.Invoke(ctx, Coordinator_PublishResult_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *coordinatorClient) WatchResult(ctx context.Context, in *WatchResultRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchResultResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Coordinator_ServiceDesc.Streams[0], Coordinator_WatchResult_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchResultRequest, WatchResultResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Coordinator_WatchResultClient = grpc.ServerStreamingClient[WatchResultResponse]
+
+// CoordinatorServer is the server API for Coordinator service.
+// All implementations must embed UnimplementedCoordinatorServer
+// for forward compatibility.
+//
+// Coordinator 为 key 选出唯一的 Leader，并把 Leader 的结果转交给其他调用者。
+//
+// 调用方先 AcquireFlight：成功则执行并以 PublishResult 发布结果；
+// 失败则 WatchResult 等待。等待结束于 abandoned 或 NOT_FOUND 时重新 AcquireFlight。
+type CoordinatorServer interface {
+	// AcquireFlight 尝试成为 key 的 Leader。
+	AcquireFlight(context.Context, *AcquireFlightRequest) (*AcquireFlightResponse, error)
+	// PublishResult 发布 Leader 的结果并结束执行。token 不匹配（租约已过期并被他人取得）
+	// 时返回 FAILED_PRECONDITION。
+	PublishResult(context.Context, *PublishResultRequest) (*PublishResultResponse, error)
+	// WatchResult 等待 key 当前执行的结果，发送一条消息后结束。
+	// key 既没有进行中的执行、也没有保留的结果时返回 NOT_FOUND。
+	WatchResult(*WatchResultRequest, grpc.ServerStreamingServer[WatchResultResponse]) error
+	mustEmbedUnimplementedCoordinatorServer()
+}
+
+// UnimplementedCoordinatorServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCoordinatorServer struct{}
+
+func (UnimplementedCoordinatorServer) AcquireFlight(context.Context, *AcquireFlightRequest) (*AcquireFlightResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcquireFlight not implemented")
+}
+func (UnimplementedCoordinatorServer) PublishResult(context.Context, *PublishResultRequest) (*PublishResultResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PublishResult not implemented")
+}
+func (UnimplementedCoordinatorServer) WatchResult(*WatchResultRequest, grpc.ServerStreamingServer[WatchResultResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchResult not implemented")
+}
+func (UnimplementedCoordinatorServer) mustEmbedUnimplementedCoordinatorServer() {}
+func (UnimplementedCoordinatorServer) testEmbeddedByValue()                     {}
+
+// UnsafeCoordinatorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CoordinatorServer will
+// result in compilation errors.
+type UnsafeCoordinatorServer interface {
+	mustEmbedUnimplementedCoordinatorServer()
+}
+
+func RegisterCoordinatorServer(s grpc.ServiceRegistrar, srv CoordinatorServer) {
+	// If the following call pancis, it indicates UnimplementedCoordinatorServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Coordinator_ServiceDesc, srv)
+}
+
+func _Coordinator_AcquireFlight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AcquireFlightRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoordinatorServer).AcquireFlight(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Coordinator_AcquireFlight_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoordinatorServer).AcquireFlight(ctx, req.(*AcquireFlightRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Coordinator_PublishResult_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PublishResultRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CoordinatorServer).PublishResult(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Coordinator_PublishResult_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CoordinatorServer).PublishResult(ctx, req.(*PublishResultRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Coordinator_WatchResult_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchResultRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CoordinatorServer).WatchResult(m, &grpc.GenericServerStream[WatchResultRequest, WatchResultResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Coordinator_WatchResultServer = grpc.ServerStreamingServer[WatchResultResponse]
+
+// Coordinator_ServiceDesc is the grpc.ServiceDesc for Coordinator service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Coordinator_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "singleflight.coordinator.v1.Coordinator",
+	HandlerType: (*CoordinatorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AcquireFlight",
+			Handler:    _Coordinator_AcquireFlight_Handler,
+		},
+		{
+			MethodName: "PublishResult",
+			Handler:    _Coordinator_PublishResult_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchResult",
+			Handler:       _Coordinator_WatchResult_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "coordinatorpb/coordinator.proto",
+}
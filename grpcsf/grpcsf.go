@@ -0,0 +1,186 @@
+// Package grpcsf 通过一个中心化的 gRPC 协调服务，在多个服务、多个实例之间合并
+// 对同一个 key 的昂贵计算。
+//
+// 协调服务（coordinatorpb 中定义，Server 为参考实现）为每个 key 选出唯一的 Leader：
+// Leader 执行 fn 并发布编码后的结果，其他实例上的调用者直接收到该结果，
+// 而不是在本地再执行一次。每个实例内部先经 singleflight.Group 合并，
+// 只有本地 Leader 访问协调服务。
+//
+// 生成代码由 coordinatorpb/coordinator.proto 产生，其他语言可据此实现客户端。
+package grpcsf
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative coordinatorpb/coordinator.proto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/oy3o/singleflight"
+	"github.com/oy3o/singleflight/grpcsf/coordinatorpb"
+)
+
+// RemoteError 表示结果来自另一个实例上失败的执行，Message 为其错误信息。
+// 原始错误的类型无法跨进程保留。
+type RemoteError struct {
+	Message string
+}
+
+func (e *RemoteError) Error() string {
+	return "grpcsf: remote execution failed: " + e.Message
+}
+
+// Group 经协调服务合并跨实例的调用。字段在首次使用后不应修改。
+type Group[K comparable, V any] struct {
+	// Local 为进程内合并组，nil 时使用私有 Group。
+	Local *singleflight.Group[K, V]
+
+	// Client 为协调服务的客户端。
+	Client coordinatorpb.CoordinatorClient
+
+	// Key 把 K 编码为协调服务范围内唯一的名字。
+	Key func(K) string
+
+	// Codec 编码在实例间传递的结果，所有实例必须一致。nil 时使用 singleflight.GobCodec。
+	Codec singleflight.Codec[V]
+
+	// LeaseTTL 为 Leader 的租约，应大于 fn 的最长执行时间；
+	// 超过后执行被视为放弃，等待者会重新竞争。0 时使用服务端默认值。
+	LeaseTTL time.Duration
+
+	// FailOpen 为 true 时，协调服务出错降级为本地执行；否则返回 *singleflight.BackendError。
+	FailOpen bool
+
+	local singleflight.Group[K, V]
+}
+
+// New 创建 Group。
+func New[K comparable, V any](client coordinatorpb.CoordinatorClient, key func(K) string) *Group[K, V] {
+	return &Group[K, V]{Client: client, Key: key}
+}
+
+// Do 语义同 singleflight.Group.Do，shared 只反映进程内是否共享。
+//
+// 错误语义：
+//   - 本实例执行的 fn 的错误原样返回；其他实例的执行错误以 *RemoteError 返回。
+//   - 协调服务错误以 *singleflight.BackendError 返回（FailOpen 时降级执行，不返回）。
+//   - 等待其他实例期间 ctx 结束，返回满足 singleflight.ErrWaiterCancelled 的错误。
+func (g *Group[K, V]) Do(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) (V, error),
+) (v V, err error, shared bool) {
+	local := g.Local
+	if local == nil {
+		local = &g.local
+	}
+	return local.Do(ctx, key, func(ctx context.Context) (V, error) {
+		return g.doRemote(ctx, g.Key(key), fn)
+	})
+}
+
+func (g *Group[K, V]) doRemote(
+	ctx context.Context,
+	name string,
+	fn func(ctx context.Context) (V, error),
+) (V, error) {
+	var zero V
+	for {
+		resp, err := g.Client.AcquireFlight(ctx, &coordinatorpb.AcquireFlightRequest{
+			Key:   name,
+			TtlMs: g.LeaseTTL.Milliseconds(),
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return zero, waitError(ctx)
+			}
+			if g.FailOpen {
+				return fn(ctx)
+			}
+			return zero, &singleflight.BackendError{Key: name, Err: err}
+		}
+		if resp.Acquired {
+			return g.lead(ctx, name, resp.Token, fn)
+		}
+
+		v, err, retry := g.watch(ctx, name)
+		if retry {
+			continue
+		}
+		var be *singleflight.BackendError
+		if g.FailOpen && errors.As(err, &be) {
+			return fn(ctx)
+		}
+		return v, err
+	}
+}
+
+// lead 执行 fn 并发布结果。fn panic 时仍结束执行，等待者无需等到租约过期。
+func (g *Group[K, V]) lead(
+	ctx context.Context,
+	name, token string,
+	fn func(ctx context.Context) (V, error),
+) (v V, err error) {
+	req := &coordinatorpb.PublishResultRequest{Key: name, Token: token, ReleaseOnly: true}
+	defer func() {
+		// 发布不应受 fn 期间 ctx 取消的影响。发布失败只影响其他实例，
+		// 它们会在租约过期后重新竞争，本次结果照常返回。
+		g.Client.PublishResult(context.WithoutCancel(ctx), req)
+	}()
+
+	v, err = fn(ctx)
+	switch {
+	case err != nil:
+		// 调用者取消导致的失败不代表执行本身的结果，让其他实例重新竞争。
+		if ctx.Err() == nil {
+			req.Error, req.ReleaseOnly = err.Error(), false
+		}
+	default:
+		if data, encErr := g.codec().Marshal(v); encErr == nil {
+			req.Value, req.ReleaseOnly = data, false
+		}
+	}
+	return v, err
+}
+
+// watch 等待其他实例的结果。retry 为 true 表示执行已结束但没有可用结果，应重新竞争。
+func (g *Group[K, V]) watch(ctx context.Context, name string) (v V, err error, retry bool) {
+	stream, err := g.Client.WatchResult(ctx, &coordinatorpb.WatchResultRequest{Key: name})
+	var resp *coordinatorpb.WatchResultResponse
+	if err == nil {
+		resp, err = stream.Recv()
+	}
+	switch {
+	case ctx.Err() != nil:
+		return v, waitError(ctx), false
+	case status.Code(err) == codes.NotFound:
+		return v, nil, true
+	case err != nil:
+		return v, &singleflight.BackendError{Key: name, Err: err}, false
+	case resp.Abandoned:
+		return v, nil, true
+	case resp.Error != "":
+		return v, &RemoteError{Message: resp.Error}, false
+	}
+	v, err = g.codec().Unmarshal(resp.Value)
+	if err != nil {
+		return v, &singleflight.BackendError{Key: name, Err: fmt.Errorf("decode result: %w", err)}, false
+	}
+	return v, nil, false
+}
+
+func (g *Group[K, V]) codec() singleflight.Codec[V] {
+	if g.Codec != nil {
+		return g.Codec
+	}
+	return singleflight.GobCodec[V]{}
+}
+
+// waitError 与 singleflight 内部对调用者取消的包装一致。
+func waitError(ctx context.Context) error {
+	return &singleflight.WaitError{Err: ctx.Err(), Cause: context.Cause(ctx)}
+}
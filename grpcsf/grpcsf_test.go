@@ -0,0 +1,135 @@
+package grpcsf
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/oy3o/singleflight"
+	"github.com/oy3o/singleflight/grpcsf/coordinatorpb"
+)
+
+var _ singleflight.LockBackend = (*Backend)(nil)
+
+func dial(t *testing.T, srv *Server) coordinatorpb.CoordinatorClient {
+	l := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	coordinatorpb.RegisterCoordinatorServer(s, srv)
+	go s.Serve(l)
+	t.Cleanup(s.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return l.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return coordinatorpb.NewCoordinatorClient(conn)
+}
+
+func TestGroup_SharesAcrossInstances(t *testing.T) {
+	client := dial(t, &Server{})
+	// 两个 Group 模拟两个实例，各自有独立的进程内合并。
+	a := New[string, string](client, func(k string) string { return "user/" + k })
+	b := New[string, string](client, func(k string) string { return "user/" + k })
+	ctx := context.Background()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error)
+	go func() {
+		v, err, _ := a.Do(ctx, "1", func(context.Context) (string, error) {
+			close(started)
+			<-release
+			return "moon", nil
+		})
+		if v != "moon" {
+			t.Errorf("leader got %q", v)
+		}
+		done <- err
+	}()
+	<-started
+
+	res := make(chan string)
+	go func() {
+		v, err, _ := b.Do(ctx, "1", func(context.Context) (string, error) {
+			t.Error("second instance executed fn")
+			return "", nil
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		res <- v
+	}()
+	time.Sleep(10 * time.Millisecond) // 让 b 进入等待；即使晚到，Retain 也保证它拿到结果。
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if v := <-res; v != "moon" {
+		t.Fatalf("second instance got %q", v)
+	}
+}
+
+func TestGroup_RemoteError(t *testing.T) {
+	client := dial(t, &Server{})
+	a := New[string, int](client, func(k string) string { return k })
+	b := New[string, int](client, func(k string) string { return k })
+	ctx := context.Background()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	go a.Do(ctx, "k", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 0, errors.New("boom")
+	})
+	<-started
+	res := make(chan error)
+	go func() {
+		_, err, _ := b.Do(ctx, "k", func(context.Context) (int, error) { return 0, nil })
+		res <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	var re *RemoteError
+	if err := <-res; !errors.As(err, &re) || re.Message != "boom" {
+		t.Fatalf("err = %v, want RemoteError(boom)", err)
+	}
+}
+
+func TestServer_LeaseExpiry(t *testing.T) {
+	clock := singleflight.NewFakeClock(time.Unix(100, 0))
+	srv := &Server{Clock: clock}
+	client := dial(t, srv)
+	ctx := context.Background()
+
+	first, err := client.AcquireFlight(ctx, &coordinatorpb.AcquireFlightRequest{Key: "k", TtlMs: 1000})
+	if err != nil || !first.Acquired {
+		t.Fatalf("AcquireFlight = %v, %v", first, err)
+	}
+	if r, _ := client.AcquireFlight(ctx, &coordinatorpb.AcquireFlightRequest{Key: "k"}); r.Acquired {
+		t.Fatal("held flight acquired twice")
+	}
+	stream, err := client.WatchResult(ctx, &coordinatorpb.WatchResultRequest{Key: "k"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(2 * time.Second)
+	// 服务端可能在租约过期之前或之后才收到 WatchResult，两种结果都让客户端重新竞争。
+	if r, err := stream.Recv(); status.Code(err) != codes.NotFound && (err != nil || !r.Abandoned) {
+		t.Fatalf("watch after lease expiry = %v, %v", r, err)
+	}
+	if _, err := client.PublishResult(ctx, &coordinatorpb.PublishResultRequest{Key: "k", Token: first.Token}); err == nil {
+		t.Fatal("publish on an expired lease succeeded")
+	}
+	if r, _ := client.AcquireFlight(ctx, &coordinatorpb.AcquireFlightRequest{Key: "k"}); !r.Acquired {
+		t.Fatal("expired flight not re-acquirable")
+	}
+}
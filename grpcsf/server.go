@@ -0,0 +1,152 @@
+package grpcsf
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/oy3o/singleflight"
+	"github.com/oy3o/singleflight/grpcsf/coordinatorpb"
+)
+
+// Server 是 Coordinator 服务的参考实现，状态保存在内存中。零值可用，
+// 以 coordinatorpb.RegisterCoordinatorServer 注册到 grpc.Server。
+//
+// Server 重启会丢失所有进行中的执行：等待者收到错误后由客户端重新竞争，
+// 旧 Leader 的 PublishResult 因 token 不匹配而被拒绝。
+type Server struct {
+	coordinatorpb.UnimplementedCoordinatorServer
+
+	// DefaultTTL 为请求未指定租约时的时长。默认 30s。
+	DefaultTTL time.Duration
+
+	// Retain 为结果发布后仍可被 WatchResult 取得的时长，吸收在 AcquireFlight 失败与
+	// WatchResult 之间恰好完成的执行。新的 AcquireFlight 不受影响。默认 1s，< 0 表示不保留。
+	Retain time.Duration
+
+	// Clock 驱动租约与保留计时，nil 时使用 singleflight.SystemClock。
+	Clock singleflight.Clock
+
+	mu       sync.Mutex
+	flights  map[string]*flight
+	retained map[string]*flight
+}
+
+// flight 是一次执行。resp 在 done 关闭前写入，之后只读。
+type flight struct {
+	token string
+	done  chan struct{}
+	resp  *coordinatorpb.WatchResultResponse
+	lease singleflight.Timer
+}
+
+// AcquireFlight 实现 coordinatorpb.CoordinatorServer。
+func (s *Server) AcquireFlight(ctx context.Context, req *coordinatorpb.AcquireFlightRequest) (*coordinatorpb.AcquireFlightResponse, error) {
+	var b [16]byte
+	rand.Read(b[:])
+	ttl := time.Duration(req.TtlMs) * time.Millisecond
+	if ttl <= 0 {
+		ttl = s.DefaultTTL
+	}
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.flights[req.Key]; ok {
+		return &coordinatorpb.AcquireFlightResponse{}, nil
+	}
+	f := &flight{token: hex.EncodeToString(b[:]), done: make(chan struct{})}
+	if s.flights == nil {
+		s.flights = make(map[string]*flight)
+	}
+	s.flights[req.Key] = f
+	// 租约到期视为 Leader 已放弃，等待者重新竞争。
+	f.lease = s.clock().AfterFunc(ttl, func() {
+		s.finish(req.Key, f, &coordinatorpb.WatchResultResponse{Abandoned: true})
+	})
+	return &coordinatorpb.AcquireFlightResponse{Acquired: true, Token: f.token}, nil
+}
+
+// PublishResult 实现 coordinatorpb.CoordinatorServer。
+func (s *Server) PublishResult(ctx context.Context, req *coordinatorpb.PublishResultRequest) (*coordinatorpb.PublishResultResponse, error) {
+	s.mu.Lock()
+	f, ok := s.flights[req.Key]
+	s.mu.Unlock()
+	if !ok || f.token != req.Token {
+		return nil, status.Error(codes.FailedPrecondition, "flight not held")
+	}
+	resp := &coordinatorpb.WatchResultResponse{Value: req.Value, Error: req.Error}
+	if req.ReleaseOnly {
+		resp = &coordinatorpb.WatchResultResponse{Abandoned: true}
+	}
+	if !s.finish(req.Key, f, resp) {
+		return nil, status.Error(codes.FailedPrecondition, "flight not held")
+	}
+	return &coordinatorpb.PublishResultResponse{}, nil
+}
+
+// WatchResult 实现 coordinatorpb.CoordinatorServer。
+func (s *Server) WatchResult(req *coordinatorpb.WatchResultRequest, stream coordinatorpb.Coordinator_WatchResultServer) error {
+	s.mu.Lock()
+	f, ok := s.flights[req.Key]
+	if !ok {
+		f, ok = s.retained[req.Key]
+	}
+	s.mu.Unlock()
+	if !ok {
+		return status.Error(codes.NotFound, "no flight")
+	}
+	select {
+	case <-f.done:
+		return stream.Send(f.resp)
+	case <-stream.Context().Done():
+		return status.FromContextError(stream.Context().Err()).Err()
+	}
+}
+
+// finish 以 resp 结束 f，f 已不是 key 当前的执行时返回 false。
+func (s *Server) finish(key string, f *flight, resp *coordinatorpb.WatchResultResponse) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.flights[key] != f {
+		return false
+	}
+	f.lease.Stop()
+	delete(s.flights, key)
+	f.resp = resp
+	close(f.done)
+
+	retain := s.Retain
+	if retain == 0 {
+		retain = time.Second
+	}
+	if resp.Abandoned || retain < 0 {
+		return true
+	}
+	if s.retained == nil {
+		s.retained = make(map[string]*flight)
+	}
+	s.retained[key] = f
+	s.clock().AfterFunc(retain, func() {
+		s.mu.Lock()
+		if s.retained[key] == f {
+			delete(s.retained, key)
+		}
+		s.mu.Unlock()
+	})
+	return true
+}
+
+func (s *Server) clock() singleflight.Clock {
+	if s.Clock != nil {
+		return s.Clock
+	}
+	return singleflight.SystemClock
+}
@@ -0,0 +1,51 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+)
+
+// WithHandoff 在 Leader 因其调用者的 ctx 结束而失败时，不把这个失败交给
+// 仍在等待的 Follower，而是由其中一个 Follower 以自己的 ctx 重新执行，
+// 其余 Follower 合并到这次新的执行上。
+//
+// 判定条件是 fn 返回了错误且 Leader 调用者的 ctx 已结束；panic 照常传播。
+// 接手者使用自己传入的 fn，为 nil 时使用原 Leader 的 fn。
+// Leader 本身仍收到 fn 的错误。这样的失败不计入 WithCircuitBreaker 与
+// WithFailureBackoff，也不会作为 WithMinExecInterval / WithDebounce 保留的结果
+// 交给之后的调用者，它们拿到的仍是之前的结果。
+func WithHandoff() Option {
+	return func(o *options) { o.handoff = true }
+}
+
+// WithCancelHandoff 是只针对取消的 WithHandoff：仅当 fn 的错误是 context.Canceled
+// 或 context.DeadlineExceeded、且 Leader 调用者的 ctx 已结束时，才不把它交给
+// ctx 仍然有效的 Follower，而由其中一个重新执行。与取消同时发生的其他错误
+// 照常交给所有调用者，不会引发重新执行。其余行为同 WithHandoff；
+// 两者同时设置时以 WithHandoff 为准。
+func WithCancelHandoff() Option {
+	return func(o *options) { o.cancelHandoff = true }
+}
+
+// handsOff 报告已结束的执行 c 是否应交给 Follower 重新执行，callerCtx 为 Leader 调用者的 ctx。
+func (g *Group[K, V]) handsOff(c *call[V], callerCtx context.Context) bool {
+	if c.panicErr != nil || c.err == nil || callerCtx.Err() == nil {
+		return false
+	}
+	return g.cfg.handoff ||
+		errors.Is(c.err, context.Canceled) || errors.Is(c.err, context.DeadlineExceeded)
+}
+
+// takeOver 在 c 被交接后重新发起调用：第一个到达的 Follower 成为新的 Leader。
+func (g *Group[K, V]) takeOver(
+	ctx context.Context,
+	key K,
+	c *call[V],
+	fn func(ctx context.Context) (V, error),
+	co *callOpts[V],
+) (V, error, flight) {
+	if fn == nil {
+		fn = c.fn
+	}
+	return g.do(ctx, key, fn, co)
+}
@@ -0,0 +1,176 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHandoff_FollowerTakesOver(t *testing.T) {
+	joined := make(chan struct{})
+	g := NewGroup[string, int](
+		WithHandoff(),
+		WithHooks(Hooks[string]{FollowerJoined: func(string) { close(joined) }}),
+	)
+	var execs atomic.Int32
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (int, error) {
+		execs.Add(1)
+		started <- struct{}{}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-release:
+			return 1, nil
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := g.DoChan(ctx, "k", fn)
+	<-started
+	follower := g.DoChan(context.Background(), "k", nil)
+	<-joined
+
+	cancel()
+	if r := <-leader; !errors.Is(r.Err, context.Canceled) {
+		t.Fatalf("leader = %+v", r)
+	}
+	<-started // Follower 接手并以自己的 ctx 重新执行
+	close(release)
+	if r := <-follower; r.Val != 1 || r.Err != nil {
+		t.Fatalf("follower = %+v, want result of the handed-off execution", r)
+	}
+	if n := execs.Load(); n != 2 {
+		t.Fatalf("execs = %d", n)
+	}
+}
+
+func TestHandoff_DisabledSharesFailure(t *testing.T) {
+	joined := make(chan struct{})
+	g := NewGroup[string, int](WithHooks(Hooks[string]{FollowerJoined: func(string) { close(joined) }}))
+	started := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := g.DoChan(ctx, "k", func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	<-started
+	follower := g.DoChan(context.Background(), "k", nil)
+	<-joined
+	cancel()
+	<-leader
+	if r := <-follower; !errors.Is(r.Err, context.Canceled) {
+		t.Fatalf("follower = %+v, want the leader's cancellation by default", r)
+	}
+}
+
+func TestHandoff_FailureNotKept(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	g := NewGroup[string, int](WithHandoff(), WithClock(clock), WithMinExecInterval(time.Second))
+	if v, _, _ := g.Do(context.Background(), "k", func(ctx context.Context) (int, error) { return 1, nil }); v != 1 {
+		t.Fatalf("first = %d", v)
+	}
+	clock.Advance(time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	fn := func(ctx context.Context) (int, error) {
+		cancel()
+		return 0, ctx.Err()
+	}
+	if _, err, _ := g.Do(ctx, "k", fn); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled leader err = %v", err)
+	}
+	// 间隔内的调用者拿到上一次成功的结果，而不是别人的取消。
+	if v, err, _ := g.Do(context.Background(), "k", nil); v != 1 || err != nil {
+		t.Fatalf("limited = %d, %v", v, err)
+	}
+}
+
+func TestHandoff_NotRecordedByBreakerOrBackoff(t *testing.T) {
+	joined := make(chan struct{})
+	g := NewGroup[string, int](
+		WithHandoff(),
+		WithFailureBackoff(Backoff{Base: time.Hour}),
+		WithCircuitBreaker(Breaker{Threshold: 1, Cooldown: time.Hour}),
+		WithHooks(Hooks[string]{FollowerJoined: func(string) { close(joined) }}),
+	)
+	started := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := g.DoChan(ctx, "k", func(ctx context.Context) (int, error) {
+		close(started)
+		<-ctx.Done()
+		// 熔断与退避都计入 DeadlineExceeded。
+		return 0, context.DeadlineExceeded
+	})
+	<-started
+	follower := g.DoChan(context.Background(), "k", func(ctx context.Context) (int, error) { return 1, nil })
+	<-joined
+
+	cancel()
+	if r := <-leader; !errors.Is(r.Err, context.DeadlineExceeded) {
+		t.Fatalf("leader = %+v", r)
+	}
+	if r := <-follower; r.Val != 1 || r.Err != nil {
+		t.Fatalf("follower = %+v, want the handed-off execution's result", r)
+	}
+	if v, err, _ := g.Do(context.Background(), "k", func(ctx context.Context) (int, error) { return 2, nil }); v != 2 || err != nil {
+		t.Fatalf("later call = %d, %v; the handed-off failure must not open the breaker or back off", v, err)
+	}
+}
+
+func TestCancelHandoff(t *testing.T) {
+	backendErr := errors.New("backend down")
+	for _, tc := range []struct {
+		name     string
+		leader   error
+		wantExec int32
+	}{
+		{"cancelled", context.Canceled, 2},
+		{"deadline", context.DeadlineExceeded, 2},
+		// 与取消同时发生的真实错误照常共享，不引发重新执行。
+		{"backend error", backendErr, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			joined := make(chan struct{})
+			g := NewGroup[string, int](
+				WithCancelHandoff(),
+				WithFailureBackoff(Backoff{Base: time.Hour}),
+				WithHooks(Hooks[string]{FollowerJoined: func(string) { close(joined) }}),
+			)
+			var execs atomic.Int32
+			started := make(chan struct{})
+			ctx, cancel := context.WithCancel(context.Background())
+			leader := g.DoChan(ctx, "k", func(ctx context.Context) (int, error) {
+				execs.Add(1)
+				close(started)
+				<-ctx.Done()
+				return 0, tc.leader
+			})
+			<-started
+			follower := g.DoChan(context.Background(), "k", func(ctx context.Context) (int, error) {
+				execs.Add(1)
+				return 1, nil
+			})
+			<-joined
+
+			cancel()
+			if r := <-leader; !errors.Is(r.Err, tc.leader) {
+				t.Fatalf("leader = %+v", r)
+			}
+			r := <-follower
+			if tc.wantExec == 2 && (r.Val != 1 || r.Err != nil) {
+				t.Fatalf("follower = %+v, want the handed-off execution's result", r)
+			}
+			if tc.wantExec == 1 && !errors.Is(r.Err, backendErr) {
+				t.Fatalf("follower = %+v, want the shared backend error", r)
+			}
+			if n := execs.Load(); n != tc.wantExec {
+				t.Fatalf("execs = %d, want %d", n, tc.wantExec)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package singleflight
+
+import "time"
+
+// WithResultHold 让每次成功的执行在完成后把结果保留 d 时长，期间对该 key 的调用
+// 直接取用而不再执行，吸收恰好在执行完成后才到达的调用者。d <= 0 表示不保留。
+//
+// 与缓存不同，保留只覆盖执行完成后很短的时间，不需要容量与淘汰策略；
+// 失败的结果不保留，被 Forget 的执行不保留，Forget 同时丢弃 key 已保留的结果。
+// 其收益可先用 WithNearMissWindow 统计评估。
+func WithResultHold(d time.Duration) Option {
+	return func(o *options) { o.hold = d }
+}
+
+// holdFor 返回 c 完成后其结果应保留的时长。被 Forget 的执行的结果已经失效，
+// 无论 DoDetachedWait 还是 WithResultHold 都不保留。
+func (g *Group[K, V]) holdFor(c *call[V]) time.Duration {
+	if c.forgotten {
+		return 0
+	}
+	if g.cfg == nil || g.cfg.hold <= 0 {
+		return c.park
+	}
+	return max(c.park, g.cfg.hold)
+}
@@ -0,0 +1,76 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestResultHold(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	g := NewGroup[string, int](WithClock(clock), WithResultHold(time.Second))
+	ctx := context.Background()
+	var execs int
+	fn := func(ctx context.Context) (int, error) {
+		execs++
+		return execs, nil
+	}
+
+	if v, _, shared := g.Do(ctx, "k", fn); v != 1 || shared {
+		t.Fatalf("first = %d, shared=%v", v, shared)
+	}
+	clock.Advance(time.Second - time.Millisecond)
+	if v, _, shared := g.Do(ctx, "k", fn); v != 1 || !shared {
+		t.Fatalf("held = %d, shared=%v", v, shared)
+	}
+	clock.Advance(time.Millisecond)
+	if v, _, _ := g.Do(ctx, "k", fn); v != 2 {
+		t.Fatalf("after hold = %d, want 2", v)
+	}
+
+	// Forget 丢弃保留的结果。
+	g.Forget("k")
+	if v, _, _ := g.Do(ctx, "k", fn); v != 3 {
+		t.Fatalf("after forget = %d, want 3", v)
+	}
+}
+
+func TestResultHold_FailureNotHeld(t *testing.T) {
+	g := NewGroup[string, int](WithResultHold(time.Hour))
+	ctx := context.Background()
+	errBoom := errors.New("boom")
+	if _, err, _ := g.Do(ctx, "k", func(ctx context.Context) (int, error) { return 0, errBoom }); err != errBoom {
+		t.Fatalf("err = %v", err)
+	}
+	if v, err, _ := g.Do(ctx, "k", func(ctx context.Context) (int, error) { return 1, nil }); v != 1 || err != nil {
+		t.Fatalf("after failure = %d, %v", v, err)
+	}
+}
+
+// 同时使用 WithResultHold 与 DoDetachedWait 时，被 Forget 的执行同样不保留，
+// 无论保留时长来自哪一方。
+func TestResultHold_ForgottenWithDetachedWait(t *testing.T) {
+	completed := make(chan struct{}, 1)
+	g := NewGroup[string, string](
+		WithResultHold(time.Hour),
+		WithHooks(Hooks[string]{BeforeWake: func(string) { completed <- struct{}{} }}),
+	)
+	ctx := context.Background()
+	release := make(chan struct{})
+	_, err, _ := g.DoDetachedWait(ctx, "k", time.Millisecond, func(context.Context) (string, error) {
+		<-release
+		return "stale", nil
+	})
+	if !errors.Is(err, ErrMaxWaitExceeded) {
+		t.Fatalf("err = %v, want ErrMaxWaitExceeded", err)
+	}
+	g.Forget("k")
+	close(release)
+	<-completed
+
+	v, _, shared := g.Do(ctx, "k", func(context.Context) (string, error) { return "fresh", nil })
+	if v != "fresh" || shared {
+		t.Fatalf("after Forget got %q, shared=%v", v, shared)
+	}
+}
@@ -0,0 +1,52 @@
+package singleflight
+
+// Hooks 暴露 Group 内部的同步点，供测试确定性地编排 Leader / Follower 的交错，
+// 取代依赖 sleep 的时序假设。
+//
+// 所有回调都在不持有 Group 锁的情况下同步调用；回调可以阻塞，
+// 从而把被测 goroutine 停在该同步点上。生产代码不应设置 Hooks。
+type Hooks[K comparable] struct {
+	// LeaderInstalled 在 Leader 登记到 Group 之后、执行 fn 之前调用。
+	// 此后到达的同 key 调用者必然成为 Follower。
+	LeaderInstalled func(key K)
+
+	// FollowerJoined 在 Follower 登记之后、开始等待之前调用。
+	FollowerJoined func(key K)
+
+	// Queued 在 WithSerial 下调用排到正在进行的执行之后、开始等待之前调用。
+	Queued func(key K)
+
+	// BeforeWake 在 fn 返回且 key 已从 Group 移除之后、唤醒 Follower 之前调用。
+	BeforeWake func(key K)
+}
+
+// WithHooks 安装测试同步点。K 必须与 Group 的 key 类型一致。
+func WithHooks[K comparable](h Hooks[K]) Option {
+	return func(o *options) { o.rawHooks = h }
+}
+
+// 以下方法在未配置 Hooks 时只付出一次 nil 判断。
+
+func (g *Group[K, V]) hookLeaderInstalled(key K) {
+	if g.cfg != nil && g.cfg.hooks.LeaderInstalled != nil {
+		g.cfg.hooks.LeaderInstalled(key)
+	}
+}
+
+func (g *Group[K, V]) hookFollowerJoined(key K) {
+	if g.cfg != nil && g.cfg.hooks.FollowerJoined != nil {
+		g.cfg.hooks.FollowerJoined(key)
+	}
+}
+
+func (g *Group[K, V]) hookQueued(key K) {
+	if g.cfg != nil && g.cfg.hooks.Queued != nil {
+		g.cfg.hooks.Queued(key)
+	}
+}
+
+func (g *Group[K, V]) hookBeforeWake(key K) {
+	if g.cfg != nil && g.cfg.hooks.BeforeWake != nil {
+		g.cfg.hooks.BeforeWake(key)
+	}
+}
@@ -0,0 +1,47 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+)
+
+// TestHooks_DeterministicJoin 不依赖 sleep：
+// Leader 停在 fn 内，直到 Follower 通过 FollowerJoined 报告已登记。
+func TestHooks_DeterministicJoin(t *testing.T) {
+	installed := make(chan struct{})
+	joined := make(chan struct{})
+	g := NewGroup[string, int](WithHooks(Hooks[string]{
+		LeaderInstalled: func(string) { close(installed) },
+		FollowerJoined:  func(string) { close(joined) },
+	}))
+
+	leader := make(chan bool)
+	go func() {
+		_, _, shared := g.Do(context.Background(), "k", func(ctx context.Context) (int, error) {
+			<-joined
+			return 1, nil
+		})
+		leader <- shared
+	}()
+
+	<-installed
+	v, _, shared := g.Do(context.Background(), "k", func(ctx context.Context) (int, error) {
+		t.Error("follower must not execute fn")
+		return 0, nil
+	})
+	if v != 1 || !shared {
+		t.Fatalf("follower got %d, shared=%v", v, shared)
+	}
+	if !<-leader {
+		t.Fatal("leader must observe shared=true")
+	}
+}
+
+func TestWithHooks_TypeMismatchPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for mismatched key type")
+		}
+	}()
+	NewGroup[int, int](WithHooks(Hooks[string]{}))
+}
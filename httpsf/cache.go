@@ -0,0 +1,172 @@
+package httpsf
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultMaxBodyBytes 是 Transport.MaxBodyBytes 为 0 时可缓存的最大响应体。
+const defaultMaxBodyBytes = 1 << 20
+
+// entry 是缓存的一个响应，按 RFC 9111 共享缓存的规则存储与验证。
+type entry struct {
+	resp   *http.Response // Body 为 nil
+	body   []byte
+	stored time.Time
+
+	// header 与 vary 同 flight，用于检查请求能否使用该响应。
+	header http.Header
+	vary   []string
+}
+
+// response 为 req 生成一个读取缓存 Body 的独立响应。
+func (e *entry) response(req *http.Request) *http.Response {
+	resp := *e.resp
+	resp.Header = e.resp.Header.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(e.body))
+	resp.Request = req
+	return &resp
+}
+
+// fresh 报告 now 时是否可以不经验证直接使用。
+func (e *entry) fresh(now time.Time) bool {
+	cc := cacheControl(e.resp.Header)
+	if _, ok := cc["no-cache"]; ok {
+		return false
+	}
+	return lifetime(e.resp.Header, cc) > age(e.resp.Header)+now.Sub(e.stored)
+}
+
+// condition 在 h 上设置验证 e 所需的条件请求头。
+func (e *entry) condition(h http.Header) {
+	if etag := e.resp.Header.Get("ETag"); etag != "" {
+		h.Set("If-None-Match", etag)
+	}
+	if lm := e.resp.Header.Get("Last-Modified"); lm != "" {
+		h.Set("If-Modified-Since", lm)
+	}
+}
+
+// validatable 报告 e 是否带有可用于条件请求的验证器。
+func (e *entry) validatable() bool {
+	return e.resp.Header.Get("ETag") != "" || e.resp.Header.Get("Last-Modified") != ""
+}
+
+// refresh 以 304 响应的头更新 e，返回新的条目（RFC 9111 §4.3.4）。
+func (e *entry) refresh(h http.Header, now time.Time) *entry {
+	resp := *e.resp
+	resp.Header = e.resp.Header.Clone()
+	for k, v := range h {
+		if k == "Content-Length" {
+			continue
+		}
+		resp.Header[k] = v
+	}
+	n := *e
+	n.resp = &resp
+	n.stored = now
+	n.vary = parseVary(resp.Header)
+	return &n
+}
+
+// storable 报告共享缓存能否存储 resp（RFC 9111 §3 与 §3.5）。
+func storable(req *http.Request, resp *http.Response) bool {
+	if req.Method != http.MethodGet || resp.StatusCode != http.StatusOK {
+		return false
+	}
+	if _, ok := cacheControl(req.Header)["no-store"]; ok {
+		return false
+	}
+	cc := cacheControl(resp.Header)
+	for _, d := range []string{"no-store", "private"} {
+		if _, ok := cc[d]; ok {
+			return false
+		}
+	}
+	if req.Header.Get("Authorization") != "" {
+		_, public := cc["public"]
+		_, smax := cc["s-maxage"]
+		_, must := cc["must-revalidate"]
+		if !public && !smax && !must {
+			return false
+		}
+	}
+	return resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != "" ||
+		lifetime(resp.Header, cc) > 0
+}
+
+// conditional 报告请求本身是否带有条件，这类请求由调用方自己验证，直接透传。
+func conditional(req *http.Request) bool {
+	for _, h := range []string{"If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since", "If-Range"} {
+		if req.Header.Get(h) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheControl 解析 Cache-Control 指令，指令名为小写。
+func cacheControl(h http.Header) map[string]string {
+	cc := make(map[string]string)
+	for _, v := range h.Values("Cache-Control") {
+		for d := range strings.SplitSeq(v, ",") {
+			name, val, _ := strings.Cut(strings.TrimSpace(d), "=")
+			if name != "" {
+				cc[strings.ToLower(name)] = strings.Trim(val, `"`)
+			}
+		}
+	}
+	return cc
+}
+
+// lifetime 返回响应的新鲜期（RFC 9111 §4.2.1），不做启发式估计。
+func lifetime(h http.Header, cc map[string]string) time.Duration {
+	for _, d := range []string{"s-maxage", "max-age"} {
+		if v, ok := cc[d]; ok {
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil && n >= 0 {
+				return time.Duration(n) * time.Second
+			}
+			return 0
+		}
+	}
+	if exp := h.Get("Expires"); exp != "" {
+		e, err1 := http.ParseTime(exp)
+		d, err2 := http.ParseTime(h.Get("Date"))
+		if err1 == nil && err2 == nil {
+			return e.Sub(d)
+		}
+	}
+	return 0
+}
+
+// age 返回响应到达时已有的年龄（Age 头）。
+func age(h http.Header) time.Duration {
+	n, err := strconv.ParseInt(h.Get("Age"), 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return time.Duration(n) * time.Second
+}
+
+// capture 在不超过 limit 字节时保留写入的内容，用于边分发边缓存响应体。
+type capture struct {
+	buf      bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+func (c *capture) Write(p []byte) (int, error) {
+	if !c.overflow {
+		if c.buf.Len()+len(p) > c.limit {
+			c.overflow = true
+			c.buf = bytes.Buffer{}
+		} else {
+			c.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
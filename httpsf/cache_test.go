@@ -0,0 +1,118 @@
+package httpsf
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+// waitStored 等待后台的响应体复制把条目写入缓存。
+func waitStored(tr *Transport, n int) {
+	for {
+		tr.mu.Lock()
+		l := len(tr.entries)
+		tr.mu.Unlock()
+		if l >= n {
+			return
+		}
+		runtime.Gosched()
+	}
+}
+
+func fetch(t *testing.T, c *http.Client, url string, header http.Header) (int, string) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if header != nil {
+		req.Header = header
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(b)
+}
+
+func TestTransport_Revalidation(t *testing.T) {
+	var full, notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full.Add(1)
+		io.WriteString(w, "hello")
+	}))
+	defer srv.Close()
+	clock := singleflight.NewFakeClock(time.Now())
+	tr := &Transport{CacheEntries: 16, Clock: clock}
+	c := &http.Client{Transport: tr}
+
+	if code, body := fetch(t, c, srv.URL, nil); code != 200 || body != "hello" {
+		t.Fatalf("first = %d %q", code, body)
+	}
+	waitStored(tr, 1)
+
+	// 新鲜期内直接复用。
+	if _, body := fetch(t, c, srv.URL, nil); body != "hello" || full.Load() != 1 || notModified.Load() != 0 {
+		t.Fatalf("fresh hit = %q, full=%d, 304=%d", body, full.Load(), notModified.Load())
+	}
+
+	// 过期后以条件请求验证，304 刷新条目并复用缓存的 Body。
+	clock.Advance(61 * time.Second)
+	if code, body := fetch(t, c, srv.URL, nil); code != 200 || body != "hello" {
+		t.Fatalf("revalidated = %d %q", code, body)
+	}
+	if full.Load() != 1 || notModified.Load() != 1 {
+		t.Fatalf("full=%d, 304=%d after revalidation", full.Load(), notModified.Load())
+	}
+	if _, body := fetch(t, c, srv.URL, nil); body != "hello" || notModified.Load() != 1 {
+		t.Fatalf("refreshed entry not fresh: %q, 304=%d", body, notModified.Load())
+	}
+
+	// Cache-Control: no-cache 的请求总是验证。
+	fetch(t, c, srv.URL, http.Header{"Cache-Control": {"no-cache"}})
+	if notModified.Load() != 2 {
+		t.Fatalf("no-cache request was not revalidated: 304=%d", notModified.Load())
+	}
+}
+
+func TestStorable(t *testing.T) {
+	req := func(h http.Header) *http.Request {
+		r, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+		r.Header = h
+		return r
+	}
+	resp := func(h http.Header) *http.Response {
+		return &http.Response{StatusCode: http.StatusOK, Header: h}
+	}
+	auth := http.Header{"Authorization": {"Bearer x"}}
+	for _, tc := range []struct {
+		name string
+		req  http.Header
+		resp http.Header
+		want bool
+	}{
+		{"etag", nil, http.Header{"Etag": {`"a"`}}, true},
+		{"max-age", nil, http.Header{"Cache-Control": {"max-age=10"}}, true},
+		{"no validator", nil, http.Header{}, false},
+		{"no-store", nil, http.Header{"Etag": {`"a"`}, "Cache-Control": {"no-store"}}, false},
+		{"private", nil, http.Header{"Etag": {`"a"`}, "Cache-Control": {"private"}}, false},
+		{"authorized", auth, http.Header{"Etag": {`"a"`}}, false},
+		{"authorized public", auth, http.Header{"Etag": {`"a"`}, "Cache-Control": {"public"}}, true},
+	} {
+		if got := storable(req(tc.req), resp(tc.resp)); got != tc.want {
+			t.Errorf("%s: storable = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
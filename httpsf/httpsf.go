@@ -0,0 +1,332 @@
+// Package httpsf 提供合并并发相同 HTTP 请求的 http.RoundTripper。
+//
+// 同一时刻对同一资源的多个 GET / HEAD 只向上游发出一次，响应体写入共享的
+// spool 缓冲，每个调用者拿到独立的 Body，从头开始按自己的节奏读取。
+//
+// 合并 key 由方法、URL、Transport.Headers 中的请求头以及上游 Vary 响应头
+// 声明的请求头共同决定，凭据以哈希参与，响应绝不会在不兼容的请求之间共享。
+//
+// 设置 CacheEntries 后 Transport 同时是一个按 RFC 9111 工作的共享验证缓存：
+// 新鲜的响应直接复用，过期的响应以条件请求（If-None-Match / If-Modified-Since）
+// 在合并下验证一次，304 刷新后的条目分发给所有等待者。
+package httpsf
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oy3o/singleflight"
+	"github.com/oy3o/singleflight/internal/spool"
+)
+
+// DefaultHeaders 是 Transport.Headers 为 nil 时参与合并 key 的请求头。
+var DefaultHeaders = []string{"Accept", "Accept-Encoding", "Accept-Language"}
+
+// credentialHeaders 总是以哈希参与合并 key，凭据不同的请求不会共享响应，
+// 且 key 本身不包含明文凭据。
+var credentialHeaders = []string{"Authorization", "Cookie"}
+
+// Transport 合并并发的相同请求，零值可用（使用 http.DefaultTransport）。
+//
+// 带请求体、Range 请求以及 GET / HEAD 之外的方法直接透传给 Base。
+type Transport struct {
+	// Base 为实际发出请求的 RoundTripper，nil 时使用 http.DefaultTransport。
+	Base http.RoundTripper
+
+	// Headers 为除 URL 外参与合并 key 的请求头，nil 时使用 DefaultHeaders。
+	Headers []string
+
+	// CacheEntries 为缓存的最大响应数，<= 0 表示只合并并发请求而不缓存。
+	CacheEntries int
+
+	// MaxBodyBytes 为可缓存的最大响应体，0 时为 1 MiB。
+	MaxBodyBytes int
+
+	// MemLimit 为每个共享响应体保存在内存中的最大字节数，超出部分溢出到
+	// SpoolDir 下的临时文件（为空时使用 os.TempDir）。<= 0 表示全部保存在内存中。
+	MemLimit int
+	SpoolDir string
+
+	// Clock 用于缓存新鲜度判断，nil 时使用 singleflight.SystemClock。
+	Clock singleflight.Clock
+
+	group singleflight.Group[string, *flight]
+
+	// vary 记录每个资源最近一次响应的 Vary 头，之后的请求据此扩展 key。
+	mu      sync.Mutex
+	vary    map[string][]string
+	entries map[string]*entry
+}
+
+// flight 是一次上游请求的共享结果。
+type flight struct {
+	resp *http.Response
+	body *spool.Spool
+
+	// entry 非 nil 表示缓存的响应经 304 验证后被复用，此时 resp 与 body 为 nil。
+	entry *entry
+
+	// header 为发起请求的头，vary 为响应声明的 Vary 头，
+	// 用于检查在 Vary 尚未获知时合并进来的请求是否兼容。
+	header http.Header
+	vary   []string
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+// RoundTrip 实现 http.RoundTripper。
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !coalescable(req) {
+		return t.base().RoundTrip(req)
+	}
+	key, ok := t.key(req)
+	if !ok {
+		return t.base().RoundTrip(req)
+	}
+
+	var stale *entry
+	if t.caching(req) {
+		if e := t.lookup(key, req); e != nil {
+			if _, revalidate := cacheControl(req.Header)["no-cache"]; !revalidate && e.fresh(t.now()) {
+				return e.response(req), nil
+			}
+			if e.validatable() {
+				stale = e
+			}
+		}
+	}
+
+	f, err, shared := t.group.Do(req.Context(), key, func(ctx context.Context) (*flight, error) {
+		return t.fetch(ctx, req, stale)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if shared && !f.compatible(req) {
+		return t.base().RoundTrip(req)
+	}
+	if f.entry != nil {
+		return f.entry.response(req), nil
+	}
+	body := f.body.NewReader()
+	if body == nil {
+		// 所有读者在我们加入前都已放弃，下载已被中止。
+		return t.base().RoundTrip(req)
+	}
+	resp := *f.resp
+	resp.Header = f.resp.Header.Clone()
+	resp.Trailer = f.resp.Trailer.Clone()
+	resp.Body = body
+	resp.Request = req
+	return &resp, nil
+}
+
+func coalescable(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		return false
+	}
+	return req.Header.Get("Range") == ""
+}
+
+// key 生成合并 key。资源声明了 Vary: * 时返回 false，请求不应合并。
+func (t *Transport) key(req *http.Request) (string, bool) {
+	resource := req.Method + " " + req.URL.String()
+	t.mu.Lock()
+	vary := t.vary[resource]
+	t.mu.Unlock()
+	if slices.Contains(vary, "*") {
+		return "", false
+	}
+
+	headers := t.Headers
+	if headers == nil {
+		headers = DefaultHeaders
+	}
+	names := make([]string, 0, len(headers)+len(vary))
+	for _, h := range headers {
+		names = append(names, http.CanonicalHeaderKey(h))
+	}
+	names = append(names, vary...)
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	var b strings.Builder
+	b.WriteString(resource)
+	for _, h := range names {
+		if slices.Contains(credentialHeaders, h) {
+			continue
+		}
+		b.WriteString("\n" + h + ": " + strings.Join(req.Header.Values(h), ", "))
+	}
+	for _, h := range credentialHeaders {
+		if v := req.Header.Values(h); len(v) > 0 {
+			sum := sha256.Sum256([]byte(strings.Join(v, "\n")))
+			b.WriteString("\n" + h + ": " + hex.EncodeToString(sum[:8]))
+		}
+	}
+	return b.String(), true
+}
+
+// fetch 以 Leader 身份发出请求。请求与 Body 的读取都与发起者的 ctx 解耦，
+// 只有当所有读者都关闭时才被中止。
+//
+// stale 非 nil 时发出条件请求，304 响应刷新 stale 并让所有等待者复用缓存的 Body。
+func (t *Transport) fetch(ctx context.Context, req *http.Request, stale *entry) (*flight, error) {
+	dctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	out := req.Clone(dctx)
+	if stale != nil {
+		stale.condition(out.Header)
+	}
+	resp, err := t.base().RoundTrip(out)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if stale != nil && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		cancel()
+		e := stale.refresh(resp.Header, t.now())
+		t.learn(req, e.vary)
+		t.store(req, e)
+		return &flight{entry: e, header: e.header, vary: e.vary}, nil
+	}
+
+	vary := parseVary(resp.Header)
+	t.learn(req, vary)
+
+	s := spool.NewOverflow(cancel, t.MemLimit, t.SpoolDir)
+	var body io.Writer = s
+	var tee *capture
+	if t.CacheEntries > 0 && storable(req, resp) && !slices.Contains(vary, "*") {
+		tee = &capture{limit: t.maxBodyBytes()}
+		body = io.MultiWriter(s, tee)
+	}
+
+	shared := *resp
+	shared.Body = nil
+	header := req.Header.Clone()
+	go func() {
+		_, err := io.Copy(body, resp.Body)
+		resp.Body.Close()
+		s.CloseWithError(err)
+		cancel()
+		if err == nil && tee != nil && !tee.overflow {
+			t.store(req, &entry{resp: &shared, body: tee.buf.Bytes(), stored: t.now(), header: header, vary: vary})
+		}
+	}()
+	return &flight{resp: &shared, body: s, header: header, vary: vary}, nil
+}
+
+// learn 记录资源的 Vary 头。
+func (t *Transport) learn(req *http.Request, vary []string) {
+	resource := req.Method + " " + req.URL.String()
+	t.mu.Lock()
+	if len(vary) == 0 {
+		delete(t.vary, resource)
+	} else {
+		if t.vary == nil {
+			t.vary = make(map[string][]string)
+		}
+		t.vary[resource] = vary
+	}
+	t.mu.Unlock()
+}
+
+// caching 报告 req 能否使用缓存。自带条件的请求由调用方自己验证。
+func (t *Transport) caching(req *http.Request) bool {
+	if t.CacheEntries <= 0 || req.Method != http.MethodGet || conditional(req) {
+		return false
+	}
+	_, noStore := cacheControl(req.Header)["no-store"]
+	return !noStore
+}
+
+// lookup 返回 key 下可供 req 使用的缓存条目。
+func (t *Transport) lookup(key string, req *http.Request) *entry {
+	t.mu.Lock()
+	e := t.entries[key]
+	t.mu.Unlock()
+	if e == nil || !varyMatch(e.vary, e.header, req) {
+		return nil
+	}
+	return e
+}
+
+// store 以学习到 Vary 之后的 key 保存 e，满时随机淘汰一个条目。
+func (t *Transport) store(req *http.Request, e *entry) {
+	key, ok := t.key(req)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.entries == nil {
+		t.entries = make(map[string]*entry)
+	}
+	if _, ok := t.entries[key]; !ok && len(t.entries) >= t.CacheEntries {
+		for k := range t.entries {
+			delete(t.entries, k)
+			break
+		}
+	}
+	t.entries[key] = e
+}
+
+func (t *Transport) maxBodyBytes() int {
+	if t.MaxBodyBytes > 0 {
+		return t.MaxBodyBytes
+	}
+	return defaultMaxBodyBytes
+}
+
+func (t *Transport) now() time.Time {
+	if t.Clock != nil {
+		return t.Clock.Now()
+	}
+	return time.Now()
+}
+
+// compatible 报告 req 能否使用 f 的响应。
+func (f *flight) compatible(req *http.Request) bool {
+	return varyMatch(f.vary, f.header, req)
+}
+
+// varyMatch 报告 req 在 vary 声明的请求头上是否与 header 一致。
+func varyMatch(vary []string, header http.Header, req *http.Request) bool {
+	for _, h := range vary {
+		if h == "*" || !slices.Equal(req.Header.Values(h), header.Values(h)) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseVary 返回规范化、排序去重后的 Vary 请求头名。
+func parseVary(h http.Header) []string {
+	var names []string
+	for _, v := range h.Values("Vary") {
+		for name := range strings.SplitSeq(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
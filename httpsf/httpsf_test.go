@@ -0,0 +1,121 @@
+package httpsf
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// get 并发发出 headers 描述的请求，返回各自读到的 Body。
+func get(t *testing.T, c *http.Client, url string, headers []http.Header) []string {
+	t.Helper()
+	got := make([]string, len(headers))
+	var wg sync.WaitGroup
+	for i, h := range headers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, url, nil)
+			req.Header = h
+			resp, err := c.Do(req)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer resp.Body.Close()
+			b, _ := io.ReadAll(resp.Body)
+			got[i] = string(b)
+		}()
+	}
+	wg.Wait()
+	return got
+}
+
+func TestTransport_SharedBodiesAreIndependent(t *testing.T) {
+	var hits atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			<-release
+		}
+		io.WriteString(w, "accept="+r.Header.Get("Accept"))
+	}))
+	defer srv.Close()
+	c := &http.Client{Transport: &Transport{}}
+
+	var got []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		headers := make([]http.Header, 8)
+		for i := range headers {
+			headers[i] = http.Header{"Accept": {"text/plain"}}
+		}
+		got = get(t, c, srv.URL, headers)
+	}()
+	for hits.Load() == 0 {
+		runtime.Gosched()
+	}
+	close(release)
+	<-done
+	// 合并到同一次请求的读者各自读到完整 Body。
+	for i, s := range got {
+		if s != "accept=text/plain" {
+			t.Fatalf("reader %d got %q", i, s)
+		}
+	}
+
+	// Accept 不同的请求绝不共享响应。
+	got = get(t, c, srv.URL, []http.Header{{"Accept": {"a"}}, {"Accept": {"b"}}})
+	if got[0] != "accept=a" || got[1] != "accept=b" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestTransport_HonorsVary(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Vary", "X-Tenant")
+		io.WriteString(w, "tenant="+r.Header.Get("X-Tenant"))
+	}))
+	defer srv.Close()
+	tr := &Transport{}
+	c := &http.Client{Transport: tr}
+
+	// 首次请求之前 Vary 未知，合并进来的请求也要经过兼容性检查。
+	for range 20 {
+		got := get(t, c, srv.URL, []http.Header{{"X-Tenant": {"a"}}, {"X-Tenant": {"b"}}, {"X-Tenant": {"a"}}})
+		if got[0] != "tenant=a" || got[1] != "tenant=b" || got[2] != "tenant=a" {
+			t.Fatalf("got %q", got)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	ka, _ := tr.key(req)
+	req.Header.Set("X-Tenant", "b")
+	kb, _ := tr.key(req)
+	if ka == kb {
+		t.Fatal("learned Vary header does not take part in the key")
+	}
+}
+
+func TestTransport_CredentialsAreHashed(t *testing.T) {
+	var tr Transport
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/x", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	k1, _ := tr.key(req)
+	req.Header.Set("Authorization", "Bearer other")
+	k2, _ := tr.key(req)
+	if k1 == k2 {
+		t.Fatal("different credentials share a key")
+	}
+	for _, k := range []string{k1, k2} {
+		if strings.Contains(k, "secret") || strings.Contains(k, "other") {
+			t.Fatalf("key leaks credentials: %q", k)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Package idemsf 按调用方提供的幂等键合并有副作用的操作，并在一段时间内记住其结果。
+//
+// 客户端超时重试时携带相同的幂等键：原操作仍在进行时重试加入它，
+// 已完成时直接重放保留的结果，副作用只发生一次。
+package idemsf
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+// Store 以幂等键合并操作并保留其结果，零值可用（只合并进行中的操作，不保留结果）。
+type Store[V any] struct {
+	// Window 为操作完成后结果的保留时长，期间相同幂等键的调用直接重放结果。
+	// <= 0 表示不保留。
+	Window time.Duration
+
+	// RetainError 报告失败的结果是否也应保留。nil 时只保留成功的结果，
+	// 失败的操作可以被重试。操作可能已产生部分副作用时，应保留其错误而不是重新执行。
+	RetainError func(error) bool
+
+	// Clock 用于保留期的判断，nil 时使用 singleflight.SystemClock。
+	Clock singleflight.Clock
+
+	group singleflight.Group[string, V]
+
+	mu      sync.Mutex
+	results map[string]outcome[V]
+	swept   int
+}
+
+type outcome[V any] struct {
+	val     V
+	err     error
+	expires time.Time
+}
+
+func (s *Store[V]) now() time.Time {
+	if s.Clock != nil {
+		return s.Clock.Now()
+	}
+	return time.Now()
+}
+
+// Do 以幂等键 key 执行 fn。相同 key 的操作在进行中时加入它，在保留期内已完成时
+// 重放其结果；replayed 报告结果是否来自另一次调用发起的执行。
+//
+// fn 以脱离 ctx 取消的 context 执行：调用者放弃等待时操作照常完成并保留结果，
+// 供携带同一幂等键的重试取用。调用者收到的错误满足
+// errors.Is(err, singleflight.ErrWaiterCancelled)。与 Group.DoChan 相同，
+// fn 在内部 goroutine 上执行，其 panic 无法被调用方恢复。
+func (s *Store[V]) Do(ctx context.Context, key string, fn func(ctx context.Context) (V, error)) (v V, err error, replayed bool) {
+	if o, ok := s.lookup(key); ok {
+		return o.val, o.err, true
+	}
+	// hit 只由本调用的 fn 写入，经 channel 接收结果后读取。
+	var hit bool
+	ch := s.group.DoChan(context.WithoutCancel(ctx), key, func(ctx context.Context) (V, error) {
+		// 上一次执行可能在我们查询之后、成为 Leader 之前刚完成。
+		if o, ok := s.lookup(key); ok {
+			hit = true
+			return o.val, o.err
+		}
+		v, err := fn(ctx)
+		// 在唤醒等待者之前写入，完成之后到达的重试一定能查到。
+		s.retain(key, v, err)
+		return v, err
+	})
+	select {
+	case r := <-ch:
+		return r.Val, r.Err, r.Shared || hit
+	case <-ctx.Done():
+		var zero V
+		return zero, &singleflight.WaitError{Err: ctx.Err(), Cause: context.Cause(ctx)}, false
+	}
+}
+
+// Forget 丢弃 key 保留的结果，之后相同 key 的调用会重新执行。进行中的操作不受影响。
+func (s *Store[V]) Forget(key string) {
+	s.mu.Lock()
+	delete(s.results, key)
+	s.mu.Unlock()
+}
+
+func (s *Store[V]) lookup(key string) (outcome[V], bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.results[key]
+	if !ok {
+		return o, false
+	}
+	if !s.now().Before(o.expires) {
+		delete(s.results, key)
+		return o, false
+	}
+	return o, true
+}
+
+func (s *Store[V]) retain(key string, v V, err error) {
+	if s.Window <= 0 || err != nil && (s.RetainError == nil || !s.RetainError(err)) {
+		return
+	}
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.results == nil {
+		s.results = make(map[string]outcome[V])
+	}
+	// 翻倍时清理过期项，兜底不再被重试的 key。
+	if len(s.results) >= 2*s.swept+16 {
+		for k, o := range s.results {
+			if !now.Before(o.expires) {
+				delete(s.results, k)
+			}
+		}
+		s.swept = len(s.results)
+	}
+	s.results[key] = outcome[V]{val: v, err: err, expires: now.Add(s.Window)}
+}
@@ -0,0 +1,77 @@
+package idemsf
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+func TestStore_ReplayAfterCancelledCaller(t *testing.T) {
+	clock := singleflight.NewFakeClock(time.Unix(0, 0))
+	s := &Store[int]{Window: time.Minute, Clock: clock}
+	charges := 0
+	started := make(chan struct{})
+	release := make(chan struct{})
+	charge := func(ctx context.Context) (int, error) {
+		charges++
+		close(started)
+		<-release
+		return 42, ctx.Err()
+	}
+
+	// 原请求在操作完成前超时，操作本身继续进行。
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error)
+	go func() {
+		_, err, _ := s.Do(ctx, "req-1", charge)
+		first <- err
+	}()
+	<-started
+	cancel()
+	if err := <-first; !errors.Is(err, singleflight.ErrWaiterCancelled) {
+		t.Fatalf("cancelled caller err = %v", err)
+	}
+	close(release)
+
+	// 重试重放原操作的结果而不再执行。
+	v, err, replayed := s.Do(context.Background(), "req-1", charge)
+	if v != 42 || err != nil || !replayed {
+		t.Fatalf("retry = %d, %v, replayed=%v", v, err, replayed)
+	}
+	if charges != 1 {
+		t.Fatalf("charges = %d", charges)
+	}
+
+	clock.Advance(time.Minute)
+	release = make(chan struct{})
+	close(release)
+	started = make(chan struct{})
+	if _, _, replayed := s.Do(context.Background(), "req-1", charge); replayed || charges != 2 {
+		t.Fatalf("after window replayed=%v, charges=%d", replayed, charges)
+	}
+}
+
+func TestStore_Errors(t *testing.T) {
+	boom := errors.New("boom")
+	fail := func(context.Context) (int, error) { return 0, boom }
+	ok := func(context.Context) (int, error) { return 1, nil }
+
+	s := &Store[int]{Window: time.Minute}
+	s.Do(context.Background(), "k", fail)
+	if v, err, replayed := s.Do(context.Background(), "k", ok); v != 1 || err != nil || replayed {
+		t.Fatalf("failure must not be retained by default: %d, %v, %v", v, err, replayed)
+	}
+
+	s = &Store[int]{Window: time.Minute, RetainError: func(err error) bool { return errors.Is(err, boom) }}
+	s.Do(context.Background(), "k", fail)
+	if _, err, replayed := s.Do(context.Background(), "k", ok); !errors.Is(err, boom) || !replayed {
+		t.Fatalf("retained failure = %v, replayed=%v", err, replayed)
+	}
+	s.Forget("k")
+	if v, _, _ := s.Do(context.Background(), "k", ok); v != 1 {
+		t.Fatalf("after Forget = %d", v)
+	}
+}
@@ -0,0 +1,76 @@
+package singleflight
+
+import "time"
+
+// CallInfo 描述一次进行中的执行。
+type CallInfo struct {
+	// Start 为 Leader 开始执行的时刻。执行既非由 DoResult 发起、
+	// Group 也未开启 WithTiming 时为零值。
+	Start time.Time
+	// Elapsed 为调用 Inspect / Range 时已执行的时长，Start 为零值时为 0。
+	Elapsed time.Duration
+	// Waiters 为当前等待的 Follower 数量。
+	Waiters int
+	// Shared 表示已有 Follower 加入，结果将被共享。
+	Shared bool
+	// Forgotten 表示 key 已被 Forget（或被 WithWatchdog 释放），执行仍在运行，
+	// 但新的调用不会再加入它。
+	Forgotten bool
+	// Origin 为发起执行的调用方，未开启 WithLeaderOrigin 时为 nil。
+	Origin *Origin
+}
+
+// Range 对每个进行中的执行调用 f，f 返回 false 时停止。
+// 已被 Forget 但仍在运行的执行同样会被访问，因此同一个 key 可能出现多次。
+//
+// f 在 Group 锁外对调用时刻的快照调用，可以回调 Group（例如 Forget 选中的 key），
+// 但看到的执行可能已经结束。
+func (g *Group[K, V]) Range(f func(key K, info CallInfo) bool) {
+	type item struct {
+		key  K
+		info CallInfo
+	}
+	now := g.now()
+	g.mu.Lock()
+	items := make([]item, 0, len(g.calls))
+	for key, c := range g.calls {
+		items = append(items, item{key, c.info(now)})
+	}
+	for key, cs := range g.orphans {
+		for _, c := range cs {
+			items = append(items, item{key, c.info(now)})
+		}
+	}
+	g.mu.Unlock()
+
+	for _, it := range items {
+		if !f(it.key, it.info) {
+			return
+		}
+	}
+}
+
+// Inspect 返回 key 上进行中的执行。没有进行中的执行时，返回最近一次被 Forget
+// 但仍在运行的执行（Forgotten 为 true）；两者都没有时 ok 为 false。
+func (g *Group[K, V]) Inspect(key K) (info CallInfo, ok bool) {
+	key = g.canonical(key)
+	now := g.now()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if c, ok := g.calls[key]; ok {
+		return c.info(now), true
+	}
+	if cs := g.orphans[key]; len(cs) > 0 {
+		return cs[len(cs)-1].info(now), true
+	}
+	return CallInfo{}, false
+}
+
+// info 生成 c 在 now 时的快照，调用时必须持有 g.mu。
+func (c *call[V]) info(now time.Time) CallInfo {
+	info := CallInfo{Start: c.start, Waiters: int(c.dups.Load()), Shared: c.dups.Load() > 0, Forgotten: c.forgotten, Origin: c.origin}
+	if !c.start.IsZero() {
+		info.Elapsed = now.Sub(c.start)
+	}
+	return info
+}
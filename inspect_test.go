@@ -0,0 +1,103 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRange(t *testing.T) {
+	clock := NewFakeClock(time.Unix(100, 0))
+	joined := make(chan struct{}, 1)
+	g := NewGroup[string, int](
+		WithClock(clock),
+		WithTiming(),
+		WithHooks(Hooks[string]{FollowerJoined: func(string) { joined <- struct{}{} }}),
+	)
+
+	release := make(chan struct{})
+	var results []<-chan Result[int]
+	for _, key := range []string{"a", "b"} {
+		started := make(chan struct{})
+		results = append(results, g.DoChan(context.Background(), key, func(context.Context) (int, error) {
+			close(started)
+			<-release
+			return 0, nil
+		}))
+		<-started
+	}
+	results = append(results, g.DoChan(context.Background(), "a", nil))
+	<-joined
+
+	got := make(map[string]CallInfo)
+	g.Range(func(key string, info CallInfo) bool {
+		got[key] = info
+		return true
+	})
+	if len(got) != 2 {
+		t.Fatalf("Range visited %v", got)
+	}
+	if a := got["a"]; a.Waiters != 1 || !a.Shared || !a.Start.Equal(clock.Now()) {
+		t.Fatalf("a = %+v", a)
+	}
+	if b := got["b"]; b.Waiters != 0 || b.Shared {
+		t.Fatalf("b = %+v", b)
+	}
+
+	n := 0
+	g.Range(func(string, CallInfo) bool { n++; return false })
+	if n != 1 {
+		t.Fatalf("Range continued after false: %d calls", n)
+	}
+
+	close(release)
+	for _, r := range results {
+		<-r
+	}
+}
+
+func TestInspect(t *testing.T) {
+	clock := NewFakeClock(time.Unix(100, 0))
+	g := NewGroup[string, int](WithClock(clock), WithTiming())
+	if _, ok := g.Inspect("k"); ok {
+		t.Fatal("Inspect of an idle key reported a call")
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	res := g.DoChan(context.Background(), "k", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 0, nil
+	})
+	<-started
+	clock.Advance(time.Second)
+	info, ok := g.Inspect("k")
+	if !ok || info.Elapsed != time.Second || info.Forgotten {
+		t.Fatalf("Inspect = %+v, %v", info, ok)
+	}
+
+	g.Forget("k")
+	if info, ok := g.Inspect("k"); !ok || !info.Forgotten {
+		t.Fatalf("after Forget Inspect = %+v, %v", info, ok)
+	}
+	seen := 0
+	g.Range(func(key string, info CallInfo) bool {
+		if key == "k" && info.Forgotten {
+			seen++
+		}
+		return true
+	})
+	if seen != 1 {
+		t.Fatalf("Range saw the forgotten call %d times", seen)
+	}
+
+	close(release)
+	<-res
+	if _, ok := g.Inspect("k"); ok {
+		t.Fatal("forgotten call still reported after it finished")
+	}
+	if len(g.orphans) != 0 {
+		t.Fatalf("orphans retained: %v", g.orphans)
+	}
+}
@@ -0,0 +1,25 @@
+package singleflight
+
+import "context"
+
+// DoFunc 是一次执行：以 key 运行本次调用的 fn。
+type DoFunc[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+// WithInterceptor 在每次执行外层包裹 ic，用于统一添加日志、追踪、鉴权检查、
+// 超时等横切逻辑，而不必在每个调用点包装 fn。K、V 必须与 Group 一致。
+//
+// 拦截器只包裹真正的执行（每次合并的执行一次），不包裹 Follower 的等待。
+// 多次使用时先注册的在外层。拦截器在 WithExecTimeout 设置的超时之内运行，
+// 可以不调用 next 而直接返回，例如鉴权失败。
+func WithInterceptor[K comparable, V any](ic func(next DoFunc[K, V]) DoFunc[K, V]) Option {
+	return func(o *options) { o.rawInterceptors = append(o.rawInterceptors, ic) }
+}
+
+// intercept 用配置的拦截器包裹 fn。
+func (g *Group[K, V]) intercept(fn func(ctx context.Context) (V, error)) DoFunc[K, V] {
+	next := DoFunc[K, V](func(ctx context.Context, _ K) (V, error) { return fn(ctx) })
+	for i := len(g.cfg.interceptors) - 1; i >= 0; i-- {
+		next = g.cfg.interceptors[i](next)
+	}
+	return next
+}
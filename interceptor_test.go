@@ -0,0 +1,48 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestWithInterceptor_Order(t *testing.T) {
+	var trace []string
+	tag := func(name string) func(DoFunc[string, int]) DoFunc[string, int] {
+		return func(next DoFunc[string, int]) DoFunc[string, int] {
+			return func(ctx context.Context, key string) (int, error) {
+				trace = append(trace, name+">"+key)
+				v, err := next(ctx, key)
+				trace = append(trace, "<"+name)
+				return v + 1, err
+			}
+		}
+	}
+	g := NewGroup[string, int](WithInterceptor(tag("outer")), WithInterceptor(tag("inner")))
+	v, err, _ := g.Do(context.Background(), "k", func(context.Context) (int, error) {
+		trace = append(trace, "fn")
+		return 0, nil
+	})
+	if v != 2 || err != nil {
+		t.Fatalf("got %d, %v", v, err)
+	}
+	want := []string{"outer>k", "inner>k", "fn", "<inner", "<outer"}
+	if !slices.Equal(trace, want) {
+		t.Fatalf("trace = %v, want %v", trace, want)
+	}
+}
+
+func TestWithInterceptor_ShortCircuit(t *testing.T) {
+	denied := errors.New("denied")
+	g := NewGroup[string, int](WithInterceptor(func(next DoFunc[string, int]) DoFunc[string, int] {
+		return func(ctx context.Context, key string) (int, error) { return 0, denied }
+	}))
+	_, err, _ := g.Do(context.Background(), "k", func(context.Context) (int, error) {
+		t.Error("fn must not run")
+		return 0, nil
+	})
+	if !errors.Is(err, denied) {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package singleflight
+
+import (
+	"fmt"
+	"sync"
+)
+
+// WithInterning 让 Group.Intern 为至多 n 个不同的 key 复用同一份字符串，
+// 表满后随机淘汰一项。K 的类型必须是 string，否则 NewGroup panic。
+// n <= 0 表示不驻留。
+//
+// 适用于 key 集合有限且反复出现的场景：调用方在可复用的 []byte 中拼出 key，
+// 命中时 Intern 不分配内存。驻留表随 Group 一起释放。
+func WithInterning(n int) Option {
+	return func(o *options) { o.intern = n }
+}
+
+// internTable 使用独立的读写锁，Intern 不与 Do 争用 g.mu。
+type internTable[K comparable] struct {
+	mu  sync.RWMutex
+	max int
+	m   map[string]K
+}
+
+func newInternTable[K comparable](n int) *internTable[K] {
+	if _, ok := any("").(K); !ok {
+		var k K
+		panic(fmt.Sprintf("singleflight: WithInterning: key type %T is not string", k))
+	}
+	return &internTable[K]{max: n, m: make(map[string]K)}
+}
+
+// Intern 返回内容为 b 的 key。配置了 WithInterning 时，相同内容的 key
+// 共享同一份字符串，命中时不分配内存；否则每次返回新分配的 key。
+// 仅适用于 K 为 string 的 Group，b 在返回后可以被调用方复用。
+func (g *Group[K, V]) Intern(b []byte) K {
+	var t *internTable[K]
+	if g.cfg != nil {
+		t = g.cfg.interned
+	}
+	if t == nil {
+		return any(string(b)).(K)
+	}
+	// m[string(b)] 形式的查找不会分配临时字符串。
+	t.mu.RLock()
+	k, ok := t.m[string(b)]
+	t.mu.RUnlock()
+	if ok {
+		return k
+	}
+
+	s := string(b)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if k, ok := t.m[s]; ok {
+		return k
+	}
+	if len(t.m) >= t.max {
+		for old := range t.m {
+			delete(t.m, old)
+			break
+		}
+	}
+	k = any(s).(K)
+	t.m[s] = k
+	return k
+}
@@ -0,0 +1,46 @@
+package singleflight
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func TestIntern(t *testing.T) {
+	g := NewGroup[string, int](WithInterning(2))
+	buf := []byte("user:1")
+	a := g.Intern(buf)
+	buf[5] = '2'
+	b := g.Intern(buf)
+	if a != "user:1" || b != "user:2" {
+		t.Fatalf("Intern = %q, %q", a, b)
+	}
+	buf[5] = '1'
+	if again := g.Intern(buf); unsafe.StringData(again) != unsafe.StringData(a) {
+		t.Fatal("repeated key was not interned")
+	}
+	if n := testing.AllocsPerRun(100, func() { g.Intern(buf) }); n != 0 {
+		t.Fatalf("Intern hit allocates %v times", n)
+	}
+
+	// 超出上限时淘汰旧项，结果仍然正确。
+	if k := g.Intern([]byte("user:3")); k != "user:3" {
+		t.Fatalf("Intern = %q", k)
+	}
+	if n := len(g.cfg.interned.m); n != 2 {
+		t.Fatalf("table holds %d keys, want 2", n)
+	}
+
+	var zero Group[string, int]
+	if k := zero.Intern(buf); k != "user:1" {
+		t.Fatalf("zero Group Intern = %q", k)
+	}
+}
+
+func TestIntern_NonStringKey(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("WithInterning on int keys must panic")
+		}
+	}()
+	NewGroup[int, int](WithInterning(1))
+}
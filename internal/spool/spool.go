@@ -0,0 +1,218 @@
+// Package spool 实现单写多读的共享缓冲：写端按到达顺序追加数据，
+// 每个读端从头开始以各自的节奏独立消费完整内容。
+//
+// 这是把一个 io.ReadCloser 安全地分发给多个合并调用者的基础设施，
+// 直接共享同一个 Body 会让读者互相抢夺字节。
+//
+// 内容超过内存上限的部分溢出到临时文件，慢读者不会让大对象常驻内存。
+package spool
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// Spool 是追加写入、可多次独立读取的缓冲区。
+type Spool struct {
+	mu   sync.Mutex
+	buf  []byte
+	done bool
+	err  error
+
+	// wait 在每次写入或关闭时被 close 并替换，用于广播唤醒阻塞的读者。
+	wait chan struct{}
+
+	refs    int
+	onIdle  func()
+	idleRan bool
+
+	// memLimit > 0 时 buf 至多保存这么多字节，之后的内容写入 dir 下的 file。
+	// fileSize 为 file 中已对读者可见的字节数；file 只由写端追加，
+	// 读写都使用带偏移的 ReadAt / WriteAt，写入本身无需持锁。
+	memLimit int
+	dir      string
+	file     *os.File
+	fileSize int64
+	// released 表示溢出文件已删除，之后不能再创建读者。
+	released bool
+}
+
+// New 创建完全位于内存的 Spool。onIdle 在写入完成前所有读者都已关闭时调用一次，
+// 调用方可借此中止已无人消费的下载。
+func New(onIdle func()) *Spool {
+	return &Spool{wait: make(chan struct{}), onIdle: onIdle}
+}
+
+// NewOverflow 创建内存中至多保存 memLimit 字节的 Spool，其余内容溢出到
+// dir 下的临时文件（dir 为空时使用 os.TempDir）。memLimit <= 0 等同于 New。
+//
+// 溢出文件在写入完成且所有读者关闭后删除，此后 NewReader 返回 nil。
+func NewOverflow(onIdle func(), memLimit int, dir string) *Spool {
+	s := New(onIdle)
+	s.memLimit = memLimit
+	s.dir = dir
+	return s
+}
+
+// Write 追加数据并唤醒所有等待中的读者。
+func (s *Spool) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	if s.done {
+		s.mu.Unlock()
+		return 0, io.ErrClosedPipe
+	}
+	n := 0
+	if s.memLimit <= 0 {
+		n = len(p)
+	} else if s.file == nil {
+		n = min(len(p), s.memLimit-len(s.buf))
+	}
+	s.buf = append(s.buf, p[:n]...)
+	if n > 0 {
+		s.broadcastLocked()
+	}
+	if n == len(p) {
+		s.mu.Unlock()
+		return n, nil
+	}
+	if s.released {
+		s.mu.Unlock()
+		return n, io.ErrClosedPipe
+	}
+	if s.file == nil {
+		f, err := os.CreateTemp(s.dir, "spool-*")
+		if err != nil {
+			s.mu.Unlock()
+			return n, err
+		}
+		s.file = f
+	}
+	f, off := s.file, s.fileSize
+	s.mu.Unlock()
+
+	// 只有写端修改 fileSize，读者只读取 fileSize 之前的内容，写入可以在锁外进行。
+	m, err := f.WriteAt(p[n:], off)
+	s.mu.Lock()
+	s.fileSize += int64(m)
+	s.broadcastLocked()
+	s.mu.Unlock()
+	return n + m, err
+}
+
+// CloseWithError 结束写入。err 为 nil 时读者在读完后得到 io.EOF。
+func (s *Spool) CloseWithError(err error) {
+	s.mu.Lock()
+	if !s.done {
+		s.done = true
+		s.err = err
+		s.broadcastLocked()
+	}
+	s.mu.Unlock()
+}
+
+func (s *Spool) broadcastLocked() {
+	close(s.wait)
+	s.wait = make(chan struct{})
+}
+
+// releaseLocked 在没有读者需要溢出文件时摘下它，返回的文件由调用方在锁外删除。
+func (s *Spool) releaseLocked() *os.File {
+	if s.file == nil || s.refs > 0 || !s.done && !s.idleRan {
+		return nil
+	}
+	f := s.file
+	s.file = nil
+	s.released = true
+	return f
+}
+
+func remove(f *os.File) {
+	if f != nil {
+		f.Close()
+		os.Remove(f.Name())
+	}
+}
+
+// NewReader 返回一个从头读取的独立读者。
+// 若所有读者都已关闭且写入尚未完成，或溢出文件已被删除，返回 nil，
+// 调用方应重新发起获取。
+func (s *Spool) NewReader() io.ReadCloser {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.idleRan || s.released {
+		return nil
+	}
+	s.refs++
+	return &reader{s: s}
+}
+
+type reader struct {
+	s      *Spool
+	off    int64
+	closed bool
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	s := r.s
+	for {
+		s.mu.Lock()
+		if r.closed {
+			s.mu.Unlock()
+			return 0, io.ErrClosedPipe
+		}
+		if r.off < int64(len(s.buf)) {
+			n := copy(p, s.buf[r.off:])
+			r.off += int64(n)
+			s.mu.Unlock()
+			return n, nil
+		}
+		if off := r.off - int64(len(s.buf)); off < s.fileSize {
+			f, size := s.file, s.fileSize
+			s.mu.Unlock()
+			// 本读者持有引用，文件在它关闭前不会被删除。
+			n, err := f.ReadAt(p[:min(int64(len(p)), size-off)], off)
+			r.off += int64(n)
+			if n > 0 || err == nil {
+				return n, nil
+			}
+			return 0, err
+		}
+		if s.done {
+			err := s.err
+			s.mu.Unlock()
+			if err == nil {
+				err = io.EOF
+			}
+			return 0, err
+		}
+		wait := s.wait
+		s.mu.Unlock()
+		<-wait
+	}
+}
+
+func (r *reader) Close() error {
+	s := r.s
+	s.mu.Lock()
+	if r.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	s.refs--
+	idle := s.refs == 0 && !s.done && !s.idleRan
+	if idle {
+		s.idleRan = true
+	}
+	onIdle := s.onIdle
+	f := s.releaseLocked()
+	s.mu.Unlock()
+
+	remove(f)
+	// 回调可能取消下载并回写 Spool，必须在锁外执行。
+	if idle && onIdle != nil {
+		onIdle()
+	}
+	return nil
+}
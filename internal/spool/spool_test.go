@@ -0,0 +1,43 @@
+package spool
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSpool_Overflow(t *testing.T) {
+	dir := t.TempDir()
+	s := NewOverflow(nil, 4, dir)
+	first := s.NewReader()
+
+	want := strings.Repeat("0123456789", 100)
+	if n, err := io.Copy(s, strings.NewReader(want)); err != nil || n != int64(len(want)) {
+		t.Fatalf("copy = %d, %v", n, err)
+	}
+	if len(s.buf) != 4 {
+		t.Fatalf("memory holds %d bytes, want 4", len(s.buf))
+	}
+	s.CloseWithError(nil)
+
+	// 写入完成后加入的读者同样从头读到完整内容。
+	second := s.NewReader()
+	for i, r := range []io.ReadCloser{first, second} {
+		b, err := io.ReadAll(r)
+		if err != nil || string(b) != want {
+			t.Fatalf("reader %d got %d bytes, %v", i, len(b), err)
+		}
+	}
+	first.Close()
+	if files, _ := os.ReadDir(dir); len(files) != 1 {
+		t.Fatalf("spool file removed while a reader is open: %d files", len(files))
+	}
+	second.Close()
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Fatalf("spool file not removed after the last reader: %d files", len(files))
+	}
+	if s.NewReader() != nil {
+		t.Fatal("NewReader after release must report nil")
+	}
+}
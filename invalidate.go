@@ -0,0 +1,72 @@
+package singleflight
+
+import "context"
+
+// InvalidationBus 是跨实例广播失效消息的最小抽象，可由 Redis pub/sub、NATS 等实现。
+type InvalidationBus interface {
+	// Publish 向所有订阅者（包括本实例）广播 key 已失效。
+	Publish(ctx context.Context, key string) error
+
+	// Subscribe 在订阅建立后返回，此后收到的每条消息都交给 handler，
+	// 直到 ctx 结束。handler 可能被并发调用。
+	Subscribe(ctx context.Context, handler func(key string)) error
+}
+
+// Invalidator 把 Forget 扩展到集群：在任一实例上 Invalidate 一个 key，
+// 所有订阅了同一 Bus 的实例都会 Forget 该 key 并调用 OnInvalidate 驱逐其缓存，
+// 避免其他实例继续提供旧数据。
+//
+// 消息至多投递一次：Publish 失败或实例短暂断开期间的失效会丢失，
+// 缓存仍应设置 TTL 兜底。
+type Invalidator[K comparable, V any] struct {
+	// Group 为本实例的合并组。
+	Group *Group[K, V]
+
+	// Bus 为广播通道。
+	Bus InvalidationBus
+
+	// Key 把 K 编码为消息内容，ParseKey 为其逆过程。
+	// ParseKey 返回 false 的消息被忽略，便于多个组共用一个频道。
+	Key      func(K) string
+	ParseKey func(string) (K, bool)
+
+	// OnInvalidate 在本地或远程失效时调用，用于驱逐与 key 相关的缓存。可为 nil。
+	OnInvalidate func(K)
+}
+
+// NewInvalidator 创建 Invalidator，之后需调用 Subscribe 开始接收其他实例的失效。
+func NewInvalidator[K comparable, V any](
+	g *Group[K, V],
+	bus InvalidationBus,
+	key func(K) string,
+	parse func(string) (K, bool),
+) *Invalidator[K, V] {
+	return &Invalidator[K, V]{Group: g, Bus: bus, Key: key, ParseKey: parse}
+}
+
+// Subscribe 开始接收失效消息，直到 ctx 结束。
+func (inv *Invalidator[K, V]) Subscribe(ctx context.Context) error {
+	return inv.Bus.Subscribe(ctx, func(msg string) {
+		if key, ok := inv.ParseKey(msg); ok {
+			inv.invalidateLocal(key)
+		}
+	})
+}
+
+// Invalidate 先在本地失效 key，再广播给其他实例。
+// 广播失败时本地失效依然生效，错误以 *BackendError 返回。
+func (inv *Invalidator[K, V]) Invalidate(ctx context.Context, key K) error {
+	inv.invalidateLocal(key)
+	name := inv.Key(key)
+	if err := inv.Bus.Publish(ctx, name); err != nil {
+		return &BackendError{Key: name, Err: err}
+	}
+	return nil
+}
+
+func (inv *Invalidator[K, V]) invalidateLocal(key K) {
+	inv.Group.Forget(key)
+	if inv.OnInvalidate != nil {
+		inv.OnInvalidate(key)
+	}
+}
@@ -0,0 +1,80 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// memBus 是进程内模拟的广播通道，多个 Invalidator 共享它即模拟多个实例。
+type memBus struct {
+	mu       sync.Mutex
+	handlers []func(string)
+	err      error
+}
+
+func (b *memBus) Publish(ctx context.Context, key string) error {
+	b.mu.Lock()
+	handlers, err := b.handlers, b.err
+	b.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	for _, h := range handlers {
+		h(key)
+	}
+	return nil
+}
+
+func (b *memBus) Subscribe(ctx context.Context, handler func(string)) error {
+	b.mu.Lock()
+	b.handlers = append(b.handlers, handler)
+	b.mu.Unlock()
+	return nil
+}
+
+func TestInvalidator_Broadcast(t *testing.T) {
+	bus := &memBus{}
+	key := func(s string) string { return "users:" + s }
+	parse := func(s string) (string, bool) { return strings.CutPrefix(s, "users:") }
+
+	const instances = 3
+	var mu sync.Mutex
+	evicted := make(map[int][]string)
+	invs := make([]*Invalidator[string, int], instances)
+	for i := range invs {
+		invs[i] = NewInvalidator(NewGroup[string, int](), bus, key, parse)
+		invs[i].OnInvalidate = func(k string) {
+			mu.Lock()
+			evicted[i] = append(evicted[i], k)
+			mu.Unlock()
+		}
+		if err := invs[i].Subscribe(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := invs[0].Invalidate(context.Background(), "42"); err != nil {
+		t.Fatal(err)
+	}
+	// 其他频道的消息被忽略。
+	bus.Publish(context.Background(), "orders:1")
+
+	for i := 1; i < instances; i++ {
+		if got := evicted[i]; len(got) != 1 || got[0] != "42" {
+			t.Fatalf("instance %d evicted %v", i, got)
+		}
+	}
+
+	bus.err = errors.New("down")
+	err := invs[1].Invalidate(context.Background(), "7")
+	var be *BackendError
+	if !errors.As(err, &be) || be.Key != "users:7" {
+		t.Fatalf("err = %v, want *BackendError", err)
+	}
+	if got := evicted[1]; got[len(got)-1] != "7" {
+		t.Fatalf("local invalidation skipped on publish failure: %v", got)
+	}
+}
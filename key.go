@@ -0,0 +1,48 @@
+package singleflight
+
+import (
+	"fmt"
+	"hash/maphash"
+)
+
+// Key2 是由两个维度组成的复合 key。它本身可比较，可直接作为 Group 的 K，
+// 免去在热路径上用 fmt.Sprintf 拼接字符串带来的分配。
+type Key2[A, B comparable] struct {
+	K1 A
+	K2 B
+}
+
+// MakeKey2 构造 Key2，便于依赖类型推断。
+func MakeKey2[A, B comparable](a A, b B) Key2[A, B] {
+	return Key2[A, B]{a, b}
+}
+
+// Hash 以 seed 计算 key 的哈希，供分片等需要自行散列的场景使用，不分配内存。
+func (k Key2[A, B]) Hash(seed maphash.Seed) uint64 {
+	return maphash.Comparable(seed, k)
+}
+
+func (k Key2[A, B]) String() string {
+	return fmt.Sprintf("(%v, %v)", k.K1, k.K2)
+}
+
+// Key3 是由三个维度组成的复合 key，见 Key2。
+type Key3[A, B, C comparable] struct {
+	K1 A
+	K2 B
+	K3 C
+}
+
+// MakeKey3 构造 Key3，便于依赖类型推断。
+func MakeKey3[A, B, C comparable](a A, b B, c C) Key3[A, B, C] {
+	return Key3[A, B, C]{a, b, c}
+}
+
+// Hash 以 seed 计算 key 的哈希，见 Key2.Hash。
+func (k Key3[A, B, C]) Hash(seed maphash.Seed) uint64 {
+	return maphash.Comparable(seed, k)
+}
+
+func (k Key3[A, B, C]) String() string {
+	return fmt.Sprintf("(%v, %v, %v)", k.K1, k.K2, k.K3)
+}
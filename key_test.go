@@ -0,0 +1,36 @@
+package singleflight
+
+import (
+	"context"
+	"hash/maphash"
+	"testing"
+)
+
+func TestKey2_ZeroAllocDo(t *testing.T) {
+	if debugBuild {
+		t.Skip("invariant checks box the key")
+	}
+	var g Group[Key2[string, int], int]
+	fn := func(context.Context) (int, error) { return 1, nil }
+	tenant, id := "acme", 42
+	allocs := testing.AllocsPerRun(100, func() {
+		g.Do(context.Background(), MakeKey2(tenant, id), fn)
+	})
+	if allocs != 0 {
+		t.Fatalf("Do with Key2 allocates %.1f times per call", allocs)
+	}
+}
+
+func TestKey3_HashAndString(t *testing.T) {
+	seed := maphash.MakeSeed()
+	a, b := MakeKey3("x", 1, true), MakeKey3("x", 1, true)
+	if a != b || a.Hash(seed) != b.Hash(seed) {
+		t.Fatal("equal keys must hash equally")
+	}
+	if a.Hash(seed) == MakeKey3("x", 2, true).Hash(seed) {
+		t.Fatal("unexpected collision")
+	}
+	if s := a.String(); s != "(x, 1, true)" {
+		t.Fatalf("String() = %q", s)
+	}
+}
@@ -0,0 +1,135 @@
+package singleflight
+
+import (
+	"context"
+	"hash/maphash"
+	"sync"
+)
+
+// shardSet 按 key 的哈希把状态分散到 shardCount 个分片上，零值可用，
+// 首次使用时分配。S 应自带锁并填充到缓存行，相邻分片不会互相争用。
+type shardSet[K comparable, S any] struct {
+	once   sync.Once
+	seed   maphash.Seed
+	shards []S
+	mask   uint64
+}
+
+func (s *shardSet[K, S]) get(key K) *S {
+	shards := s.all()
+	return &shards[maphash.Comparable(s.seed, key)&s.mask]
+}
+
+// all 返回全部分片。
+func (s *shardSet[K, S]) all() []S {
+	s.once.Do(func() {
+		n := shardCount()
+		s.seed = maphash.MakeSeed()
+		s.shards = make([]S, n)
+		s.mask = uint64(n - 1)
+	})
+	return s.shards
+}
+
+// keyedTable 是按 key 分片的引用计数表，为 KeyedMutex 等按 key 互斥的原语
+// 保存每个 key 的令牌槽。持有者与等待者都持有槽的引用，最后一个引用释放时
+// 槽被移出表并放回池中，表的大小只随同时活跃的 key 数增长。
+//
+// 零值可用。
+type keyedTable[K comparable] struct {
+	shards shardSet[K, keyedShard[K]]
+	free   sync.Pool // *keyedSlot
+}
+
+type keyedShard[K comparable] struct {
+	mu    sync.Mutex
+	slots map[K]*keyedSlot
+	_     [64 - 16]byte // 相邻分片的锁不共享缓存行
+}
+
+// keyedSlot 的 tokens 容量即允许同时持有的数量，refs 由分片锁保护。
+type keyedSlot struct {
+	tokens chan struct{}
+	refs   int
+}
+
+func (t *keyedTable[K]) shard(key K) *keyedShard[K] { return t.shards.get(key) }
+
+// acquire 为 key 取得一个令牌，n 为槽的容量。能立即取得时 ok 为 true；
+// 否则 wait 为 false 时直接返回，为 true 时返回已引用的槽，由调用方阻塞等待，
+// 并在放弃时调用 unref。
+func (t *keyedTable[K]) acquire(key K, n int, wait bool) (s *keyedSlot, ok bool) {
+	sh := t.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	s = sh.slots[key]
+	if s == nil {
+		s, _ = t.free.Get().(*keyedSlot)
+		if s == nil || cap(s.tokens) != n {
+			s = &keyedSlot{tokens: make(chan struct{}, n)}
+		}
+		if sh.slots == nil {
+			sh.slots = make(map[K]*keyedSlot)
+		}
+		sh.slots[key] = s
+	}
+	select {
+	case s.tokens <- struct{}{}:
+		s.refs++
+		return s, true
+	default:
+	}
+	if !wait {
+		t.unrefLocked(sh, key, s)
+		return nil, false
+	}
+	s.refs++
+	return s, false
+}
+
+// wait 为 key 取得一个令牌，ctx 结束前无法取得时返回 *WaitError。
+func (t *keyedTable[K]) wait(ctx context.Context, key K, n int) error {
+	s, ok := t.acquire(key, n, true)
+	if ok {
+		return nil
+	}
+	select {
+	case s.tokens <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		t.unref(key, s)
+		return waitError(ctx)
+	}
+}
+
+// unref 撤销等待者对槽的引用。
+func (t *keyedTable[K]) unref(key K, s *keyedSlot) {
+	sh := t.shard(key)
+	sh.mu.Lock()
+	s.refs--
+	t.unrefLocked(sh, key, s)
+	sh.mu.Unlock()
+}
+
+// release 归还 key 的一个令牌。key 没有被持有时返回 false。
+func (t *keyedTable[K]) release(key K) bool {
+	sh := t.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	s := sh.slots[key]
+	if s == nil || len(s.tokens) == 0 {
+		return false
+	}
+	<-s.tokens
+	s.refs--
+	t.unrefLocked(sh, key, s)
+	return true
+}
+
+// unrefLocked 在槽不再被引用时回收它。
+func (t *keyedTable[K]) unrefLocked(sh *keyedShard[K], key K, s *keyedSlot) {
+	if s.refs == 0 {
+		delete(sh.slots, key)
+		t.free.Put(s)
+	}
+}
@@ -0,0 +1,141 @@
+package singleflight
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// KeyedLimiter 是按 key 的令牌桶：每个 key 每隔 Every 补充一个令牌，至多积攒 Burst 个。
+// 桶补满后与从未使用的 key 无异，会在之后的清理中被删除，因此内存只随近期活跃的 key 增长。
+//
+// 零值可用且不做限制。字段在首次使用后不可修改。可单独使用，也可经 WithLimiter
+// 限制 Group 的执行频率；WithMinExecInterval 即是 Burst 为 1 的 KeyedLimiter。
+type KeyedLimiter[K comparable] struct {
+	// Every 为补充一个令牌的间隔，<= 0 表示不限制。
+	Every time.Duration
+	// Burst 为桶容量，即空闲的 key 可以连续通过的次数，<= 0 时为 1。
+	Burst int
+	// Clock 为时间源，nil 时使用 SystemClock。
+	Clock Clock
+
+	shards shardSet[K, limiterShard[K]]
+}
+
+type limiterShard[K comparable] struct {
+	mu    sync.Mutex
+	tats  map[K]time.Time
+	swept int
+	_     [64 - 24]byte
+}
+
+// 桶以 GCRA 表示：tat 为 key 下一个令牌的理论到达时间，桶满时不晚于当前时间。
+// 全部运算都是整数时长，Burst 为 1 时恰好退化为"两次通过至少间隔 Every"，
+// 与 WithMinExecInterval 的语义逐纳秒一致。
+
+// Allow 报告 key 现在能否通过，能通过时消耗一个令牌。
+func (l *KeyedLimiter[K]) Allow(key K) bool {
+	_, ok := l.take(key, false)
+	return ok
+}
+
+// Wait 消耗 key 的一个令牌，没有令牌时等待至补充。ctx 在此之前结束时归还预约的令牌
+// 并返回 *WaitError。
+func (l *KeyedLimiter[K]) Wait(ctx context.Context, key K) error {
+	if ctx.Err() != nil {
+		return waitError(ctx)
+	}
+	delay, _ := l.take(key, true)
+	if delay <= 0 {
+		return nil
+	}
+	t := clockOrSystem(l.Clock).NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C():
+		return nil
+	case <-ctx.Done():
+		l.refund(key)
+		return waitError(ctx)
+	}
+}
+
+// take 消耗 key 的一个令牌。没有令牌时，reserve 为 false 则不消耗并返回 false；
+// 为 true 则预约下一个令牌并返回需要等待的时长。
+func (l *KeyedLimiter[K]) take(key K, reserve bool) (time.Duration, bool) {
+	if l.Every <= 0 {
+		return 0, true
+	}
+	sh := l.shards.get(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	now := clockOrSystem(l.Clock).Now()
+	tat, ok := sh.tats[key]
+	if !ok {
+		if sh.tats == nil {
+			sh.tats = make(map[K]time.Time)
+		}
+		l.sweepLocked(sh, now)
+		tat = now
+	}
+	delay := max(l.delay(tat, now), 0)
+	if delay > 0 && !reserve {
+		return 0, false
+	}
+	if tat.Before(now) {
+		tat = now
+	}
+	sh.tats[key] = tat.Add(l.Every)
+	return delay, true
+}
+
+// wait 返回 key 还需多久才有令牌，有令牌时为 0。不消耗令牌。
+func (l *KeyedLimiter[K]) wait(key K) time.Duration {
+	if l.Every <= 0 {
+		return 0
+	}
+	sh := l.shards.get(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	tat, ok := sh.tats[key]
+	if !ok {
+		return 0
+	}
+	return max(l.delay(tat, clockOrSystem(l.Clock).Now()), 0)
+}
+
+// delay 返回 now 距离下一个令牌的时长，小于等于 0 表示已有令牌。
+func (l *KeyedLimiter[K]) delay(tat, now time.Time) time.Duration {
+	return tat.Sub(now) - time.Duration(max(l.Burst, 1)-1)*l.Every
+}
+
+// refund 归还 Wait 预约后未使用的令牌。
+func (l *KeyedLimiter[K]) refund(key K) {
+	sh := l.shards.get(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if tat, ok := sh.tats[key]; ok {
+		sh.tats[key] = tat.Add(-l.Every)
+	}
+}
+
+// sweepLocked 与 Group 的 sweepLocked 相同，在分片比上次清理后翻倍时删除已补满的桶。
+func (l *KeyedLimiter[K]) sweepLocked(sh *limiterShard[K], now time.Time) {
+	if len(sh.tats) < 2*sh.swept+16 {
+		return
+	}
+	for k, tat := range sh.tats {
+		if !tat.After(now) {
+			delete(sh.tats, k)
+		}
+	}
+	sh.swept = len(sh.tats)
+}
+
+// WithLimiter 让 Group 的每次执行消耗 l 中对应 key 的一个令牌，合并到已有执行的
+// Follower 不消耗。没有令牌时调用者收到 ErrRateLimited。与 WithMinExecInterval 不同，
+// 它允许突发，且同一个 KeyedLimiter 可以由多个 Group 共享，限制它们对同一后端的总频率。
+// K 必须与 Group 一致。
+func WithLimiter[K comparable](l *KeyedLimiter[K]) Option {
+	return func(o *options) { o.rawLimiter = l }
+}
@@ -0,0 +1,173 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestKeyedLimiter(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	l := &KeyedLimiter[string]{Every: time.Second, Burst: 2, Clock: clock}
+
+	if !l.Allow("a") || !l.Allow("a") {
+		t.Fatal("burst was not allowed")
+	}
+	if l.Allow("a") {
+		t.Fatal("allowed beyond burst")
+	}
+	if !l.Allow("b") {
+		t.Fatal("keys are not independent")
+	}
+	clock.Advance(time.Second)
+	if !l.Allow("a") || l.Allow("a") {
+		t.Fatal("refill did not add exactly one token")
+	}
+
+	// Wait 预约下一个令牌并等到它补充。
+	done := make(chan error, 1)
+	go func() { done <- l.Wait(context.Background(), "a") }()
+	for clock.Timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Fatalf("Wait = %v", err)
+	}
+	if l.Allow("a") {
+		t.Fatal("the token consumed by Wait was handed out again")
+	}
+
+	// 取消的等待归还令牌。
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { done <- l.Wait(ctx, "a") }()
+	for clock.Timers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait = %v, want context.Canceled", err)
+	}
+	clock.Advance(time.Second)
+	if !l.Allow("a") {
+		t.Fatal("cancelled Wait did not return its token")
+	}
+}
+
+func TestKeyedLimiter_Unlimited(t *testing.T) {
+	var l KeyedLimiter[int]
+	for i := range 100 {
+		if !l.Allow(0) {
+			t.Fatalf("zero-value limiter rejected call %d", i)
+		}
+	}
+}
+
+func TestKeyedLimiter_EvictsIdle(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	l := &KeyedLimiter[string]{Every: time.Second, Clock: clock}
+	for i := range 1000 {
+		l.Allow(fmt.Sprint(i))
+	}
+	clock.Advance(time.Second)
+	for i := range 1000 {
+		l.Allow(fmt.Sprint("new", i))
+	}
+	n := 0
+	shards := l.shards.all()
+	for i := range shards {
+		n += len(shards[i].tats)
+	}
+	if n >= 2000 {
+		t.Fatalf("%d buckets kept, refilled ones were not evicted", n)
+	}
+}
+
+func TestWithLimiter(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	l := &KeyedLimiter[string]{Every: time.Minute, Clock: clock}
+	installed := make(chan struct{}, 2)
+	joined := make(chan struct{}, 1)
+	g := NewGroup[string, int](WithLimiter(l), WithHooks(Hooks[string]{
+		LeaderInstalled: func(string) { installed <- struct{}{} },
+		FollowerJoined:  func(string) { joined <- struct{}{} },
+	}))
+	ctx := context.Background()
+
+	// Follower 合并到执行上，不消耗令牌。
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		g.Do(ctx, "k", func(context.Context) (int, error) {
+			<-joined
+			return 1, nil
+		})
+	}()
+	<-installed
+	if v, err, shared := g.Do(ctx, "k", nil); v != 1 || err != nil || !shared {
+		t.Fatalf("follower = %v, %v, %v", v, err, shared)
+	}
+	<-done
+
+	if _, err, _ := g.Do(ctx, "k", func(context.Context) (int, error) { return 2, nil }); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("second execution err = %v, want ErrRateLimited", err)
+	}
+	if _, err := g.TryDo(ctx, "k", func(context.Context) (int, error) { return 2, nil }); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("TryDo err = %v, want ErrRateLimited", err)
+	}
+	clock.Advance(time.Minute)
+	if v, err, _ := g.Do(ctx, "k", func(context.Context) (int, error) { return 3, nil }); v != 3 || err != nil {
+		t.Fatalf("after refill = %v, %v", v, err)
+	}
+}
+
+// 被 WithLimiter 拒绝的调用不推迟 WithMinExecInterval 与 WithDebounce 的窗口。
+func TestWithLimiter_RejectionHasNoSideEffects(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	l := &KeyedLimiter[string]{Every: time.Hour, Clock: clock}
+	g := NewGroup[string, int](WithClock(clock), WithLimiter(l), WithMinExecInterval(time.Minute), WithDebounce(time.Second))
+	ctx := context.Background()
+	fn := func(context.Context) (int, error) { return 1, nil }
+
+	g.Do(ctx, "k", fn)
+	seen := g.states["k"].lastSeen
+	clock.Advance(2 * time.Minute)
+	if _, err, _ := g.Do(ctx, "k", fn); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("err = %v, want ErrRateLimited", err)
+	}
+	if w := g.cfg.interval.wait("k"); w != 0 {
+		t.Fatalf("rejected call consumed the exec interval, next in %v", w)
+	}
+	if s := g.states["k"]; s != nil && !s.lastSeen.Equal(seen) {
+		t.Fatalf("rejected call moved the debounce window to %v", s.lastSeen)
+	}
+}
+
+func TestWithLimiter_DoMulti(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	g := NewGroup[string, int](WithLimiter(&KeyedLimiter[string]{Every: time.Hour, Clock: clock}))
+	var loads int
+	load := func(_ context.Context, keys []string) (map[string]int, error) {
+		loads++
+		m := make(map[string]int)
+		for _, k := range keys {
+			m[k] = 1
+		}
+		return m, nil
+	}
+	for _, r := range g.DoMulti(context.Background(), []string{"a", "b"}, load, nil) {
+		if r.Err != nil {
+			t.Fatalf("first batch: %v", r.Err)
+		}
+	}
+	for k, r := range g.DoMulti(context.Background(), []string{"a", "b"}, load, nil) {
+		if !errors.Is(r.Err, ErrRateLimited) {
+			t.Fatalf("%s: err = %v, want ErrRateLimited", k, r.Err)
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("loads = %d, want 1", loads)
+	}
+}
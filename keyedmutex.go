@@ -0,0 +1,30 @@
+package singleflight
+
+import "context"
+
+// KeyedMutex 是按 key 的互斥锁：同一个 key 同时只有一个持有者，不同 key 互不影响。
+// 与 Group 不同，它不共享结果，只提供互斥。
+//
+// 零值可用，不可复制。只有正在被持有或等待的 key 占用内存。
+type KeyedMutex[K comparable] struct {
+	table keyedTable[K]
+}
+
+// Lock 获取 key 的锁，ctx 结束前无法获取时返回 *WaitError。
+func (m *KeyedMutex[K]) Lock(ctx context.Context, key K) error {
+	return m.table.wait(ctx, key, 1)
+}
+
+// TryLock 尝试获取 key 的锁而不等待，报告是否成功。
+func (m *KeyedMutex[K]) TryLock(key K) bool {
+	_, ok := m.table.acquire(key, 1, false)
+	return ok
+}
+
+// Unlock 释放 key 的锁。与 sync.Mutex 相同，锁不属于特定 goroutine；
+// 释放未被持有的 key 时 panic。
+func (m *KeyedMutex[K]) Unlock(key K) {
+	if !m.table.release(key) {
+		panic("singleflight: unlock of unlocked key")
+	}
+}
@@ -0,0 +1,83 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestKeyedMutex(t *testing.T) {
+	var m KeyedMutex[string]
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var held [2]int
+	for i := range 100 {
+		key := []string{"a", "b"}[i%2]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := m.Lock(ctx, key); err != nil {
+				t.Error(err)
+				return
+			}
+			// 非原子的读改写只有在互斥时才不丢失更新，-race 也会报告并发访问。
+			held[i%2]++
+			m.Unlock(key)
+		}()
+	}
+	wg.Wait()
+	if held != [2]int{50, 50} {
+		t.Fatalf("held = %v", held)
+	}
+	if n := keyedSlots(&m.table); n != 0 {
+		t.Fatalf("%d slots left after all unlocks", n)
+	}
+}
+
+func TestKeyedMutex_TryLockAndCancel(t *testing.T) {
+	var m KeyedMutex[string]
+	if !m.TryLock("k") {
+		t.Fatal("TryLock on a free key failed")
+	}
+	if m.TryLock("k") {
+		t.Fatal("TryLock on a held key succeeded")
+	}
+	if !m.TryLock("other") {
+		t.Fatal("keys are not independent")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- m.Lock(ctx, "k") }()
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) || !errors.Is(err, ErrWaiterCancelled) {
+		t.Fatalf("Lock = %v, want cancellation", err)
+	}
+
+	m.Unlock("k")
+	m.Unlock("other")
+	if n := keyedSlots(&m.table); n != 0 {
+		t.Fatalf("%d slots left", n)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Unlock of an unlocked key did not panic")
+		}
+	}()
+	m.Unlock("k")
+}
+
+// keyedSlots 返回表中仍被引用的 key 数。
+func keyedSlots[K comparable](t *keyedTable[K]) int {
+	n := 0
+	shards := t.shards.all()
+	for i := range shards {
+		sh := &shards[i]
+		sh.mu.Lock()
+		n += len(sh.slots)
+		sh.mu.Unlock()
+	}
+	return n
+}
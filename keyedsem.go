@@ -0,0 +1,41 @@
+package singleflight
+
+import (
+	"context"
+	"fmt"
+)
+
+// KeyedSemaphore 是按 key 的计数信号量：同一个 key 至多 n 个持有者，不同 key 互不影响，
+// 用于限制对单个下游资源（租户、主机、对象）的并发而不限制整体。
+//
+// 与 KeyedMutex 共用同一种分片表，只有正在被持有或等待的 key 占用内存。
+type KeyedSemaphore[K comparable] struct {
+	n     int
+	table keyedTable[K]
+}
+
+// NewKeyedSemaphore 创建每个 key 至多 n 个持有者的信号量。n < 1 时 panic。
+func NewKeyedSemaphore[K comparable](n int) *KeyedSemaphore[K] {
+	if n < 1 {
+		panic(fmt.Sprintf("singleflight: NewKeyedSemaphore: n = %d, want >= 1", n))
+	}
+	return &KeyedSemaphore[K]{n: n}
+}
+
+// Acquire 为 key 取得一个名额，ctx 结束前无法取得时返回 *WaitError。
+func (s *KeyedSemaphore[K]) Acquire(ctx context.Context, key K) error {
+	return s.table.wait(ctx, key, s.n)
+}
+
+// TryAcquire 尝试为 key 取得一个名额而不等待，报告是否成功。
+func (s *KeyedSemaphore[K]) TryAcquire(key K) bool {
+	_, ok := s.table.acquire(key, s.n, false)
+	return ok
+}
+
+// Release 归还 key 的一个名额。key 没有名额被持有时 panic。
+func (s *KeyedSemaphore[K]) Release(key K) {
+	if !s.table.release(key) {
+		panic("singleflight: release of unacquired key")
+	}
+}
@@ -0,0 +1,75 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestKeyedSemaphore(t *testing.T) {
+	s := NewKeyedSemaphore[string](3)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var cur, peak atomic.Int32
+	for range 60 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Acquire(ctx, "k"); err != nil {
+				t.Error(err)
+				return
+			}
+			n := cur.Add(1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			cur.Add(-1)
+			s.Release("k")
+		}()
+	}
+	wg.Wait()
+	if p := peak.Load(); p > 3 {
+		t.Fatalf("peak holders = %d, want <= 3", p)
+	}
+	if n := keyedSlots(&s.table); n != 0 {
+		t.Fatalf("%d slots left", n)
+	}
+}
+
+func TestKeyedSemaphore_TryAcquireAndCancel(t *testing.T) {
+	s := NewKeyedSemaphore[string](2)
+	if !s.TryAcquire("k") || !s.TryAcquire("k") {
+		t.Fatal("TryAcquire within capacity failed")
+	}
+	if s.TryAcquire("k") {
+		t.Fatal("TryAcquire beyond capacity succeeded")
+	}
+	if !s.TryAcquire("other") {
+		t.Fatal("keys are not independent")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.Acquire(ctx, "k"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Acquire = %v, want context.Canceled", err)
+	}
+
+	s.Release("k")
+	if err := s.Acquire(context.Background(), "k"); err != nil {
+		t.Fatalf("Acquire after Release = %v", err)
+	}
+	s.Release("k")
+	s.Release("k")
+	s.Release("other")
+	if n := keyedSlots(&s.table); n != 0 {
+		t.Fatalf("%d slots left", n)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Release of an unacquired key did not panic")
+		}
+	}()
+	s.Release("k")
+}
@@ -0,0 +1,84 @@
+package singleflight
+
+import (
+	"errors"
+	"fmt"
+)
+
+// WithName 为 Group 命名，用于 WithKeyedErrors 附在错误上的 Group 名称。
+// Named 创建的 Group 自动以其 name 命名。
+func WithName(name string) Option {
+	return func(o *options) { o.name = name }
+}
+
+// WithKeyedErrors 把 Group 自身产生的错误（等待被取消、超出等待者上限、
+// 看门狗触发、熔断等，即 IsExecutionError 为 false 的错误）包装为 *KeyError，
+// 日志与指标可以据此归属到 key，而不必在每条错误路径上手工传递 key。
+// fn 返回的错误与 panic 保持原样。
+//
+// 包装后的错误仍满足 errors.Is / errors.As 对原错误的判断，
+// 但不再与哨兵错误直接相等。
+func WithKeyedErrors() Option {
+	return func(o *options) { o.keyedErrors = true }
+}
+
+// Name 返回 Group 的名称，未命名时为空。
+func (g *Group[K, V]) Name() string {
+	if g.cfg == nil {
+		return ""
+	}
+	return g.cfg.name
+}
+
+// KeyError 为 Group 自身产生的错误附上 key 与 Group 名称，见 WithKeyedErrors。
+type KeyError[K comparable] struct {
+	key   K
+	group string
+	err   error
+}
+
+// Key 返回产生错误的调用的 key（经 WithKeyFunc 规范化之后）。
+func (e *KeyError[K]) Key() K { return e.key }
+
+// Group 返回产生错误的 Group 的名称，未命名时为空。
+func (e *KeyError[K]) Group() string { return e.group }
+
+func (e *KeyError[K]) Error() string {
+	if e.group == "" {
+		return fmt.Sprintf("%v [key=%v]", e.err, e.key)
+	}
+	return fmt.Sprintf("%v [group=%s key=%v]", e.err, e.group, e.key)
+}
+
+func (e *KeyError[K]) Unwrap() error { return e.err }
+
+func (e *KeyError[K]) keyAny() any { return e.key }
+
+// keyed 由所有 KeyError 实例化实现，供不知道 K 的调用方取出 key。
+type keyed interface {
+	keyAny() any
+	Group() string
+}
+
+// ErrorKey 从 err 中取出 WithKeyedErrors 附上的 key 与 Group 名称，
+// 供不知道 key 类型的中间件使用。err 不含 *KeyError 时 ok 为 false。
+func ErrorKey(err error) (key any, group string, ok bool) {
+	var k keyed
+	if !errors.As(err, &k) {
+		return nil, "", false
+	}
+	return k.keyAny(), k.Group(), true
+}
+
+// keyedError 包装 Group 自身产生的 err。ForgetRetry、WithHandoff 重新发起的调用
+// 已经包装过，不再重复。
+func (g *Group[K, V]) keyedError(key K, err error) error {
+	if IsExecutionError(err) {
+		return err
+	}
+	var k keyed
+	if errors.As(err, &k) {
+		return err
+	}
+	return &KeyError[K]{key: key, group: g.cfg.name, err: err}
+}
@@ -0,0 +1,59 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestKeyedErrors(t *testing.T) {
+	g := NewGroup[string, int](WithName("users"), WithKeyedErrors())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err, _ := g.Do(ctx, "u1", func(context.Context) (int, error) { return 1, nil })
+	var ke *KeyError[string]
+	if !errors.As(err, &ke) || ke.Key() != "u1" || ke.Group() != "users" {
+		t.Fatalf("err = %v", err)
+	}
+	if !errors.Is(err, ErrWaiterCancelled) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("wrapped error lost its identity: %v", err)
+	}
+	if err.Error() != "singleflight: waiter cancelled: context canceled [group=users key=u1]" {
+		t.Fatalf("Error() = %q", err.Error())
+	}
+	if key, group, ok := ErrorKey(err); !ok || key != "u1" || group != "users" {
+		t.Fatalf("ErrorKey = %v, %q, %v", key, group, ok)
+	}
+
+	// fn 自己的错误保持原样。
+	boom := errors.New("boom")
+	if _, err, _ := g.Do(context.Background(), "u1", func(context.Context) (int, error) { return 0, boom }); err != boom {
+		t.Fatalf("execution error = %#v", err)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	res := g.DoChan(context.Background(), "u2", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 2, nil
+	})
+	<-started
+	if _, err := g.TryDo(context.Background(), "u2", nil); !errors.Is(err, ErrInFlight) || !errors.As(err, &ke) || ke.Key() != "u2" {
+		t.Fatalf("TryDo err = %v", err)
+	}
+	close(release)
+	<-res
+}
+
+func TestKeyedErrors_Named(t *testing.T) {
+	g := Named[string, int]("TestKeyedErrors_Named", WithKeyedErrors())
+	if g.Name() != "TestKeyedErrors_Named" {
+		t.Fatalf("Name() = %q", g.Name())
+	}
+	g.Close()
+	_, err, _ := g.Do(context.Background(), "k", nil)
+	if _, group, ok := ErrorKey(err); !ok || group != "TestKeyedErrors_Named" || !errors.Is(err, ErrGroupClosed) {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,18 @@
+package singleflight
+
+// WithKeyFunc 在查找之前把 key 规范化，例如把主机名转为小写、去掉易变的查询参数，
+// 使逻辑上相同的请求得以合并。K 必须与 Group 的 key 类型一致。
+//
+// f 必须是幂等的（f(f(k)) == f(k)）：内部重试等路径可能对已规范化的 key 再次调用它。
+// Hooks、fn 的结果以及所有按 key 的状态都使用规范化后的 key。
+func WithKeyFunc[K comparable](f func(K) K) Option {
+	return func(o *options) { o.rawKeyFunc = f }
+}
+
+// canonical 返回规范化后的 key，未配置时原样返回。
+func (g *Group[K, V]) canonical(key K) K {
+	if g.cfg != nil && g.cfg.keyFunc != nil {
+		return g.cfg.keyFunc(key)
+	}
+	return key
+}
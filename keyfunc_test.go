@@ -0,0 +1,46 @@
+package singleflight
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestWithKeyFunc_Coalesces(t *testing.T) {
+	joined := make(chan string, 1)
+	g := NewGroup[string, int](
+		WithKeyFunc(strings.ToLower),
+		WithHooks(Hooks[string]{FollowerJoined: func(k string) { joined <- k }}),
+	)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	leader := g.DoChan(context.Background(), "Example.COM", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	follower := g.DoChan(context.Background(), "example.com", nil)
+	if k := <-joined; k != "example.com" {
+		t.Fatalf("hook saw key %q, want canonical form", k)
+	}
+	close(release)
+	for _, ch := range []<-chan Result[int]{leader, follower} {
+		if r := <-ch; r.Val != 1 || !r.Shared {
+			t.Fatalf("result = %+v", r)
+		}
+	}
+
+	if !g.ForgetUnshared("EXAMPLE.com") {
+		t.Fatal("ForgetUnshared must canonicalize")
+	}
+}
+
+func TestWithKeyFunc_TypeMismatchPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("mismatched key type must panic")
+		}
+	}()
+	NewGroup[int, int](WithKeyFunc(strings.ToLower))
+}
@@ -0,0 +1,282 @@
+package singleflight
+
+import (
+	"context"
+	"time"
+)
+
+// keyState 保存跨越单次执行的每 key 状态，供熔断、限频、防抖等按 key 生效的策略使用。
+// 只有配置了这类策略的 Group 才会分配，且在状态回到初始值时删除，
+// 避免 key 空间无限增长。所有字段由 g.mu 保护。
+type keyState[V any] struct {
+	breakerState
+	backoff backoffState[V]
+	last    lastResult[V]
+	pending pendingExec[V]
+	// lastSeen 为最近一次调用到达的时间（WithDebounce）。
+	lastSeen time.Time
+}
+
+// lastResult 是 key 最近一次完成的执行结果，供限频、防抖复用。
+type lastResult[V any] struct {
+	val V
+	err error
+	ok  bool
+	// at 为结果产生的时间，用于 WithMaxStale。
+	at time.Time
+}
+
+// pendingExec 是为 key 预约的一次后台执行，同一时刻至多一个，
+// 期间到达的调用只更新 fn 与触发时间。
+type pendingExec[V any] struct {
+	timer     Timer
+	fn        func(ctx context.Context) (V, error)
+	scheduled bool
+	// ctx 为 WithContextValues 从提供 fn 的调用者处复制的值，未配置时为 nil。
+	ctx context.Context
+}
+
+// setPendingFn 记录用于预约执行的 fn 以及调用者 ctx 中允许传递的值。
+// 调用者的 ctx 本身不会被保留。
+func (g *Group[K, V]) setPendingFn(p *pendingExec[V], ctx context.Context, fn func(ctx context.Context) (V, error)) {
+	p.fn = fn
+	p.ctx = nil
+	if len(g.cfg.contextValues) > 0 {
+		p.ctx = g.detach(ctx)
+	}
+}
+
+func (g *Group[K, V]) stateLocked(key K) *keyState[V] {
+	s, ok := g.states[key]
+	if !ok {
+		if g.states == nil {
+			g.states = make(map[K]*keyState[V])
+		}
+		g.sweepLocked()
+		s = new(keyState[V])
+		g.states[key] = s
+	}
+	return s
+}
+
+// sweepLocked 在 states 比上次清理后翻倍时删除所有空闲状态。
+// 过期的状态本应在下次访问该 key 时删除，但不再被访问的 key 需要这里兜底，
+// 翻倍触发使清理的代价均摊为 O(1)。
+func (g *Group[K, V]) sweepLocked() {
+	if len(g.states) < 2*g.statesSwept+16 {
+		return
+	}
+	now := g.now()
+	for k, s := range g.states {
+		if g.idleLocked(k, s, now) {
+			delete(g.states, k)
+		}
+	}
+	g.statesSwept = len(g.states)
+}
+
+// idleLocked 报告状态是否已回到初始值，可以删除。执行间隔未过时保留状态，
+// 间隔内的调用还要复用其中的结果。
+func (g *Group[K, V]) idleLocked(key K, s *keyState[V], now time.Time) bool {
+	return s.breakerState.idle(now) &&
+		s.backoff.idle(now) &&
+		!s.pending.scheduled &&
+		(g.cfg.interval == nil || g.cfg.interval.wait(key) == 0) &&
+		!now.Before(s.lastSeen.Add(g.cfg.debounce))
+}
+
+// admitLocked 在成为 Leader 之前调用，决定是否允许本次执行。
+// handled 为 true 时调用方直接返回 v、err 而不执行 fn；
+// reused 表示 v、err 是之前某次执行的结果。
+//
+// 只有确定执行时才记录本次执行（消耗令牌、刷新防抖窗口、取消预约的尾沿），
+// 被 WithLimiter 拒绝的调用不会推迟之后的执行。
+func (g *Group[K, V]) admitLocked(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) (V, error),
+	co *callOpts[V],
+) (v V, err error, reused, handled bool) {
+	s, ok := g.states[key]
+	now := g.now()
+	if ok && g.cfg.breaker != nil {
+		if err := g.cfg.breaker.admit(&s.breakerState, now); err != nil {
+			return v, err, false, true
+		}
+	}
+	if ok && s.backoff.streak > 0 && now.Before(s.backoff.until) {
+		if s.backoff.hasStale && g.freshEnough(s.backoff.staleAt, now, co) {
+			return s.backoff.stale, nil, true, true
+		}
+		return v, s.backoff.err, true, true
+	}
+	seen, supersede := false, false
+	if w := g.cfg.debounce; w > 0 {
+		// 尾沿执行本身也刷新 lastSeen，使其结果在之后的 window 内被复用。
+		trailing := co != nil && co.trailing
+		if !trailing && ok && s.last.ok && now.Before(s.lastSeen.Add(w)) {
+			if g.freshEnough(s.last.at, now, co) {
+				s.lastSeen = now
+				g.scheduleLocked(ctx, key, s, w, fn)
+				return s.last.val, s.last.err, true, true
+			}
+			// 结果已超过 WithMaxStale 或本调用的 maxAge，本次调用即是最新的执行，预约的尾沿不再需要。
+			supersede = true
+		}
+		seen = true
+	}
+	if l := g.cfg.interval; l != nil {
+		if wait := l.wait(key); wait > 0 {
+			if !ok {
+				s, ok = g.stateLocked(key), true
+			}
+			if seen {
+				s.lastSeen = now
+			}
+			if g.cfg.spacedRefresh {
+				g.refreshLocked(ctx, key, s, wait, fn)
+			}
+			if !s.last.ok || !g.freshEnough(s.last.at, now, co) {
+				return v, ErrRateLimited, false, true
+			}
+			return s.last.val, s.last.err, true, true
+		}
+	}
+	if g.cfg.limiter != nil && !g.cfg.limiter.Allow(key) {
+		return v, ErrRateLimited, false, true
+	}
+
+	if supersede {
+		g.cancelPendingLocked(s)
+	}
+	if seen || g.cfg.interval != nil {
+		if !ok {
+			s = g.stateLocked(key)
+		}
+		if seen {
+			s.lastSeen = now
+		}
+		if g.cfg.interval != nil {
+			g.cfg.interval.Allow(key)
+		}
+	}
+	return v, nil, false, false
+}
+
+// settleLocked 在执行结束、key 从 calls 中移除之后调用，更新按 key 的状态。
+// 成功路径上没有既存状态时不分配任何东西。
+func (g *Group[K, V]) settleLocked(key K, c *call[V]) {
+	// 交接的失败只属于 Leader 自己的调用者，不计入熔断与退避，否则接手的 Follower
+	// 会被 Leader 刚留下的退避窗口拒绝，收到的仍是这个失败。
+	record := !c.handoff
+	panicked := c.panicErr != nil
+	failed := record && g.cfg.breaker != nil && g.cfg.breaker.failed(c.err, panicked)
+	backoff := record && g.cfg.backoff != nil && g.cfg.backoff.failed(c.err, panicked)
+	s, ok := g.states[key]
+	if !ok && !failed && !backoff {
+		return
+	}
+
+	now := g.now()
+	if !ok {
+		s = g.stateLocked(key)
+	}
+	if record && g.cfg.breaker != nil {
+		g.cfg.breaker.record(&s.breakerState, now, failed)
+	}
+	if record && g.cfg.backoff != nil {
+		g.recordBackoffLocked(s, c, now, backoff)
+	}
+	// 交接的失败只属于 Leader 自己的调用者，不能保留给之后的调用者。
+	if g.cfg.keepLast && c.panicErr == nil && !c.handoff {
+		s.last = lastResult[V]{val: c.val, err: c.err, ok: true, at: now}
+	}
+	if g.idleLocked(key, s, now) {
+		delete(g.states, key)
+	}
+}
+
+// scheduleLocked 预约在 d 之后以 fn 执行一次 key，已有预约时推迟到新的时间并改用新的 fn。
+// fn 为 nil 时沿用之前的 fn，便于只想拿结果的调用者参与合并。
+func (g *Group[K, V]) scheduleLocked(
+	ctx context.Context,
+	key K,
+	s *keyState[V],
+	d time.Duration,
+	fn func(ctx context.Context) (V, error),
+) {
+	p := &s.pending
+	if fn != nil {
+		g.setPendingFn(p, ctx, fn)
+	}
+	if p.fn == nil {
+		return
+	}
+	p.scheduled = true
+	if p.timer == nil {
+		p.timer = clockOrSystem(g.cfg.clock).AfterFunc(d, func() { g.runPending(key, s) })
+		return
+	}
+	p.timer.Reset(d)
+}
+
+// refreshLocked 预约一次 d 之后的刷新。与 scheduleLocked 不同，已有预约时
+// 只更新 fn 而不推迟，保证执行间隔不被持续到达的调用拉长。
+func (g *Group[K, V]) refreshLocked(
+	ctx context.Context,
+	key K,
+	s *keyState[V],
+	d time.Duration,
+	fn func(ctx context.Context) (V, error),
+) {
+	if s.pending.scheduled {
+		if fn != nil {
+			g.setPendingFn(&s.pending, ctx, fn)
+		}
+		return
+	}
+	g.scheduleLocked(ctx, key, s, d, fn)
+}
+
+// runPending 在预约时间到达时执行。状态已被替换或预约已取消时什么也不做。
+// fn 的 panic 不会使进程崩溃。
+func (g *Group[K, V]) runPending(key K, s *keyState[V]) {
+	g.mu.Lock()
+	p := &s.pending
+	if g.states[key] != s || !p.scheduled || g.closed {
+		g.mu.Unlock()
+		return
+	}
+	ctx, fn := p.ctx, p.fn
+	p.scheduled, p.ctx, p.fn = false, nil, nil
+	g.mu.Unlock()
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// 预约的执行没有调用方接收 panic：它已按 panic 策略交给加入的 Follower，
+	// 这里只阻止它使计时器 goroutine 崩溃。
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(*PanicError); !ok {
+				panic(r)
+			}
+		}
+	}()
+	g.do(ctx, key, fn, &callOpts[V]{trailing: true})
+}
+
+// cancelPendingLocked 取消 s 预约的执行。
+func (g *Group[K, V]) cancelPendingLocked(s *keyState[V]) {
+	if s.pending.timer != nil {
+		s.pending.timer.Stop()
+	}
+	s.pending.scheduled, s.pending.ctx, s.pending.fn = false, nil, nil
+}
+
+// stopPendingLocked 取消所有预约的执行，由 Close 调用。
+func (g *Group[K, V]) stopPendingLocked() {
+	for _, s := range g.states {
+		g.cancelPendingLocked(s)
+	}
+}
@@ -0,0 +1,78 @@
+package kvsf
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDBAPI 是 DynamoDB 用到的 *dynamodb.Client 方法。
+type DynamoDBAPI interface {
+	PutItem(ctx context.Context, in *dynamodb.PutItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(ctx context.Context, in *dynamodb.DeleteItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+}
+
+// DynamoDB 以 DynamoDB 表实现 ConditionalKV。
+//
+// 表的分区键为字符串属性 "pk"，没有排序键。记录还包含 owner、expires（毫秒）
+// 以及 ttl（秒）属性；在表上把 ttl 设为 TTL 属性即可让 DynamoDB 自动清理过期记录。
+type DynamoDB struct {
+	Client DynamoDBAPI
+	Table  string
+}
+
+// PutIfAbsent 实现 ConditionalKV。
+func (d *DynamoDB) PutIfAbsent(ctx context.Context, key, owner string, now, expires time.Time) (bool, error) {
+	_, err := d.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: &d.Table,
+		Item: map[string]types.AttributeValue{
+			"pk":      &types.AttributeValueMemberS{Value: key},
+			"owner":   &types.AttributeValueMemberS{Value: owner},
+			"expires": number(expires.UnixMilli()),
+			// TTL 清理按秒进行且可能延迟数天，只用于回收空间，不参与互斥判断。
+			"ttl": number(expires.Unix() + 1),
+		},
+		ConditionExpression:      ptr("attribute_not_exists(#pk) OR #expires <= :now"),
+		ExpressionAttributeNames: map[string]string{"#pk": "pk", "#expires": "expires"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": number(now.UnixMilli()),
+		},
+	})
+	if conditionFailed(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// DeleteIfOwner 实现 ConditionalKV。
+func (d *DynamoDB) DeleteIfOwner(ctx context.Context, key, owner string) error {
+	_, err := d.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:           &d.Table,
+		Key:                 map[string]types.AttributeValue{"pk": &types.AttributeValueMemberS{Value: key}},
+		ConditionExpression: ptr("#owner = :owner"),
+		// 属性名一律经占位符引用，避免与 DynamoDB 的保留字冲突。
+		ExpressionAttributeNames: map[string]string{"#owner": "owner"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":owner": &types.AttributeValueMemberS{Value: owner},
+		},
+	})
+	if conditionFailed(err) {
+		return nil
+	}
+	return err
+}
+
+func conditionFailed(err error) bool {
+	var ccf *types.ConditionalCheckFailedException
+	return errors.As(err, &ccf)
+}
+
+func number(n int64) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(n, 10)}
+}
+
+func ptr(s string) *string { return &s }
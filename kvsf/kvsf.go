@@ -0,0 +1,51 @@
+// Package kvsf 以支持条件写入的键值存储实现 singleflight.LockBackend。
+//
+// 许多 serverless 部署没有 Redis，却有 DynamoDB、Firestore、Cosmos DB 这类
+// 支持 "不存在才写入" 的云端 KV。实现 ConditionalKV 即可接入，DynamoDB 已内置。
+package kvsf
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+// ConditionalKV 是锁所需的最小条件写入接口。两个方法的判断与写入都必须是原子的。
+type ConditionalKV interface {
+	// PutIfAbsent 在 key 不存在、或已有记录在 now 时已过期的情况下
+	// 写入属于 owner、在 expires 过期的记录，写入成功时返回 true。
+	PutIfAbsent(ctx context.Context, key, owner string, now, expires time.Time) (bool, error)
+
+	// DeleteIfOwner 在 key 的记录仍属于 owner 时删除它，否则什么也不做。
+	DeleteIfOwner(ctx context.Context, key, owner string) error
+}
+
+// Backend 是基于 ConditionalKV 的 singleflight.LockBackend。
+//
+// 过期判断使用调用方的时钟，实例之间的时钟偏差应远小于 ttl。
+// 过期记录的清理交给存储自身（例如 DynamoDB 的 TTL），Backend 只保证过期记录可被抢占。
+type Backend struct {
+	KV ConditionalKV
+
+	// Clock 用于计算过期时间，nil 时使用 singleflight.SystemClock。
+	Clock singleflight.Clock
+}
+
+// TryLock 实现 singleflight.LockBackend。
+func (b *Backend) TryLock(ctx context.Context, key string, ttl time.Duration) (unlock func(context.Context) error, acquired bool, err error) {
+	var token [16]byte
+	rand.Read(token[:])
+	owner := hex.EncodeToString(token[:])
+	now := time.Now()
+	if b.Clock != nil {
+		now = b.Clock.Now()
+	}
+	ok, err := b.KV.PutIfAbsent(ctx, key, owner, now, now.Add(ttl))
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return func(ctx context.Context) error { return b.KV.DeleteIfOwner(ctx, key, owner) }, true, nil
+}
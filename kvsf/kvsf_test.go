@@ -0,0 +1,85 @@
+package kvsf
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/oy3o/singleflight"
+)
+
+var _ singleflight.LockBackend = (*Backend)(nil)
+
+// fakeDynamo 只实现 DynamoDB 发出的两种条件表达式。
+type fakeDynamo struct {
+	mu    sync.Mutex
+	items map[string]map[string]types.AttributeValue
+}
+
+func attr(m map[string]types.AttributeValue, name string) string {
+	switch v := m[name].(type) {
+	case *types.AttributeValueMemberS:
+		return v.Value
+	case *types.AttributeValueMemberN:
+		return v.Value
+	}
+	return ""
+}
+
+func (f *fakeDynamo) PutItem(ctx context.Context, in *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := attr(in.Item, "pk")
+	if cur, ok := f.items[key]; ok {
+		expires, _ := strconv.ParseInt(attr(cur, "expires"), 10, 64)
+		now, _ := strconv.ParseInt(attr(in.ExpressionAttributeValues, ":now"), 10, 64)
+		if expires > now {
+			return nil, &types.ConditionalCheckFailedException{}
+		}
+	}
+	f.items[key] = in.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamo) DeleteItem(ctx context.Context, in *dynamodb.DeleteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := attr(in.Key, "pk")
+	if attr(f.items[key], "owner") != attr(in.ExpressionAttributeValues, ":owner") {
+		return nil, &types.ConditionalCheckFailedException{}
+	}
+	delete(f.items, key)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func TestBackend_DynamoDB(t *testing.T) {
+	kv := &DynamoDB{Client: &fakeDynamo{items: make(map[string]map[string]types.AttributeValue)}, Table: "locks"}
+	clock := singleflight.NewFakeClock(time.Unix(100, 0))
+	a, b := &Backend{KV: kv, Clock: clock}, &Backend{KV: kv, Clock: clock}
+	ctx := context.Background()
+
+	unlock, ok, err := a.TryLock(ctx, "k", time.Second)
+	if err != nil || !ok {
+		t.Fatalf("TryLock = %v, %v", ok, err)
+	}
+	if _, ok, err := b.TryLock(ctx, "k", time.Second); err != nil || ok {
+		t.Fatalf("held lock acquired: %v, %v", ok, err)
+	}
+
+	// 过期后可被抢占，原持有者的释放不影响新持有者。
+	clock.Advance(time.Second)
+	if _, ok, _ := b.TryLock(ctx, "k", time.Second); !ok {
+		t.Fatal("expired lock not taken over")
+	}
+	if err := unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := a.TryLock(ctx, "k", time.Second); ok {
+		t.Fatal("stale unlock released the new holder's lock")
+	}
+}
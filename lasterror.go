@@ -0,0 +1,90 @@
+package singleflight
+
+import (
+	"container/list"
+	"time"
+)
+
+// WithLastErrors 为至多 n 个最近失败的 key 保留其最后一次失败，通过 LastError 读取。
+// 超出 n 时淘汰最久未更新的 key。n <= 0 表示不保留。
+//
+// 供健康检查报告 "key X 自 12:03 起一直失败" 之类的状态，而不必再发起一次执行。
+// 与 WithLastValues 相同，它不参与任何调用的决策。
+func WithLastErrors(n int) Option {
+	return func(o *options) { o.lastErrors = n }
+}
+
+// Failure 描述 key 最近一次失败的执行，由 LastError 返回。
+type Failure struct {
+	// Err 为最近一次失败的错误，panic 时为 *PanicError。
+	Err error
+	// At 为最近一次失败的完成时间。
+	At time.Time
+	// Since 为当前这轮连续失败中第一次失败的完成时间。
+	Since time.Time
+	// Streak 为连续失败的次数，之后有执行成功时归零，Err 与 At 保留。
+	Streak int
+}
+
+// Failing 报告 key 是否仍处于连续失败之中。
+func (f Failure) Failing() bool { return f.Streak > 0 }
+
+// lastErrors 是按更新时间淘汰的有界失败表，由 g.mu 保护。
+type lastErrors[K comparable] struct {
+	ll    list.List // *lastError[K]，最近更新的在前
+	items map[K]*list.Element
+}
+
+type lastError[K comparable] struct {
+	key K
+	Failure
+}
+
+// LastError 返回 key 最近一次失败的记录，需开启 WithLastErrors。
+func (g *Group[K, V]) LastError(key K) (Failure, bool) {
+	key = g.canonical(key)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.failures == nil {
+		return Failure{}, false
+	}
+	el, ok := g.failures.items[key]
+	if !ok {
+		return Failure{}, false
+	}
+	return el.Value.(*lastError[K]).Failure, true
+}
+
+// recordOutcomeLocked 记录 key 一次执行的结果：失败时更新记录，
+// 成功时只结束已有记录的连续失败。
+func (g *Group[K, V]) recordOutcomeLocked(key K, err error, at time.Time) {
+	l := g.failures
+	if err == nil {
+		if l != nil {
+			if el, ok := l.items[key]; ok {
+				el.Value.(*lastError[K]).Streak = 0
+			}
+		}
+		return
+	}
+	if l == nil {
+		l = &lastErrors[K]{items: make(map[K]*list.Element)}
+		g.failures = l
+	}
+	if el, ok := l.items[key]; ok {
+		le := el.Value.(*lastError[K])
+		if le.Streak == 0 {
+			le.Since = at
+		}
+		le.Err, le.At = err, at
+		le.Streak++
+		l.ll.MoveToFront(el)
+		return
+	}
+	l.items[key] = l.ll.PushFront(&lastError[K]{key: key, Failure: Failure{Err: err, At: at, Since: at, Streak: 1}})
+	if l.ll.Len() > g.cfg.lastErrors {
+		oldest := l.ll.Back()
+		l.ll.Remove(oldest)
+		delete(l.items, oldest.Value.(*lastError[K]).key)
+	}
+}
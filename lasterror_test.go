@@ -0,0 +1,44 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLastError(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	g := NewGroup[string, int](WithClock(clock), WithLastErrors(1))
+	ctx := context.Background()
+	boom := errors.New("boom")
+	fail := func(context.Context) (int, error) { return 0, boom }
+	ok := func(context.Context) (int, error) { return 1, nil }
+
+	g.Do(ctx, "a", ok)
+	if _, found := g.LastError("a"); found {
+		t.Fatal("a key that never failed has a record")
+	}
+	start := clock.Now()
+	g.Do(ctx, "a", fail)
+	clock.Advance(time.Minute)
+	g.Do(ctx, "a", fail)
+	f, found := g.LastError("a")
+	if !found || f.Err != boom || !f.Since.Equal(start) || !f.At.Equal(clock.Now()) || f.Streak != 2 || !f.Failing() {
+		t.Fatalf("LastError = %+v, %v", f, found)
+	}
+
+	g.Do(ctx, "a", ok)
+	if f, _ := g.LastError("a"); f.Failing() || f.Err != boom {
+		t.Fatalf("after recovery = %+v", f)
+	}
+
+	// 超出上限时淘汰最久未更新的 key。
+	g.Do(ctx, "b", fail)
+	if _, found := g.LastError("a"); found {
+		t.Fatal("oldest record was not evicted")
+	}
+	if f, _ := g.LastError("b"); f.Streak != 1 {
+		t.Fatalf("b = %+v", f)
+	}
+}
@@ -0,0 +1,64 @@
+package singleflight
+
+import (
+	"container/list"
+	"time"
+)
+
+// WithLastValues 为至多 n 个最近完成的 key 保留其最后一次成功的结果，
+// 通过 LastValue 读取。超出 n 时淘汰最久未更新的 key。n <= 0 表示不保留。
+//
+// 与限频、防抖保留的结果不同，它不参与任何调用的决策，也不随按 key 的状态清理，
+// 供降级服务、差异比对等需要 "最后已知值" 的逻辑使用。
+func WithLastValues(n int) Option {
+	return func(o *options) { o.lastValues = n }
+}
+
+// lastValues 是按更新时间淘汰的有界结果表，由 g.mu 保护。
+type lastValues[K comparable, V any] struct {
+	ll    list.List // *lastValue[K, V]，最近更新的在前
+	items map[K]*list.Element
+}
+
+type lastValue[K comparable, V any] struct {
+	key K
+	val V
+	at  time.Time
+}
+
+// LastValue 返回 key 最后一次成功执行的结果及其完成时间，需开启 WithLastValues。
+func (g *Group[K, V]) LastValue(key K) (v V, at time.Time, ok bool) {
+	key = g.canonical(key)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.lasts == nil {
+		return v, at, false
+	}
+	el, ok := g.lasts.items[key]
+	if !ok {
+		return v, at, false
+	}
+	lv := el.Value.(*lastValue[K, V])
+	return lv.val, lv.at, true
+}
+
+// rememberLocked 记录 key 最新的成功结果。
+func (g *Group[K, V]) rememberLocked(key K, val V, at time.Time) {
+	l := g.lasts
+	if l == nil {
+		l = &lastValues[K, V]{items: make(map[K]*list.Element)}
+		g.lasts = l
+	}
+	if el, ok := l.items[key]; ok {
+		lv := el.Value.(*lastValue[K, V])
+		lv.val, lv.at = val, at
+		l.ll.MoveToFront(el)
+		return
+	}
+	l.items[key] = l.ll.PushFront(&lastValue[K, V]{key: key, val: val, at: at})
+	if l.ll.Len() > g.cfg.lastValues {
+		oldest := l.ll.Back()
+		l.ll.Remove(oldest)
+		delete(l.items, oldest.Value.(*lastValue[K, V]).key)
+	}
+}
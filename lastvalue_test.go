@@ -0,0 +1,34 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLastValue(t *testing.T) {
+	clock := NewFakeClock(time.Unix(100, 0))
+	g := NewGroup[string, int](WithClock(clock), WithLastValues(2))
+	ctx := context.Background()
+	if _, _, ok := g.LastValue("a"); ok {
+		t.Fatal("LastValue before any execution")
+	}
+
+	g.Do(ctx, "a", func(context.Context) (int, error) { return 1, nil })
+	clock.Advance(time.Second)
+	// 失败不会覆盖最后已知的成功结果。
+	g.Do(ctx, "a", func(context.Context) (int, error) { return 0, errors.New("boom") })
+	if v, at, ok := g.LastValue("a"); !ok || v != 1 || !at.Equal(time.Unix(100, 0)) {
+		t.Fatalf("LastValue(a) = %d, %v, %v", v, at, ok)
+	}
+
+	g.Do(ctx, "b", func(context.Context) (int, error) { return 2, nil })
+	g.Do(ctx, "c", func(context.Context) (int, error) { return 3, nil })
+	if _, _, ok := g.LastValue("a"); ok {
+		t.Fatal("oldest key not evicted past the bound")
+	}
+	if v, _, ok := g.LastValue("c"); !ok || v != 3 {
+		t.Fatalf("LastValue(c) = %d, %v", v, ok)
+	}
+}
@@ -0,0 +1,38 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLatencyBreakdown(t *testing.T) {
+	clock := NewFakeClock(time.Unix(100, 0))
+	joined := make(chan struct{})
+	g := NewGroup[string, int](WithClock(clock), WithLatencyBreakdown(),
+		WithHooks(Hooks[string]{
+			FollowerJoined: func(string) { close(joined) },
+			// 在结果就绪与唤醒等待者之间推进时钟，模拟清理阶段的耗时。
+			BeforeWake: func(string) { clock.Advance(3 * time.Millisecond) },
+		}))
+
+	started, release := make(chan struct{}), make(chan struct{})
+	leader := g.DoChan(context.Background(), "k", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		clock.Advance(10 * time.Millisecond)
+		return 1, nil
+	})
+	<-started
+	follower := g.DoChan(context.Background(), "k", nil)
+	<-joined
+	close(release)
+
+	l, f := <-leader, <-follower
+	if l.LeaderDuration != 10*time.Millisecond || l.WakeDelay != 0 {
+		t.Fatalf("leader = %+v", l)
+	}
+	if f.WakeDelay != 3*time.Millisecond {
+		t.Fatalf("follower WakeDelay = %v, want 3ms", f.WakeDelay)
+	}
+}
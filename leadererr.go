@@ -0,0 +1,44 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// WithLeaderErrorGrace 让 context 结束的 Follower 再等待共享执行至多 d：
+// 执行在此期间失败时，Follower 收到 errors.Join(等待错误, 执行错误)，
+// 排查时既能看到自己超时，也能看到共享的执行最终做了什么。
+// 执行在此期间成功、panic 或仍未完成时，Follower 照常只收到等待错误。
+// d <= 0 表示不等待。
+//
+// 合并后的错误仍满足 errors.Is(err, ErrWaiterCancelled)，IsExecutionError 为 false。
+// 代价是 context 结束后 Follower 至多晚 d 返回。
+func WithLeaderErrorGrace(d time.Duration) Option {
+	return func(o *options) { o.leaderErrorGrace = d }
+}
+
+// awaitLeader 在 Follower 的 context 结束后等待 done 至多宽限期，报告执行是否已完成。
+func (g *Group[K, V]) awaitLeader(done <-chan struct{}) bool {
+	if g.cfg == nil || g.cfg.leaderErrorGrace <= 0 {
+		return false
+	}
+	t := clockOrSystem(g.cfg.clock).NewTimer(g.cfg.leaderErrorGrace)
+	defer t.Stop()
+	select {
+	case <-done:
+		return true
+	case <-t.C():
+		return false
+	}
+}
+
+// cancelledAfterLeader 生成宽限期内执行已完成时 Follower 的返回值。
+func (g *Group[K, V]) cancelledAfterLeader(ctx context.Context, key K, c *call[V], co *callOpts[V]) (V, error, flight) {
+	f := flight{shared: true}
+	if c.panicErr != nil || c.err == nil || co != nil && co.hasDef {
+		return g.cancelled(ctx, key, co, f)
+	}
+	var zero V
+	return zero, errors.Join(waitError(ctx), c.err), f
+}
@@ -0,0 +1,55 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestLeaderErrorGrace(t *testing.T) {
+	boom := errors.New("boom")
+	for _, fails := range []bool{true, false} {
+		clock := NewFakeClock(time.Unix(0, 0))
+		joined := make(chan struct{})
+		g := NewGroup[string, int](
+			WithClock(clock),
+			WithLeaderErrorGrace(time.Second),
+			WithHooks(Hooks[string]{FollowerJoined: func(string) { close(joined) }}),
+		)
+		started := make(chan struct{})
+		release := make(chan struct{})
+		leader := g.DoChan(context.Background(), "k", func(context.Context) (int, error) {
+			close(started)
+			<-release
+			return 0, boom
+		})
+		<-started
+		ctx, cancel := context.WithCancel(context.Background())
+		follower := g.DoChan(ctx, "k", nil)
+		<-joined
+		cancel()
+		// Follower 的宽限期定时器已登记。
+		for clock.Timers() == 0 {
+			runtime.Gosched()
+		}
+		if fails {
+			close(release)
+		} else {
+			clock.Advance(time.Second)
+		}
+
+		r := <-follower
+		if !errors.Is(r.Err, ErrWaiterCancelled) || !errors.Is(r.Err, context.Canceled) {
+			t.Fatalf("fails=%v: err = %v, want the wait error", fails, r.Err)
+		}
+		if errors.Is(r.Err, boom) != fails {
+			t.Fatalf("fails=%v: err = %v", fails, r.Err)
+		}
+		if !fails {
+			close(release)
+		}
+		<-leader
+	}
+}
@@ -0,0 +1,65 @@
+package singleflight
+
+import "time"
+
+// Leak 描述一次超过 WithLeakMonitor 阈值仍未完成的执行，
+// 通常意味着 fn 泄漏或死锁。Info.Waiters 即阻塞在它上面的 Follower goroutine 数。
+type Leak[K comparable] struct {
+	Key  K
+	Info CallInfo
+}
+
+// WithLeakMonitor 在执行超过 age 仍未完成时调用 report 报告它，
+// 并使其出现在 Group.Leaks 与 sfdebug.Handler 中，取代翻阅 goroutine dump。report 可以为 nil。
+//
+// report 在定时器 goroutine 上、不持有 Group 锁时调用，每次执行至多一次。
+// 开启后所有执行都会计时（同 WithTiming）。K 必须与 Group 一致，age <= 0 表示不启用。
+func WithLeakMonitor[K comparable](age time.Duration, report func(Leak[K])) Option {
+	return func(o *options) {
+		o.leakAge = age
+		o.rawLeakReport = report
+		if age > 0 {
+			o.timing = true
+		}
+	}
+}
+
+// Leaks 返回当前被 WithLeakMonitor 标记且仍在运行的执行，包括已被 Forget 的。
+func (g *Group[K, V]) Leaks() []Leak[K] {
+	var leaks []Leak[K]
+	now := g.now()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for key, c := range g.calls {
+		if c.leaked {
+			leaks = append(leaks, Leak[K]{Key: key, Info: c.info(now)})
+		}
+	}
+	for key, cs := range g.orphans {
+		for _, c := range cs {
+			if c.leaked {
+				leaks = append(leaks, Leak[K]{Key: key, Info: c.info(now)})
+			}
+		}
+	}
+	return leaks
+}
+
+// monitor 为执行 c 启动泄漏检测定时器。
+func (g *Group[K, V]) monitor(key K, c *call[V]) Timer {
+	return clockOrSystem(g.cfg.clock).AfterFunc(g.cfg.leakAge, func() {
+		now := g.now()
+		g.mu.Lock()
+		if c.finished {
+			g.mu.Unlock()
+			return
+		}
+		c.leaked = true
+		info := c.info(now)
+		g.mu.Unlock()
+
+		if g.cfg.leakReport != nil {
+			g.cfg.leakReport(Leak[K]{Key: key, Info: info})
+		}
+	})
+}
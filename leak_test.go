@@ -0,0 +1,50 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWithLeakMonitor(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	joined := make(chan struct{}, 1)
+	var reports []Leak[string]
+	g := NewGroup[string, int](
+		WithClock(clock),
+		WithLeakMonitor(time.Minute, func(l Leak[string]) { reports = append(reports, l) }),
+		WithHooks(Hooks[string]{FollowerJoined: func(string) { joined <- struct{}{} }}),
+	)
+
+	// 按时完成的执行不会被报告。
+	g.Do(context.Background(), "fast", func(context.Context) (int, error) { return 0, nil })
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	leader := g.DoChan(context.Background(), "stuck", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 0, nil
+	})
+	<-started
+	follower := g.DoChan(context.Background(), "stuck", nil)
+	<-joined
+
+	clock.Advance(time.Minute)
+	if len(reports) != 1 {
+		t.Fatalf("reports = %+v", reports)
+	}
+	if l := reports[0]; l.Key != "stuck" || l.Info.Waiters != 1 || l.Info.Elapsed != time.Minute {
+		t.Fatalf("report = %+v", l)
+	}
+	if leaks := g.Leaks(); len(leaks) != 1 || leaks[0].Key != "stuck" {
+		t.Fatalf("Leaks = %+v", leaks)
+	}
+
+	close(release)
+	<-leader
+	<-follower
+	if leaks := g.Leaks(); len(leaks) != 0 {
+		t.Fatalf("Leaks after completion = %+v", leaks)
+	}
+}
@@ -0,0 +1,60 @@
+package leasesf
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// serviceAccount 为 pod 内 ServiceAccount 凭据的挂载目录。
+const serviceAccount = "/var/run/secrets/kubernetes.io/serviceaccount/"
+
+// InCluster 以 pod 的 ServiceAccount 凭据创建 Backend，Namespace 为 pod 所在的命名空间。
+func InCluster() (*Backend, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("leasesf: not running in a Kubernetes cluster")
+	}
+	ca, err := os.ReadFile(serviceAccount + "ca.crt")
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("leasesf: no certificates in ca.crt")
+	}
+	ns, err := os.ReadFile(serviceAccount + "namespace")
+	if err != nil {
+		return nil, err
+	}
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.TLSClientConfig = &tls.Config{RootCAs: pool}
+	return &Backend{
+		Client:    &http.Client{Transport: &bearer{path: serviceAccount + "token", base: base}},
+		Server:    "https://" + net.JoinHostPort(host, port),
+		Namespace: strings.TrimSpace(string(ns)),
+	}, nil
+}
+
+// bearer 每次请求都重新读取 token 文件：投射的 ServiceAccount token 会定期轮换。
+type bearer struct {
+	path string
+	base http.RoundTripper
+}
+
+func (t *bearer) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := os.ReadFile(t.path)
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	return t.base.RoundTrip(req)
+}
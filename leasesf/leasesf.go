@@ -0,0 +1,236 @@
+// Package leasesf 以 Kubernetes 的 coordination.k8s.io/v1 Lease 对象实现
+// singleflight.LockBackend，在 pod 之间选出执行某个 key 的 Leader。
+//
+// 适用于集群中已有 Kubernetes API、但不便引入 Redis / etcd 客户端的场景。
+// 直接通过 REST 调用 API server，不依赖 client-go。pod 的 ServiceAccount 需要
+// 对所在命名空间的 leases 拥有 get / create / update 权限。
+//
+// key 按哈希分配到固定数量的 Lease 上（key-bucket），Lease 对象数量因此有界；
+// 落在同一个桶上的不同 key 会互相等待，但不影响正确性。
+package leasesf
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+// microTime 是 Lease 时间字段使用的 MicroTime 格式。
+const microTime = "2006-01-02T15:04:05.000000Z07:00"
+
+// Backend 是基于 Lease 的 singleflight.LockBackend。
+type Backend struct {
+	// Client 发送 API 请求，需自带认证。InCluster 会配置好它。
+	Client *http.Client
+
+	// Server 为 API server 地址，例如 https://10.0.0.1:443。
+	Server string
+
+	// Namespace 为 Lease 所在的命名空间。
+	Namespace string
+
+	// Prefix 为 Lease 名称的前缀，默认 "singleflight"。
+	Prefix string
+
+	// Buckets 为 Lease 数量，所有 pod 必须一致。默认 64。
+	Buckets int
+
+	// Identity 标识本 pod，写入 holderIdentity 便于排查。默认为主机名。
+	Identity string
+
+	// Clock 用于判断 Lease 是否过期，nil 时使用 singleflight.SystemClock。
+	// pod 之间的时钟偏差应远小于 ttl。
+	Clock singleflight.Clock
+}
+
+// lease 是 Lease 对象中用到的字段。
+type lease struct {
+	APIVersion string     `json:"apiVersion"`
+	Kind       string     `json:"kind"`
+	Metadata   objectMeta `json:"metadata"`
+	Spec       leaseSpec  `json:"spec"`
+}
+
+type objectMeta struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace,omitempty"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+type leaseSpec struct {
+	HolderIdentity       string `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds int32  `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          string `json:"acquireTime,omitempty"`
+	RenewTime            string `json:"renewTime,omitempty"`
+}
+
+// held 报告 l 是否仍被持有。
+func (l *lease) held(now time.Time) bool {
+	if l.Spec.HolderIdentity == "" {
+		return false
+	}
+	renew, err := time.Parse(microTime, l.Spec.RenewTime)
+	if err != nil {
+		// 无法解析的时间视为刚刚续约，宁可多等也不抢占。
+		return true
+	}
+	return now.Before(renew.Add(time.Duration(l.Spec.LeaseDurationSeconds) * time.Second))
+}
+
+// TryLock 实现 singleflight.LockBackend。ttl 向上取整到秒。
+//
+// 以 resourceVersion 做乐观并发控制：同时抢占同一个 Lease 的 pod 中只有一个的
+// 写入成功，其余收到 409 Conflict 并视为未抢到。
+func (b *Backend) TryLock(ctx context.Context, key string, ttl time.Duration) (unlock func(context.Context) error, acquired bool, err error) {
+	name := b.name(key)
+	cur, err := b.get(ctx, name)
+	if err != nil {
+		return nil, false, err
+	}
+	now := b.now()
+	if cur != nil && cur.held(now) {
+		return nil, false, nil
+	}
+
+	var token [8]byte
+	rand.Read(token[:])
+	l := &lease{
+		APIVersion: "coordination.k8s.io/v1",
+		Kind:       "Lease",
+		Metadata:   objectMeta{Name: name, Namespace: b.Namespace},
+		Spec: leaseSpec{
+			// 同一个 pod 内不同 key 落在同一个桶上时也必须互斥，因此每次抢占使用不同的身份。
+			HolderIdentity:       b.identity() + "/" + hex.EncodeToString(token[:]),
+			LeaseDurationSeconds: int32(max(1, (ttl+time.Second-1)/time.Second)),
+			AcquireTime:          now.UTC().Format(microTime),
+			RenewTime:            now.UTC().Format(microTime),
+		},
+	}
+	method, url := http.MethodPost, b.collection()
+	if cur != nil {
+		l.Metadata.ResourceVersion = cur.Metadata.ResourceVersion
+		method, url = http.MethodPut, b.collection()+"/"+name
+	}
+	mine, ok, err := b.write(ctx, method, url, l)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return func(ctx context.Context) error { return b.release(ctx, mine) }, true, nil
+}
+
+// release 清空 holderIdentity。Lease 已被他人修改（例如过期后被抢占）时放弃。
+func (b *Backend) release(ctx context.Context, l *lease) error {
+	l.Spec.HolderIdentity = ""
+	l.Spec.AcquireTime = ""
+	l.Spec.RenewTime = ""
+	_, _, err := b.write(ctx, http.MethodPut, b.collection()+"/"+l.Metadata.Name, l)
+	return err
+}
+
+func (b *Backend) get(ctx context.Context, name string) (*lease, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.collection()+"/"+name, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var l lease
+		if err := json.NewDecoder(resp.Body).Decode(&l); err != nil {
+			return nil, err
+		}
+		return &l, nil
+	case http.StatusNotFound:
+		return nil, nil
+	}
+	return nil, statusError(resp)
+}
+
+// write 创建或更新 Lease，409 Conflict 时 ok 为 false。
+func (b *Backend) write(ctx context.Context, method, url string, l *lease) (written *lease, ok bool, err error) {
+	body, err := json.Marshal(l)
+	if err != nil {
+		return nil, false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := b.client().Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		var w lease
+		if err := json.NewDecoder(resp.Body).Decode(&w); err != nil {
+			return nil, false, err
+		}
+		return &w, true, nil
+	case http.StatusConflict:
+		return nil, false, nil
+	}
+	return nil, false, statusError(resp)
+}
+
+func statusError(resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("leasesf: %s %s: %s: %s", resp.Request.Method, resp.Request.URL.Path, resp.Status, bytes.TrimSpace(msg))
+}
+
+func (b *Backend) collection() string {
+	return b.Server + "/apis/coordination.k8s.io/v1/namespaces/" + b.Namespace + "/leases"
+}
+
+// name 返回 key 所在桶的 Lease 名称。哈希必须跨进程稳定。
+func (b *Backend) name(key string) string {
+	prefix := b.Prefix
+	if prefix == "" {
+		prefix = "singleflight"
+	}
+	n := b.Buckets
+	if n <= 0 {
+		n = 64
+	}
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return fmt.Sprintf("%s-%x", prefix, h.Sum64()%uint64(n))
+}
+
+func (b *Backend) identity() string {
+	if b.Identity != "" {
+		return b.Identity
+	}
+	host, _ := os.Hostname()
+	return host
+}
+
+func (b *Backend) client() *http.Client {
+	if b.Client != nil {
+		return b.Client
+	}
+	return http.DefaultClient
+}
+
+func (b *Backend) now() time.Time {
+	if b.Clock != nil {
+		return b.Clock.Now()
+	}
+	return time.Now()
+}
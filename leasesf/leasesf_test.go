@@ -0,0 +1,93 @@
+package leasesf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+var _ singleflight.LockBackend = (*Backend)(nil)
+
+// fakeAPI 模拟 API server 上 Lease 的 get / create / update 及 resourceVersion 冲突检测。
+type fakeAPI struct {
+	mu     sync.Mutex
+	rv     int
+	leases map[string]lease
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	name := path.Base(r.URL.Path)
+	var in lease
+	if r.Method != http.MethodGet {
+		json.NewDecoder(r.Body).Decode(&in)
+		name = in.Metadata.Name
+	}
+	cur, exists := f.leases[name]
+	switch {
+	case r.Method == http.MethodGet && !exists:
+		http.NotFound(w, r)
+		return
+	case r.Method == http.MethodGet:
+		json.NewEncoder(w).Encode(cur)
+		return
+	case r.Method == http.MethodPost && exists,
+		r.Method == http.MethodPut && (!exists || cur.Metadata.ResourceVersion != in.Metadata.ResourceVersion):
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	f.rv++
+	in.Metadata.ResourceVersion = strconv.Itoa(f.rv)
+	f.leases[name] = in
+	if r.Method == http.MethodPost {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(in)
+}
+
+func TestBackend(t *testing.T) {
+	srv := httptest.NewServer(&fakeAPI{leases: make(map[string]lease)})
+	defer srv.Close()
+	clock := singleflight.NewFakeClock(time.Unix(100, 0))
+	pod := func(id string) *Backend {
+		return &Backend{Server: srv.URL, Namespace: "default", Identity: id, Clock: clock}
+	}
+	a, b := pod("a"), pod("b")
+	ctx := context.Background()
+
+	unlock, ok, err := a.TryLock(ctx, "k", 10*time.Second)
+	if err != nil || !ok {
+		t.Fatalf("create = %v, %v", ok, err)
+	}
+	if _, ok, err := b.TryLock(ctx, "k", 10*time.Second); err != nil || ok {
+		t.Fatalf("held lease acquired: %v, %v", ok, err)
+	}
+	if err := unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	unlock, ok, err = b.TryLock(ctx, "k", 10*time.Second)
+	if err != nil || !ok {
+		t.Fatalf("released lease not acquired: %v, %v", ok, err)
+	}
+
+	// 过期的 Lease 可被抢占，原持有者的释放不会影响新持有者。
+	clock.Advance(11 * time.Second)
+	if _, ok, _ := a.TryLock(ctx, "k", 10*time.Second); !ok {
+		t.Fatal("expired lease not taken over")
+	}
+	if err := unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := b.TryLock(ctx, "k", 10*time.Second); ok {
+		t.Fatal("stale unlock released the new holder's lease")
+	}
+}
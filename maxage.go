@@ -0,0 +1,24 @@
+package singleflight
+
+import (
+	"context"
+	"time"
+)
+
+// DoMaxAge 与 Do 相同，但只接受产生于 maxAge 之内的已完成结果：
+// WithResultHold、DoDetachedWait 保留的结果，以及防抖、限频、失败退避复用的结果
+// 超过 maxAge 时不交给本调用。保留的结果过旧时本调用发起新的执行，
+// 同时到达的调用者照常合并到这次执行上。进行中的执行结果尚未产生，总会被加入。
+//
+// 同一 key 在不同调用点的新鲜度要求可以不同，Group 级的 WithMaxStale 无法表达。
+// 与 WithMaxStale 相同，按 key 的限频间隔内没有足够新的结果时收到 ErrRateLimited。
+// 结果年龄按 Group 的时钟计算；maxAge <= 0 表示只接受进行中的执行。
+func (g *Group[K, V]) DoMaxAge(
+	ctx context.Context,
+	key K,
+	maxAge time.Duration,
+	fn func(ctx context.Context) (V, error),
+) (v V, err error, shared bool) {
+	v, err, f := g.do(ctx, key, fn, &callOpts[V]{hasMaxAge: true, maxAge: max(maxAge, 0)})
+	return v, err, f.shared
+}
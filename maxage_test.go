@@ -0,0 +1,51 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDoMaxAge_HeldResult(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	g := NewGroup[string, int](WithClock(clock), WithResultHold(time.Minute))
+	ctx := context.Background()
+	var execs int
+	fn := func(ctx context.Context) (int, error) {
+		execs++
+		return execs, nil
+	}
+
+	g.Do(ctx, "k", fn)
+	clock.Advance(10 * time.Second)
+	if v, _, shared := g.DoMaxAge(ctx, "k", 30*time.Second, fn); v != 1 || !shared {
+		t.Fatalf("within max age = %d, shared=%v", v, shared)
+	}
+	if v, _, shared := g.DoMaxAge(ctx, "k", 5*time.Second, fn); v != 2 || shared {
+		t.Fatalf("beyond max age = %d, shared=%v", v, shared)
+	}
+	// 刷新后的结果重新开始计算年龄，其他调用者也能取用。
+	if v, _, _ := g.DoMaxAge(ctx, "k", 0, fn); v != 2 {
+		t.Fatalf("refreshed = %d, want 2", v)
+	}
+}
+
+func TestDoMaxAge_Debounce(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	g := NewGroup[string, int](WithClock(clock), WithDebounce(time.Minute))
+	ctx := context.Background()
+	var execs int
+	fn := func(ctx context.Context) (int, error) {
+		execs++
+		return execs, nil
+	}
+
+	g.Do(ctx, "k", fn)
+	clock.Advance(10 * time.Second)
+	if v, _, _ := g.Do(ctx, "k", fn); v != 1 {
+		t.Fatalf("debounced = %d", v)
+	}
+	if v, _, _ := g.DoMaxAge(ctx, "k", time.Second, fn); v != 2 {
+		t.Fatalf("beyond max age = %d, want 2", v)
+	}
+}
@@ -0,0 +1,21 @@
+package singleflight
+
+import "time"
+
+// WithMaxStale 限制降级路径上复用的旧结果的最大年龄，从结果产生时算起。
+//
+// 超过 d 的结果不再复用：防抖改为照常执行，限频间隔内返回 ErrRateLimited，
+// 失败退避返回错误而非陈旧值，DoOrDefault 返回 def。
+// 用于必须显式约束陈旧程度的场景。d <= 0 表示不限制。
+func WithMaxStale(d time.Duration) Option {
+	return func(o *options) { o.maxStale = d }
+}
+
+// freshEnough 报告产生于 at 的结果在 now 时是否仍可复用，
+// co 为发起复用的调用的选项，可为 nil。
+func (g *Group[K, V]) freshEnough(at, now time.Time, co *callOpts[V]) bool {
+	if co != nil && co.hasMaxAge && now.After(at.Add(co.maxAge)) {
+		return false
+	}
+	return g.cfg.maxStale <= 0 || !now.After(at.Add(g.cfg.maxStale))
+}
@@ -0,0 +1,53 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithMaxStale_Debounce(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	g := NewGroup[string, int](
+		WithClock(clock),
+		WithDebounce(100*time.Millisecond),
+		WithMaxStale(time.Second),
+	)
+	ctx := context.Background()
+	execs := 0
+	fn := func(context.Context) (int, error) { execs++; return execs, nil }
+
+	g.Do(ctx, "k", fn)
+	// 持续到达的调用会无限推迟尾沿，旧结果的年龄受 WithMaxStale 约束。
+	for i := 0; i < 30; i++ {
+		clock.Advance(50 * time.Millisecond)
+		g.Do(ctx, "k", fn)
+	}
+	if execs != 2 {
+		t.Fatalf("execs = %d over a 1.5s burst, want exactly one fresh load", execs)
+	}
+}
+
+func TestWithMaxStale_RateLimitAndDefault(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	g := NewGroup[string, int](
+		WithClock(clock),
+		WithMinExecInterval(time.Hour),
+		WithMaxStale(time.Minute),
+	)
+	ctx := context.Background()
+	g.Do(ctx, "k", func(context.Context) (int, error) { return 1, nil })
+
+	clock.Advance(time.Minute)
+	if v, err, _ := g.Do(ctx, "k", nil); v != 1 || err != nil {
+		t.Fatalf("at max age got %d, %v", v, err)
+	}
+	clock.Advance(time.Second)
+	if _, err, _ := g.Do(ctx, "k", nil); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("too stale: err = %v, want ErrRateLimited", err)
+	}
+	if v, ok := g.lastGood("k"); ok {
+		t.Fatalf("lastGood served %d past max stale", v)
+	}
+}
@@ -0,0 +1,121 @@
+// Package memosf 记住昂贵的编译或渲染结果，例如解析后的模板、正则表达式与 JSON Schema。
+//
+// 结果按标识与版本保存：源文件变化时调用方换用新的版本（如修改时间或内容哈希），
+// 旧版本的结果随之被替换；同一版本的并发首次计算只进行一次。
+package memosf
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+// Key 标识一次计算的输入：ID 为资源的标识，Version 为其源的版本。
+type Key struct {
+	ID      string
+	Version string
+}
+
+// Memo 保存每个 ID 最新版本的计算结果，并发安全，零值可用。
+type Memo[V any] struct {
+	// TTL 为结果的保留时长。<= 0 时结果一直保留，直到出现新版本或被 Invalidate，
+	// 即每个 Key 只计算一次。
+	TTL time.Duration
+
+	// Clock 用于 TTL 判断，nil 时使用 singleflight.SystemClock。
+	Clock singleflight.Clock
+
+	group singleflight.Group[Key, V]
+
+	mu    sync.Mutex
+	items map[string]item[V]
+	// gen 在每次 Invalidate 时递增，进行中的计算据此放弃写入已失效的结果。
+	gen uint64
+}
+
+type item[V any] struct {
+	version string
+	val     V
+	expires time.Time
+}
+
+func (m *Memo[V]) now() time.Time {
+	if m.Clock != nil {
+		return m.Clock.Now()
+	}
+	return time.Now()
+}
+
+// Get 返回 key 的结果，没有时以 compute 计算。计算失败的结果不保留，
+// 修正源之后即使版本不变也会重新计算。
+func (m *Memo[V]) Get(ctx context.Context, key Key, compute func(ctx context.Context) (V, error)) (V, error) {
+	if v, ok := m.lookup(key); ok {
+		return v, nil
+	}
+	v, err, _ := m.group.Do(ctx, key, func(ctx context.Context) (V, error) {
+		// 上一次计算可能在我们查询之后、成为 Leader 之前刚完成。
+		if v, ok := m.lookup(key); ok {
+			return v, nil
+		}
+		m.mu.Lock()
+		gen := m.gen
+		m.mu.Unlock()
+
+		v, err := compute(ctx)
+		if err != nil {
+			return v, err
+		}
+		m.store(key, v, gen)
+		return v, nil
+	})
+	return v, err
+}
+
+// Invalidate 丢弃 id 的结果，之后任何版本的 Get 都会重新计算。
+// 进行中的计算照常返回给其调用者，但不会写入。
+func (m *Memo[V]) Invalidate(id string) {
+	m.mu.Lock()
+	delete(m.items, id)
+	m.gen++
+	m.mu.Unlock()
+}
+
+// Len 返回保留的结果数量，包括已过期但尚未被访问清理的结果。
+func (m *Memo[V]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.items)
+}
+
+func (m *Memo[V]) lookup(key Key) (V, bool) {
+	var zero V
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	it, ok := m.items[key.ID]
+	if !ok || it.version != key.Version {
+		return zero, false
+	}
+	if m.TTL > 0 && !m.now().Before(it.expires) {
+		delete(m.items, key.ID)
+		return zero, false
+	}
+	return it.val, true
+}
+
+func (m *Memo[V]) store(key Key, v V, gen uint64) {
+	it := item[V]{version: key.Version, val: v}
+	if m.TTL > 0 {
+		it.expires = m.now().Add(m.TTL)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.gen != gen {
+		return
+	}
+	if m.items == nil {
+		m.items = make(map[string]item[V])
+	}
+	m.items[key.ID] = it
+}
@@ -0,0 +1,85 @@
+package memosf
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+func TestMemo_Templates(t *testing.T) {
+	var m Memo[*template.Template]
+	sources := map[string]string{"v1": "Hello, {{.}}!", "v2": "Bye, {{.}}."}
+	var parses atomic.Int32
+	render := func(version, name string) string {
+		tmpl, err := m.Get(context.Background(), Key{ID: "greeting", Version: version}, func(context.Context) (*template.Template, error) {
+			parses.Add(1)
+			return template.New("greeting").Parse(sources[version])
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b strings.Builder
+		tmpl.Execute(&b, name)
+		return b.String()
+	}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := render("v1", "moon"); got != "Hello, moon!" {
+				t.Errorf("render = %q", got)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := parses.Load(); n != 1 {
+		t.Fatalf("parses = %d, want 1", n)
+	}
+
+	// 源变化后换用新版本，旧版本的结果被替换。
+	if got := render("v2", "moon"); got != "Bye, moon." || parses.Load() != 2 || m.Len() != 1 {
+		t.Fatalf("v2 render = %q, parses = %d, len = %d", got, parses.Load(), m.Len())
+	}
+	m.Invalidate("greeting")
+	render("v2", "moon")
+	if n := parses.Load(); n != 3 {
+		t.Fatalf("parses after Invalidate = %d, want 3", n)
+	}
+}
+
+func TestMemo_TTLAndErrors(t *testing.T) {
+	clock := singleflight.NewFakeClock(time.Unix(0, 0))
+	m := &Memo[*regexp.Regexp]{TTL: time.Minute, Clock: clock}
+	compiles := 0
+	get := func(pattern string) (*regexp.Regexp, error) {
+		return m.Get(context.Background(), Key{ID: "route", Version: pattern}, func(context.Context) (*regexp.Regexp, error) {
+			compiles++
+			return regexp.Compile(pattern)
+		})
+	}
+
+	if _, err := get("("); err == nil {
+		t.Fatal("invalid pattern compiled")
+	}
+	if m.Len() != 0 {
+		t.Fatal("failed compile was kept")
+	}
+	get(`^/users/\d+$`)
+	get(`^/users/\d+$`)
+	clock.Advance(time.Minute)
+	if re, _ := get(`^/users/\d+$`); !re.MatchString("/users/7") {
+		t.Fatal("recompiled regexp does not match")
+	}
+	if compiles != 3 {
+		t.Fatalf("compiles = %d, want 3", compiles)
+	}
+}
@@ -0,0 +1,192 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrMissing 表示批量加载没有返回该 key 的结果，且 DoMulti 未提供 missing。
+// 它来自执行本身，IsExecutionError 对它返回 true。
+var ErrMissing = errors.New("singleflight: key missing from batch result")
+
+// DoMulti 以一次批量加载获取多个 key。已有执行在进行的 key 作为 Follower 加入；
+// 其余 key 登记为同一次 load 的 Leader，load 期间到达的单 key 调用同样合并进来。
+//
+// load 可以只返回部分 key 的结果，MGET 类后端天然如此。缺失的 key 交给 missing
+// 处理：单独加载、返回零值或特定错误均可；missing 为 nil 时该 key 收到 ErrMissing。
+// missing 在各 key 自己的执行中并发调用，等待该 key 的 Follower 共享其结果。
+// load 返回错误时，由本调用登记的所有 key 都收到该错误。
+//
+// 加入他人执行的 key 在该执行被 Forget 等原因需要重新执行时，
+// 以单个 key 调用 load（及 missing）。keys 中重复的 key 只加载一次，
+// 返回的 map 包含其中的每个 key。load 在调用者的 goroutine 上、作为第一个登记的 key
+// 的执行进行，不经过 WithWorkers 执行池，但受 WithExecTimeout 等执行期选项约束；
+// load panic 时所有登记的 key 照常传播 panic。
+func (g *Group[K, V]) DoMulti(
+	ctx context.Context,
+	keys []K,
+	load func(ctx context.Context, keys []K) (map[K]V, error),
+	missing func(ctx context.Context, key K) (V, error),
+) map[K]Result[V] {
+	results := make(map[K]Result[V], len(keys))
+	// single 加载一个 key，用于加入他人执行的 key 需要自己执行时。
+	single := func(key K) func(ctx context.Context) (V, error) {
+		return func(ctx context.Context) (V, error) {
+			vals, err := load(ctx, []K{key})
+			return pick(ctx, key, vals, err, missing)
+		}
+	}
+
+	var leaders, others []K
+	var calls []*call[V]
+	seen := make(map[K]struct{}, len(keys))
+	g.mu.Lock()
+	for _, key := range keys {
+		key = g.canonical(key)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		if g.closed || ctx.Err() != nil {
+			others = append(others, key)
+			continue
+		}
+		if len(g.parked) > 0 {
+			if v, ok := g.parkedLocked(key, nil); ok {
+				results[key] = Result[V]{Val: v, Shared: true}
+				continue
+			}
+		}
+		if _, ok := g.calls[key]; ok || g.paused > 0 {
+			others = append(others, key)
+			continue
+		}
+		if g.cfg != nil && g.cfg.perKey {
+			if v, err, reused, handled := g.admitLocked(ctx, key, single(key), nil); handled {
+				results[key] = Result[V]{Val: v, Err: err, Shared: reused}
+				continue
+			}
+		}
+		leaders = append(leaders, key)
+		// 登记单 key 的 fn，WithHandoff 等需要由 Follower 重新执行时有 fn 可用。
+		calls = append(calls, g.installLocked(ctx, key, single(key), nil))
+	}
+	g.mu.Unlock()
+	for _, key := range leaders {
+		g.hookLeaderInstalled(key)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	set := func(key K, r Result[V]) {
+		mu.Lock()
+		results[key] = r
+		mu.Unlock()
+	}
+	for _, key := range others {
+		wg.Go(func() {
+			v, err, f := g.do(ctx, key, single(key), nil)
+			set(key, newResult(v, err, f))
+		})
+	}
+
+	var panicErr *PanicError
+	// run 以 fn 完成第 i 个登记的 key 的执行，返回其最终的错误与 panic。
+	run := func(i int, fn func(ctx context.Context) (V, error)) (error, *PanicError) {
+		key, c := leaders[i], calls[i]
+		shared, recycle := g.doCall(c, key, fn, ctx)
+		mu.Lock()
+		defer mu.Unlock()
+		cerr, cpanic := c.err, c.panicErr
+		if cpanic != nil {
+			if panicErr == nil {
+				panicErr = cpanic
+			}
+			return cerr, cpanic
+		}
+		results[key] = newResult(c.val, c.err, flight{shared: shared, leader: true, waiters: c.waiters, dur: c.dur})
+		if recycle {
+			g.recycle(key, c)
+		}
+		return cerr, nil
+	}
+	if len(leaders) > 0 {
+		// 批量加载在第一个 key 的执行中进行，执行超时、看门狗、泄漏检测、并发额度、
+		// 故障注入与拦截器都作用于真正的 load；其余 key 的执行只从结果中取值。
+		var vals map[K]V
+		var err error
+		var r any
+		loaded := false
+		batchErr, batchPanic := run(0, func(ctx context.Context) (V, error) {
+			loaded = true
+			vals, err, r = loadBatch(ctx, leaders, load)
+			if r != nil {
+				panic(r)
+			}
+			return pick(ctx, leaders[0], vals, err, missing)
+		})
+		// 批量加载失败或根本没有执行时，其余 key 收到第一个 key 的执行的最终结果，
+		// 包括 WithExecTimeout 的包装。
+		if !loaded || err != nil {
+			err = batchErr
+			if batchPanic != nil && r == nil {
+				r = batchPanic
+			}
+		}
+		for i, key := range leaders[1:] {
+			fn := func(ctx context.Context) (V, error) {
+				if r != nil {
+					// 以原始值重新 panic，由 doCall 为每个 key 的执行照常记录。
+					panic(r)
+				}
+				return pick(ctx, key, vals, err, missing)
+			}
+			// 只有需要 missing 单独加载的 key 才值得一个 goroutine。
+			if _, ok := vals[key]; !ok && err == nil && r == nil && missing != nil {
+				wg.Go(func() { run(i+1, fn) })
+			} else {
+				run(i+1, fn)
+			}
+		}
+	}
+	wg.Wait()
+
+	if panicErr != nil {
+		panic(panicErr)
+	}
+	return results
+}
+
+// loadBatch 调用 load 并捕获其 panic，使每个登记的 key 都能完成。
+func loadBatch[K comparable, V any](
+	ctx context.Context,
+	keys []K,
+	load func(ctx context.Context, keys []K) (map[K]V, error),
+) (vals map[K]V, err error, recovered any) {
+	defer func() { recovered = recover() }()
+	vals, err = load(ctx, keys)
+	return vals, err, nil
+}
+
+// pick 从批量结果中取出 key 的结果，缺失时交给 missing。
+func pick[K comparable, V any](
+	ctx context.Context,
+	key K,
+	vals map[K]V,
+	err error,
+	missing func(ctx context.Context, key K) (V, error),
+) (V, error) {
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	if v, ok := vals[key]; ok {
+		return v, nil
+	}
+	if missing != nil {
+		return missing(ctx, key)
+	}
+	var zero V
+	return zero, ErrMissing
+}
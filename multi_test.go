@@ -0,0 +1,157 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDoMulti_PartialResults(t *testing.T) {
+	var g Group[string, int]
+	ctx := context.Background()
+	var batches [][]string
+	load := func(ctx context.Context, keys []string) (map[string]int, error) {
+		batches = append(batches, slices.Clone(keys))
+		vals := make(map[string]int)
+		for _, k := range keys {
+			if k != "gone" {
+				vals[k] = len(k)
+			}
+		}
+		return vals, nil
+	}
+
+	res := g.DoMulti(ctx, []string{"a", "bb", "gone", "a"}, load, nil)
+	if len(batches) != 1 || !slices.Equal(batches[0], []string{"a", "bb", "gone"}) {
+		t.Fatalf("batches = %v", batches)
+	}
+	if res["a"].Val != 1 || res["bb"].Val != 2 || !res["a"].Leader {
+		t.Fatalf("res = %+v", res)
+	}
+	if err := res["gone"].Err; !errors.Is(err, ErrMissing) || !IsExecutionError(err) {
+		t.Fatalf("missing key err = %v", err)
+	}
+
+	// missing 为缺失的 key 提供单独的回退。
+	res = g.DoMulti(ctx, []string{"a", "gone"}, load, func(ctx context.Context, key string) (int, error) {
+		return -1, nil
+	})
+	if r := res["gone"]; r.Val != -1 || r.Err != nil {
+		t.Fatalf("fallback = %+v", r)
+	}
+
+	boom := errors.New("boom")
+	res = g.DoMulti(ctx, []string{"x", "y"}, func(context.Context, []string) (map[string]int, error) {
+		return nil, boom
+	}, nil)
+	if !errors.Is(res["x"].Err, boom) || !errors.Is(res["y"].Err, boom) {
+		t.Fatalf("batch error not delivered to every key: %+v", res)
+	}
+}
+
+func TestDoMulti_JoinsAndIsJoined(t *testing.T) {
+	joined := make(chan struct{}, 1)
+	g := NewGroup[string, int](WithHooks(Hooks[string]{FollowerJoined: func(string) { joined <- struct{}{} }}))
+	ctx := context.Background()
+
+	// "busy" 已有执行在进行，DoMulti 加入它而不是把它放进批量加载。
+	started := make(chan struct{})
+	release := make(chan struct{})
+	busy := g.DoChan(ctx, "busy", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 100, nil
+	})
+	<-started
+
+	var loaded []string
+	var single <-chan Result[int]
+	done := make(chan map[string]Result[int])
+	go func() {
+		done <- g.DoMulti(ctx, []string{"busy", "a"}, func(ctx context.Context, keys []string) (map[string]int, error) {
+			loaded = append(loaded, keys...)
+			// 批量加载期间到达的单 key 调用合并到它上面。
+			single = g.DoChan(ctx, "a", nil)
+			<-joined
+			<-joined
+			close(release)
+			return map[string]int{"a": 1}, nil
+		}, nil)
+	}()
+	res := <-done
+	<-busy
+	if !slices.Equal(loaded, []string{"a"}) {
+		t.Fatalf("loaded = %v, want only the idle key", loaded)
+	}
+	if r := res["busy"]; r.Val != 100 || !r.Shared {
+		t.Fatalf("busy = %+v", r)
+	}
+	if r := res["a"]; r.Val != 1 || !r.Shared || r.Waiters != 1 {
+		t.Fatalf("a = %+v", r)
+	}
+	if r := <-single; r.Val != 1 {
+		t.Fatalf("single-key caller got %+v", r)
+	}
+}
+
+func TestDoMulti_ExecTimeoutCoversLoad(t *testing.T) {
+	g := NewGroup[string, int](WithExecTimeout(10 * time.Millisecond))
+	load := func(ctx context.Context, keys []string) (map[string]int, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	res := g.DoMulti(context.Background(), []string{"a", "b", "c"}, load, nil)
+	for _, k := range []string{"a", "b", "c"} {
+		if !errors.Is(res[k].Err, ErrExecTimeout) {
+			t.Fatalf("%s: err = %v, want ErrExecTimeout", k, res[k].Err)
+		}
+	}
+}
+
+func TestDoMulti_Handoff(t *testing.T) {
+	joined := make(chan struct{}, 2)
+	started := make(chan struct{})
+	g := NewGroup[string, int](WithHandoff(), WithHooks(Hooks[string]{
+		FollowerJoined: func(string) { joined <- struct{}{} },
+	}))
+	var loads atomic.Int32
+	load := func(ctx context.Context, keys []string) (map[string]int, error) {
+		if loads.Add(1) == 1 {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		m := make(map[string]int)
+		for _, k := range keys {
+			m[k] = len(k)
+		}
+		return m, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	batch := make(chan map[string]Result[int], 1)
+	go func() { batch <- g.DoMulti(ctx, []string{"a", "bb"}, load, nil) }()
+	<-started
+	followers := make([]<-chan Result[int], 0, 2)
+	for _, k := range []string{"a", "bb"} {
+		followers = append(followers, g.DoChan(context.Background(), k, nil))
+	}
+	<-joined
+	<-joined
+	cancel()
+
+	for k, r := range <-batch {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Fatalf("batch %s: err = %v, want context.Canceled", k, r.Err)
+		}
+	}
+	// 批量登记的 key 同样有 fn，Follower 接手时以单 key 调用 load。
+	for i, want := range []int{1, 2} {
+		if r := <-followers[i]; r.Err != nil || r.Val != want || !r.Leader {
+			t.Fatalf("follower %d = %+v", i, r)
+		}
+	}
+}
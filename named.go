@@ -0,0 +1,35 @@
+package singleflight
+
+import (
+	"fmt"
+	"sync"
+)
+
+var registry struct {
+	mu     sync.Mutex
+	groups map[string]any
+}
+
+// Named 返回进程范围内以 name 标识的共享 Group，首次调用时以 opts 创建，
+// 之后的调用忽略 opts 并返回同一个 Group。不同包中的库由此可以在同一命名空间上
+// 合并调用，而不必把 Group 穿过每一层构造函数。
+//
+// 同一个 name 以不同的 K、V 获取时 panic：这是编程错误，静默地返回另一个 Group
+// 会让本该合并的调用各自执行。
+func Named[K comparable, V any](name string, opts ...Option) *Group[K, V] {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if v, ok := registry.groups[name]; ok {
+		g, ok := v.(*Group[K, V])
+		if !ok {
+			panic(fmt.Sprintf("singleflight: Named(%q): registered as %T, requested as %T", name, v, g))
+		}
+		return g
+	}
+	g := NewGroup[K, V](append(opts[:len(opts):len(opts)], WithName(name))...)
+	if registry.groups == nil {
+		registry.groups = make(map[string]any)
+	}
+	registry.groups[name] = g
+	return g
+}
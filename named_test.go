@@ -0,0 +1,29 @@
+package singleflight
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNamed(t *testing.T) {
+	a := Named[string, int]("test/named", WithMaxWaiters(3))
+	b := Named[string, int]("test/named", WithMaxWaiters(99))
+	if a != b {
+		t.Fatal("same name must return the same group")
+	}
+	if b.cfg.maxWaiters != 3 {
+		t.Fatal("options of later calls must be ignored")
+	}
+	if Named[string, int]("test/other") == a {
+		t.Fatal("different names must not share a group")
+	}
+
+	defer func() {
+		msg, _ := recover().(string)
+		if !strings.Contains(msg, "test/named") {
+			t.Fatalf("recover() = %q, want type mismatch panic", msg)
+		}
+	}()
+	Named[string, time.Duration]("test/named")
+}
@@ -0,0 +1,70 @@
+package objfetch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// HTTPFetcher 通过 HTTP GET 读取对象，适用于公开对象、预签名 URL
+// 以及 S3 / GCS 的 XML / JSON 下载端点。
+//
+// 需要签名的场景可在 Client 的 Transport 中注入认证逻辑。
+type HTTPFetcher struct {
+	Client *http.Client
+
+	// URL 将对象 key 映射为下载地址。
+	URL func(key string) string
+}
+
+// S3Fetcher 返回读取 bucket 中对象的 HTTPFetcher（虚拟主机风格地址）。
+func S3Fetcher(bucket, region string) *HTTPFetcher {
+	return &HTTPFetcher{URL: func(key string) string {
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, region, escapePath(key))
+	}}
+}
+
+// GCSFetcher 返回读取 bucket 中对象的 HTTPFetcher。
+func GCSFetcher(bucket string) *HTTPFetcher {
+	return &HTTPFetcher{URL: func(key string) string {
+		return fmt.Sprintf("https://storage.googleapis.com/%s/%s", bucket, escapePath(key))
+	}}
+}
+
+// Fetch 实现 Fetcher。非 2xx 响应被视为错误。
+func (f *HTTPFetcher) Fetch(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.URL(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		resp.Body.Close()
+		return nil, &StatusError{Key: key, StatusCode: resp.StatusCode}
+	}
+	return resp.Body, nil
+}
+
+// StatusError 表示对象存储返回了非成功状态码。
+type StatusError struct {
+	Key        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("objfetch: %s: %s", e.Key, http.StatusText(e.StatusCode))
+}
+
+// escapePath 逐段转义对象 key，保留 "/" 作为路径分隔符。
+func escapePath(key string) string {
+	return (&url.URL{Path: key}).EscapedPath()
+}
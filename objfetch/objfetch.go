@@ -0,0 +1,110 @@
+// Package objfetch 合并对同一对象的并发下载，并把响应体分发给所有等待者。
+//
+// 第一个调用者发起下载，Body 被写入共享的 spool 缓冲；
+// 每个调用者拿到独立的读者，从头开始按自己的节奏读取完整内容。
+// 下载进行中到达的调用者直接挂到同一个 spool 上，而不会发起第二次下载。
+package objfetch
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/oy3o/singleflight"
+	"github.com/oy3o/singleflight/internal/spool"
+)
+
+// Fetcher 抽象对象存储的读取操作。
+//
+// ctx 同时约束请求与 Body 的读取：Client 会在所有读者放弃后取消它。
+type Fetcher[K comparable] interface {
+	Fetch(ctx context.Context, key K) (io.ReadCloser, error)
+}
+
+// Client 对 Fetcher 做下载合并。零值不可用，需设置 Fetcher。
+type Client[K comparable] struct {
+	Fetcher Fetcher[K]
+
+	// MemLimit 为每次下载保存在内存中的最大字节数，超出部分溢出到 SpoolDir 下的
+	// 临时文件（为空时使用 os.TempDir）。<= 0 表示全部保存在内存中。
+	MemLimit int
+	SpoolDir string
+
+	group singleflight.Group[K, *spool.Spool]
+
+	mu     sync.Mutex
+	active map[K]*spool.Spool
+}
+
+// New 创建一个 Client。
+func New[K comparable](f Fetcher[K]) *Client[K] {
+	return &Client[K]{Fetcher: f}
+}
+
+// Open 返回 key 对应对象的一个独立读者，调用方必须 Close。
+//
+// ctx 只约束本调用者等待下载开始的过程；下载本身与发起者的 ctx 解耦，
+// 只有当所有读者都关闭时才会被中止。
+func (c *Client[K]) Open(ctx context.Context, key K) (io.ReadCloser, error) {
+	for {
+		if r := c.join(key); r != nil {
+			return r, nil
+		}
+
+		s, err, _ := c.group.Do(ctx, key, func(ctx context.Context) (*spool.Spool, error) {
+			return c.start(ctx, key)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if r := s.NewReader(); r != nil {
+			return r, nil
+		}
+		// 所有读者在我们加入前都已放弃，下载已被中止，重新发起。
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// join 尝试挂到进行中的下载上。
+func (c *Client[K]) join(key K) io.ReadCloser {
+	c.mu.Lock()
+	s, ok := c.active[key]
+	c.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return s.NewReader()
+}
+
+func (c *Client[K]) start(ctx context.Context, key K) (*spool.Spool, error) {
+	dctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	body, err := c.Fetcher.Fetch(dctx, key)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	s := spool.NewOverflow(cancel, c.MemLimit, c.SpoolDir)
+	c.mu.Lock()
+	if c.active == nil {
+		c.active = make(map[K]*spool.Spool)
+	}
+	c.active[key] = s
+	c.mu.Unlock()
+
+	go func() {
+		_, err := io.Copy(s, body)
+		body.Close()
+		s.CloseWithError(err)
+		cancel()
+
+		c.mu.Lock()
+		if c.active[key] == s {
+			delete(c.active, key)
+		}
+		c.mu.Unlock()
+	}()
+	return s, nil
+}
@@ -0,0 +1,77 @@
+package objfetch
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// pipeFetcher 每次 Fetch 返回一个由测试控制写入节奏的 pipe。
+type pipeFetcher struct {
+	calls atomic.Int32
+	w     chan *io.PipeWriter
+}
+
+func (f *pipeFetcher) Fetch(ctx context.Context, key string) (io.ReadCloser, error) {
+	f.calls.Add(1)
+	r, w := io.Pipe()
+	f.w <- w
+	return r, nil
+}
+
+func TestClient_SharesOneDownload(t *testing.T) {
+	f := &pipeFetcher{w: make(chan *io.PipeWriter, 1)}
+	c := New[string](f)
+	ctx := context.Background()
+
+	first, err := c.Open(ctx, "obj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := <-f.w
+	w.Write([]byte("hello "))
+
+	// 下载进行中加入的读者也必须从头读到完整内容。
+	const n = 8
+	readers := []io.ReadCloser{first}
+	for i := 0; i < n; i++ {
+		r, err := c.Open(ctx, "obj")
+		if err != nil {
+			t.Fatal(err)
+		}
+		readers = append(readers, r)
+	}
+
+	var wg sync.WaitGroup
+	got := make([]string, len(readers))
+	for i, r := range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer r.Close()
+			b, _ := io.ReadAll(r)
+			got[i] = string(b)
+		}()
+	}
+	w.Write([]byte("world"))
+	w.Close()
+	wg.Wait()
+
+	if calls := f.calls.Load(); calls != 1 {
+		t.Fatalf("fetch calls = %d, want 1", calls)
+	}
+	for i, s := range got {
+		if s != "hello world" {
+			t.Fatalf("reader %d got %q", i, s)
+		}
+	}
+}
+
+func TestEscapePath(t *testing.T) {
+	if got := escapePath("dir/a b.txt"); !strings.HasSuffix(got, "dir/a%20b.txt") {
+		t.Fatalf("escapePath = %q", got)
+	}
+}
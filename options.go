@@ -0,0 +1,194 @@
+package singleflight
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// Option 配置 NewGroup 创建的 Group。
+//
+// Option 本身不带类型参数，以便 NewGroup[K, V](WithX(...)) 无需重复书写类型；
+// 依赖 K / V 的选项在 NewGroup 中一次性解析为强类型配置，类型不匹配时 panic。
+type Option func(*options)
+
+// options 收集 Option 的原始设置。
+type options struct {
+	rawHooks        any   // Hooks[K]
+	rawKeyFunc      any   // func(K) K
+	rawCost         any   // func(K) int64
+	rawLimiter      any   // *KeyedLimiter[K]
+	rawInterceptors []any // func(DoFunc[K, V]) DoFunc[K, V]
+	clock           Clock
+	chaos           *Chaos
+
+	maxWaiters   int
+	forgetPolicy ForgetPolicy
+	execTimeout  time.Duration
+	maxExtension time.Duration
+	timing       bool
+	latency      bool
+	breaker      *Breaker
+	backoff      *Backoff
+
+	minExecInterval time.Duration
+	spacedRefresh   bool
+	debounce        time.Duration
+	maxStale        time.Duration
+	lastValues      int
+	lastErrors      int
+	recorder        int
+	stats           bool
+	nearMiss        time.Duration
+	hold            time.Duration
+	intern          int
+
+	workers int
+	fifo    bool
+
+	contextValues    []any
+	handoff          bool
+	cancelHandoff    bool
+	asyncLeader      bool
+	serial           bool
+	capacity         int64
+	leaderErrorGrace time.Duration
+
+	// panicStack 为 0（未设置 WithPanicStack）时完整捕获 panic 栈，小于 0 不捕获
+	// （WithPanicStack(0) 记为 -1），否则为字节上限。
+	panicStack  int
+	panicPolicy PanicPolicy
+
+	watchdog       time.Duration
+	watchdogCancel bool
+
+	leakAge       time.Duration
+	origin        int
+	rawLeakReport any // func(Leak[K])
+
+	rawAdaptive any // AdaptiveTimeout[K]
+
+	name        string
+	keyedErrors bool
+}
+
+// config 是 NewGroup 解析后的强类型配置。零值 Group 的 cfg 为 nil，
+// 热路径只需一次 nil 判断即可跳过所有可选功能。
+type config[K comparable, V any] struct {
+	options
+	hooks   Hooks[K]
+	keyFunc func(K) K
+	sem     *semaphore.Weighted
+	cost    func(K) int64
+
+	interceptors []func(DoFunc[K, V]) DoFunc[K, V]
+	leakReport   func(Leak[K])
+	pool         *workerPool
+	interned     *internTable[K]
+	adaptive     *latencyTracker[K]
+	limiter      *KeyedLimiter[K]
+	interval     *KeyedLimiter[K] // WithMinExecInterval
+
+	// perKey 表示启用了需要 keyState 或执行前准入的策略，keepLast 表示其中有策略
+	// 需要复用最近一次结果，均由 NewGroup 汇总。
+	perKey   bool
+	keepLast bool
+}
+
+// NewGroup 创建带选项的 Group。不需要任何选项时，零值 Group 同样可用。
+func NewGroup[K comparable, V any](opts ...Option) *Group[K, V] {
+	g := new(Group[K, V])
+	if len(opts) == 0 {
+		return g
+	}
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	g.cfg = newConfig[K, V](o)
+	return g
+}
+
+// newConfig 把 o 解析为强类型配置，并为有状态的功能（并发额度、执行池、延迟统计等）
+// 分配各自的状态。Clone 据此得到与原 Group 不共享任何状态的配置。
+func newConfig[K comparable, V any](o options) *config[K, V] {
+	cfg := &config[K, V]{options: o}
+	if o.rawHooks != nil {
+		cfg.hooks = typed[Hooks[K]]("WithHooks", o.rawHooks)
+	}
+	if o.capacity > 0 {
+		cfg.sem = semaphore.NewWeighted(o.capacity)
+		if o.rawCost != nil {
+			cfg.cost = typed[func(K) int64]("WithWeightedLimit", o.rawCost)
+		}
+	}
+	for _, ic := range o.rawInterceptors {
+		cfg.interceptors = append(cfg.interceptors, typed[func(DoFunc[K, V]) DoFunc[K, V]]("WithInterceptor", ic))
+	}
+	if o.rawLeakReport != nil {
+		cfg.leakReport = typed[func(Leak[K])]("WithLeakMonitor", o.rawLeakReport)
+	}
+	if o.rawKeyFunc != nil {
+		cfg.keyFunc = typed[func(K) K]("WithKeyFunc", o.rawKeyFunc)
+	}
+	if o.rawAdaptive != nil {
+		cfg.adaptive = newLatencyTracker(typed[AdaptiveTimeout[K]]("WithAdaptiveTimeout", o.rawAdaptive))
+	}
+	if o.rawLimiter != nil {
+		cfg.limiter = typed[*KeyedLimiter[K]]("WithLimiter", o.rawLimiter)
+	}
+	if o.intern > 0 {
+		cfg.interned = newInternTable[K](o.intern)
+	}
+	if o.nearMiss > 0 {
+		cfg.stats = true
+	}
+	if o.workers > 0 {
+		cfg.pool = &workerPool{size: o.workers, fifo: o.fifo}
+	}
+	if o.minExecInterval > 0 {
+		cfg.interval = &KeyedLimiter[K]{Every: o.minExecInterval, Clock: o.clock}
+	}
+	cfg.keepLast = o.minExecInterval > 0 || o.debounce > 0
+	cfg.perKey = o.breaker != nil || o.backoff != nil || cfg.keepLast || cfg.limiter != nil
+	return cfg
+}
+
+// typed 把依赖类型参数的选项断言为 Group 对应的类型。
+func typed[T any](name string, v any) T {
+	t, ok := v.(T)
+	if !ok {
+		var want T
+		panic(fmt.Sprintf("singleflight: %s: got %T, want %T", name, v, want))
+	}
+	return t
+}
+
+// WithMaxWaiters 限制单个 key 同时等待的 Follower 数量，
+// 超出的调用者立即收到 ErrTooManyWaiters，防止单个卡住的 key 堆积无限 goroutine。
+// n <= 0 表示不限制。
+func WithMaxWaiters(n int) Option {
+	return func(o *options) { o.maxWaiters = n }
+}
+
+// WithExecTimeout 限制 Leader 执行 fn 的时长。超时后 fn 的 ctx 以 ErrExecTimeout
+// 为 cause 被取消，fn 返回的错误会被包装为同时满足 errors.Is(err, ErrExecTimeout)。
+// d <= 0 表示不限制。
+func WithExecTimeout(d time.Duration) Option {
+	return func(o *options) { o.execTimeout = d }
+}
+
+// WithTiming 让每次执行都记录起止时间。默认只有以 DoResult 发起的执行才计时，
+// 因此以 DoResult 加入 Do 发起的执行时拿不到 LeaderDuration。
+func WithTiming() Option {
+	return func(o *options) { o.timing = true }
+}
+
+// WithLatencyBreakdown 让 DoResult / DoChan 的 Result 额外记录 LockWait 与 WakeDelay，
+// 用于判断延迟回退来自负载本身还是合并的协调开销。隐含 WithTiming。
+//
+// 每次调用会多读取两到三次时钟，建议只在排查期间开启。
+func WithLatencyBreakdown() Option {
+	return func(o *options) { o.latency, o.timing = true, true }
+}
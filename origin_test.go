@@ -0,0 +1,63 @@
+package singleflight
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestLeaderOrigin(t *testing.T) {
+	g := NewGroup[string, int](WithLeaderOrigin(4))
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var want uint64
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		want = goroutineID()
+		g.Do(context.Background(), "k", func(context.Context) (int, error) {
+			close(started)
+			<-release
+			return 0, nil
+		})
+	}()
+	<-started
+
+	info, ok := g.Inspect("k")
+	close(release)
+	<-done
+	if !ok || info.Origin == nil {
+		t.Fatalf("Inspect = %+v, %v", info, ok)
+	}
+	if info.Origin.Goroutine != want || want == 0 {
+		t.Fatalf("Goroutine = %d, want %d", info.Origin.Goroutine, want)
+	}
+	if len(info.Origin.PC) == 0 || len(info.Origin.PC) > 4 {
+		t.Fatalf("PC has %d frames", len(info.Origin.PC))
+	}
+	// 最内层的帧是发起调用的闭包，Group 内部帧已被裁掉。
+	f, _ := info.Origin.Frames().Next()
+	if !strings.Contains(f.Function, "TestLeaderOrigin") {
+		t.Fatalf("innermost frame = %s", f.Function)
+	}
+	if s := info.Origin.String(); !strings.Contains(s, "TestLeaderOrigin") || !strings.HasPrefix(s, "goroutine ") {
+		t.Fatalf("String = %q", s)
+	}
+}
+
+func TestLeaderOrigin_Disabled(t *testing.T) {
+	g := NewGroup[string, int](WithTiming())
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go g.Do(context.Background(), "k", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 0, nil
+	})
+	<-started
+	info, _ := g.Inspect("k")
+	close(release)
+	if info.Origin != nil {
+		t.Fatalf("Origin = %v without WithLeaderOrigin", info.Origin)
+	}
+}
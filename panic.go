@@ -0,0 +1,151 @@
+package singleflight
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// PanicError 包装 fn 的 panic 值和调用栈，
+// 使 Follower 收到的 panic 包含原始现场信息而非二次 panic 的栈。
+//
+// 重新抛出的 panic 值与 PanicLeaderOnly 交给 Follower 的错误都是 *PanicError，
+// 崩溃上报可以用 errors.As 取出结构化的现场，而不必解析错误文本。
+type PanicError struct {
+	value any
+	stack []byte
+}
+
+// Value 返回 fn 传给 panic 的原始值。
+func (p *PanicError) Value() any { return p.value }
+
+// Stack 返回按 WithPanicStack 捕获并裁剪后的栈，只包含 panic 处到执行入口之间的帧。
+// 不捕获栈时为 nil。
+func (p *PanicError) Stack() []byte { return p.stack }
+
+// stackHeadroom 是限长捕获时为 recover、gopanic 等随后被裁掉的帧预留的空间。
+const stackHeadroom = 1 << 10
+
+// newPanicError 必须在 doCall 的 recover 中直接调用，栈的裁剪依赖该调用位置。
+// limit 为解析后的 options.panicStack：0 为完整捕获，小于 0 不捕获，否则为字节上限。
+func newPanicError(v any, limit int) *PanicError {
+	p := &PanicError{value: v}
+	switch {
+	case limit < 0:
+	case limit == 0:
+		p.stack = trimStack(debug.Stack())
+	default:
+		buf := make([]byte, limit+stackHeadroom)
+		p.stack = truncateStack(trimStack(buf[:runtime.Stack(buf, false)]), limit)
+	}
+	return p
+}
+
+// WithPanicStack 限制 fn panic 时捕获的调用栈字节数，limit <= 0 表示完全不捕获。
+// 不设置时完整捕获：panic 风暴下每次都会分配数百 KB，
+// 且栈会出现在每个 Follower 重新 panic 的错误文本中。
+func WithPanicStack(limit int) Option {
+	return func(o *options) {
+		// 内部以 0 表示未设置（完整捕获），显式的 0 记为不捕获。
+		if limit == 0 {
+			limit = -1
+		}
+		o.panicStack = limit
+	}
+}
+
+// panicStack 返回 newPanicError 使用的限长。
+func (g *Group[K, V]) panicStack() int {
+	if g.cfg == nil {
+		return 0
+	}
+	return g.cfg.panicStack
+}
+
+// Error 包含裁剪后的栈：进程因重新抛出的 panic 崩溃时，
+// 运行时打印的就是 Error()，此时原始现场必须可见。
+func (p *PanicError) Error() string {
+	if len(p.stack) == 0 {
+		return fmt.Sprint(p.value)
+	}
+	return fmt.Sprintf("%v\n\n%s", p.value, p.stack)
+}
+
+// Format 实现 fmt.Formatter：%v 与 %s 只输出 panic 值，%+v 附带栈，
+// 避免日志中每一行都携带完整的栈。
+func (p *PanicError) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && f.Flag('+'):
+		fmt.Fprint(f, p.Error())
+	case verb == 'v', verb == 's':
+		fmt.Fprint(f, p.value)
+	case verb == 'q':
+		fmt.Fprintf(f, "%q", fmt.Sprint(p.value))
+	default:
+		fmt.Fprintf(f, "%%!%c(*singleflight.PanicError=%v)", verb, p.value)
+	}
+}
+
+// Unwrap 允许 errors.Is / errors.As 穿透到原始 error。
+func (p *PanicError) Unwrap() error {
+	err, ok := p.value.(error)
+	if !ok {
+		return nil
+	}
+	return err
+}
+
+// trimStack 只保留 panic 发生处到 doCall 之间的帧：
+// 去掉 debug.Stack、recover 所在的 defer 与 runtime.gopanic，
+// 以及 doCall 及其之上的 Group 内部与调用方帧（调用方的栈在重新 panic 时自然可见）。
+// 无法识别格式时原样返回。
+func trimStack(stack []byte) []byte {
+	lines := bytes.Split(bytes.TrimRight(stack, "\n"), []byte("\n"))
+	if len(lines) < 3 {
+		return stack
+	}
+
+	// 第一行是 "goroutine N [running]:"，之后每帧两行：函数行与文件行。
+	frames := lines[1:]
+	begin, end := -1, -1
+	for i := 0; i+1 < len(frames); i += 2 {
+		fn, file := frames[i], frames[i+1]
+		switch {
+		case bytes.HasPrefix(fn, []byte("panic(")) && bytes.Contains(file, []byte("runtime/panic.go")):
+			begin = i + 2
+		case begin >= 0 && bytes.Contains(fn, []byte("github.com/oy3o/singleflight.(*Group[")) &&
+			bytes.Contains(fn, []byte("]).doCall(")):
+			end = i
+		}
+		if end >= 0 {
+			break
+		}
+	}
+	if begin < 0 || end >= 0 && end < begin {
+		return stack
+	}
+	if end < 0 {
+		// 限长捕获截断在 doCall 之前，或不在 doCall 中调用。
+		end = len(frames)
+	}
+
+	var b bytes.Buffer
+	b.Write(lines[0])
+	b.WriteByte('\n')
+	for _, l := range frames[begin:end] {
+		b.Write(l)
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+// truncateStack 在行边界把栈截到不超过 limit 字节，并以 "...\n" 标记截断。
+func truncateStack(stack []byte, limit int) []byte {
+	if len(stack) <= limit {
+		return stack
+	}
+	const mark = "...\n"
+	cut := bytes.LastIndexByte(stack[:max(limit-len(mark), 0)], '\n') + 1
+	return append(stack[:cut:cut], mark...)
+}
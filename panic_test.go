@@ -0,0 +1,90 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func panickingLoader(ctx context.Context) (int, error) {
+	panic("kaboom")
+}
+
+func TestPanicError_FormatAndTrim(t *testing.T) {
+	var g Group[string, int]
+	var pe *PanicError
+	func() {
+		defer func() { pe, _ = recover().(*PanicError) }()
+		g.Do(context.Background(), "k", panickingLoader)
+	}()
+	if pe == nil {
+		t.Fatal("expected *PanicError")
+	}
+
+	if s := fmt.Sprintf("%v", pe); s != "kaboom" {
+		t.Fatalf("%%v = %q, want just the value", s)
+	}
+	full := fmt.Sprintf("%+v", pe)
+	if !strings.Contains(full, "panickingLoader") {
+		t.Fatalf("%%+v lacks the panicking frame:\n%s", full)
+	}
+	for _, noise := range []string{"runtime/debug.Stack", "doCall", "runtime/panic.go"} {
+		if strings.Contains(full, noise) {
+			t.Fatalf("%%+v still contains %q:\n%s", noise, full)
+		}
+	}
+}
+
+func TestPanicError_Accessors(t *testing.T) {
+	var g Group[string, int]
+	boom := errors.New("boom")
+	pe := recoverPanicError(&g, func(context.Context) (int, error) { panic(boom) })
+	var got *PanicError
+	if !errors.As(fmt.Errorf("load: %w", pe), &got) {
+		t.Fatal("errors.As did not find *PanicError")
+	}
+	if got.Value() != boom || !errors.Is(got, boom) {
+		t.Fatalf("Value() = %v", got.Value())
+	}
+	if !strings.Contains(string(got.Stack()), "TestPanicError_Accessors") {
+		t.Fatalf("Stack() lacks the panicking frame:\n%s", got.Stack())
+	}
+}
+
+func recoverPanicError(g *Group[string, int], fn func(context.Context) (int, error)) (pe *PanicError) {
+	defer func() { pe, _ = recover().(*PanicError) }()
+	g.Do(context.Background(), "k", fn)
+	return nil
+}
+
+func TestWithPanicStack(t *testing.T) {
+	for _, limit := range []int{-1, 0} {
+		g := NewGroup[string, int](WithPanicStack(limit))
+		pe := recoverPanicError(g, panickingLoader)
+		if pe == nil || len(pe.stack) != 0 || pe.Error() != "kaboom" {
+			t.Fatalf("WithPanicStack(%d) captured: %#v", limit, pe)
+		}
+	}
+
+	const limit = 256
+	var deep func(n int) (int, error)
+	deep = func(n int) (int, error) {
+		if n == 0 {
+			panic("kaboom")
+		}
+		return deep(n - 1)
+	}
+	g := NewGroup[string, int](WithPanicStack(limit))
+	pe := recoverPanicError(g, func(context.Context) (int, error) { return deep(50) })
+	if pe == nil {
+		t.Fatal("expected *PanicError")
+	}
+	if len(pe.stack) > limit || !strings.HasSuffix(string(pe.stack), "...\n") {
+		t.Fatalf("stack not capped at %d bytes (%d):\n%s", limit, len(pe.stack), pe.stack)
+	}
+	if strings.Contains(string(pe.stack), "runtime/panic.go") {
+		t.Fatalf("capped stack still contains the panic frames:\n%s", pe.stack)
+	}
+}
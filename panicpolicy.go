@@ -0,0 +1,29 @@
+package singleflight
+
+import "errors"
+
+// PanicPolicy 决定 fn panic 时哪些调用者重新 panic。
+type PanicPolicy int
+
+const (
+	// PanicAll 为默认策略：Leader 与每个 Follower 都重新 panic，
+	// 与 x/sync/singleflight 一致。
+	PanicAll PanicPolicy = iota
+
+	// PanicLeaderOnly 只让发起执行的 Leader 重新 panic，保留出错现场的崩溃语义；
+	// Follower 改为收到一个 IsPanic 为 true 的错误。
+	// 一个坏输入不应让上千个只是问了同一个问题的 goroutine 一起 panic。
+	PanicLeaderOnly
+)
+
+// WithPanicPolicy 设置 fn panic 时的传播方式。
+func WithPanicPolicy(p PanicPolicy) Option {
+	return func(o *options) { o.panicPolicy = p }
+}
+
+// IsPanic 报告 err 是否表示共享的执行发生了 panic。
+// 错误文本包含 panic 值与（按 WithPanicStack 捕获的）栈。
+func IsPanic(err error) bool {
+	var pe *PanicError
+	return errors.As(err, &pe)
+}
@@ -0,0 +1,35 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPanicLeaderOnly(t *testing.T) {
+	joined := make(chan struct{})
+	g := NewGroup[string, int](
+		WithPanicPolicy(PanicLeaderOnly),
+		WithHooks(Hooks[string]{FollowerJoined: func(string) { close(joined) }}),
+	)
+
+	started := make(chan struct{})
+	leaderPanic := make(chan any, 1)
+	go func() {
+		defer func() { leaderPanic <- recover() }()
+		g.Do(context.Background(), "k", func(context.Context) (int, error) {
+			close(started)
+			<-joined
+			panic("kaboom")
+		})
+	}()
+
+	<-started
+	// Follower 若重新 panic，测试进程会直接崩溃。
+	_, err, _ := g.Do(context.Background(), "k", nil)
+	if !IsPanic(err) || !IsExecutionError(err) {
+		t.Fatalf("follower err = %v, want a panic error", err)
+	}
+	if r := <-leaderPanic; !IsPanic(r.(error)) {
+		t.Fatalf("leader recovered %v, want the panic", r)
+	}
+}
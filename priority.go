@@ -0,0 +1,29 @@
+package singleflight
+
+import "context"
+
+// Priority 是执行在 WithWorkers 队列中的优先级，数值越大越先执行。
+type Priority int
+
+const (
+	// PriorityBatch 用于预热、后台刷新等可以让路的执行。
+	PriorityBatch Priority = -1
+	// PriorityNormal 为未设置优先级时的默认值。
+	PriorityNormal Priority = 0
+	// PriorityInteractive 用于有用户在等待的执行。
+	PriorityInteractive Priority = 1
+)
+
+type priorityKey struct{}
+
+// WithPriority 返回携带执行优先级的 ctx。Leader 的 ctx 决定排队位置，
+// 更高优先级的 Follower 加入排队中的执行时会把它提升到自己的优先级。
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFrom 返回 ctx 上的优先级，未设置时为 PriorityNormal。
+func PriorityFrom(ctx context.Context) Priority {
+	p, _ := ctx.Value(priorityKey{}).(Priority)
+	return p
+}
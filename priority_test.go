@@ -0,0 +1,89 @@
+package singleflight
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// waitQueued 等待 g 的执行池中恰有 n 个排队的执行。
+func waitQueued[K comparable, V any](g *Group[K, V], n int) {
+	for {
+		p := g.cfg.pool
+		p.mu.Lock()
+		l := p.queue.Len()
+		p.mu.Unlock()
+		if l == n {
+			return
+		}
+		runtime.Gosched()
+	}
+}
+
+func TestPriority_QueueOrder(t *testing.T) {
+	order := runQueued(t, WithWorkers(1))
+	want := []string{"batch2", "interactive", "normal", "batch1"}
+	if !slices.Equal(order, want) {
+		t.Fatalf("execution order = %v, want %v", order, want)
+	}
+}
+
+func TestPriority_FIFO(t *testing.T) {
+	order := runQueued(t, WithWorkers(1), WithFIFO())
+	want := []string{"batch1", "normal", "batch2", "interactive"}
+	if !slices.Equal(order, want) {
+		t.Fatalf("execution order = %v, want %v", order, want)
+	}
+}
+
+// runQueued 在唯一的 worker 被占住时依次提交不同优先级的执行，返回实际执行顺序。
+func runQueued(t *testing.T, opts ...Option) []string {
+	joined := make(chan struct{})
+	opts = append(opts, WithHooks(Hooks[string]{FollowerJoined: func(string) { close(joined) }}))
+	g := NewGroup[string, string](opts...)
+
+	// 占住唯一的 worker。
+	started := make(chan struct{})
+	release := make(chan struct{})
+	blocker := g.DoChan(context.Background(), "blocker", func(context.Context) (string, error) {
+		close(started)
+		<-release
+		return "", nil
+	})
+	<-started
+
+	var mu sync.Mutex
+	var order []string
+	var results []<-chan Result[string]
+	submit := func(ctx context.Context, key string) {
+		results = append(results, g.DoChan(ctx, key, func(context.Context) (string, error) {
+			mu.Lock()
+			order = append(order, key)
+			mu.Unlock()
+			return key, nil
+		}))
+		waitQueued(g, len(results))
+	}
+	batch := WithPriority(context.Background(), PriorityBatch)
+	interactive := WithPriority(context.Background(), PriorityInteractive)
+
+	submit(batch, "batch1")
+	submit(context.Background(), "normal")
+	submit(batch, "batch2")
+	submit(interactive, "interactive")
+
+	// 交互式 Follower 加入排队中的 batch2，把它提升到交互优先级（WithFIFO 时无效）；
+	// 同优先级下 batch2 提交得更早，因此排在 interactive 之前。
+	results = append(results, g.DoChan(interactive, "batch2", nil))
+	<-joined
+
+	close(release)
+	<-blocker
+	for _, ch := range results {
+		<-ch
+	}
+
+	return order
+}
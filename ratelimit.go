@@ -0,0 +1,31 @@
+package singleflight
+
+import "time"
+
+// ErrRateLimited 表示 key 在 WithMinExecInterval 的间隔内已经执行过，
+// 且还没有可复用的结果（例如上一次执行被 Forget 后仍在进行），
+// 或 WithLimiter 的令牌已用尽。
+var ErrRateLimited = newGroupError("singleflight: execution rate limited")
+
+// WithMinExecInterval 限制同一 key 两次执行开始之间的最小间隔。
+//
+// 间隔内到达且无执行可合并的调用者直接拿到该 key 最近一次完成的结果
+// （shared 为 true）；尚无结果时收到 ErrRateLimited。限制跨越 Forget，
+// 用于在失效风暴中保护后端。间隔由每个 Group 独有的、Burst 为 1 的 KeyedLimiter 计量。
+// d <= 0 表示不限制。
+func WithMinExecInterval(d time.Duration) Option {
+	return func(o *options) { o.minExecInterval = d }
+}
+
+// WithRefreshSpacing 与 WithMinExecInterval(d) 相同，但间隔内到达的调用者
+// 在复用旧结果的同时预约下一次刷新：刷新在上次执行开始 d 之后于后台进行，
+// 间隔内的所有调用合并到同一次预约上，使用最近一次调用传入的 fn。
+//
+// 与 TTL 缓存不同，陈旧程度由执行间隔而非值的年龄决定，且只要有人访问，
+// key 就按 d 的节奏持续刷新，适合轮询类负载。d <= 0 表示不开启。
+func WithRefreshSpacing(d time.Duration) Option {
+	return func(o *options) {
+		o.minExecInterval = d
+		o.spacedRefresh = d > 0
+	}
+}
@@ -0,0 +1,118 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMinExecInterval(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	g := NewGroup[string, int](WithClock(clock), WithMinExecInterval(time.Second))
+	ctx := context.Background()
+	var execs int
+	fn := func(ctx context.Context) (int, error) {
+		execs++
+		return execs, nil
+	}
+
+	if v, _, shared := g.Do(ctx, "k", fn); v != 1 || shared {
+		t.Fatalf("first = %d, shared=%v", v, shared)
+	}
+	// 间隔内的调用复用上次结果，Forget 也不能绕过限制。
+	g.Forget("k")
+	if v, err, shared := g.Do(ctx, "k", fn); v != 1 || err != nil || !shared {
+		t.Fatalf("limited = %d, %v, shared=%v", v, err, shared)
+	}
+
+	clock.Advance(time.Second)
+	if v, _, _ := g.Do(ctx, "k", fn); v != 2 {
+		t.Fatalf("after interval = %d, want 2", v)
+	}
+	if execs != 2 {
+		t.Fatalf("execs = %d", execs)
+	}
+}
+
+func TestMinExecInterval_NoResultYet(t *testing.T) {
+	g := NewGroup[string, int](WithMinExecInterval(time.Hour))
+	started := make(chan struct{})
+	release := make(chan struct{})
+	res := g.DoChan(context.Background(), "k", func(ctx context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	g.Forget("k")
+	if _, err, _ := g.Do(context.Background(), "k", nil); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("err = %v, want ErrRateLimited", err)
+	}
+	close(release)
+	<-res
+	if v, err, _ := g.Do(context.Background(), "k", nil); v != 1 || err != nil {
+		t.Fatalf("after completion = %d, %v", v, err)
+	}
+}
+
+func TestRefreshSpacing(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	g := NewGroup[string, int](WithClock(clock), WithRefreshSpacing(time.Second))
+	ctx := context.Background()
+	var execs int
+	fn := func(ctx context.Context) (int, error) {
+		execs++
+		return execs, nil
+	}
+
+	g.Do(ctx, "k", fn)
+	// 间隔内的多次调用共享旧值，并合并到同一次预约的刷新上，且不会推迟它。
+	for i := 0; i < 3; i++ {
+		clock.Advance(300 * time.Millisecond)
+		if v, _, _ := g.Do(ctx, "k", fn); v != 1 {
+			t.Fatalf("call %d = %d, want stale 1", i, v)
+		}
+	}
+	clock.Advance(100 * time.Millisecond)
+	if execs != 2 {
+		t.Fatalf("execs = %d, want refresh exactly one interval after the first", execs)
+	}
+	if v, _, _ := g.Do(ctx, "k", fn); v != 2 {
+		t.Fatalf("after refresh = %d", v)
+	}
+
+	// 无人访问时不再刷新。
+	clock.Advance(10 * time.Second)
+	if execs != 3 {
+		t.Fatalf("execs = %d, want 3", execs)
+	}
+}
+
+func TestRefreshSpacing_RefreshPanicIsContained(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	g := NewGroup[string, int](WithClock(clock), WithRefreshSpacing(time.Second))
+	ctx := context.Background()
+	g.Do(ctx, "k", func(context.Context) (int, error) { return 1, nil })
+	if v, _, _ := g.Do(ctx, "k", func(context.Context) (int, error) { panic("boom") }); v != 1 {
+		t.Fatalf("within interval = %d, want stale 1", v)
+	}
+
+	// 预约的刷新在计时器回调中执行，panic 不能逃出 Advance，旧值保持可用。
+	clock.Advance(time.Second)
+	var execs int
+	fn := func(context.Context) (int, error) {
+		execs++
+		return 2, nil
+	}
+	if v, _, _ := g.Do(ctx, "k", fn); v != 1 {
+		t.Fatalf("after panicked refresh = %d, want stale 1", v)
+	}
+	clock.Advance(time.Second)
+	if execs != 1 {
+		t.Fatalf("execs = %d, want the next refresh to run", execs)
+	}
+	if v, _, _ := g.Do(ctx, "k", fn); v != 2 {
+		t.Fatalf("after refresh = %d, want 2", v)
+	}
+}
@@ -0,0 +1,83 @@
+package singleflight
+
+import "time"
+
+// WithFlightRecorder 在一个容量为 n 的环形缓冲区中保留最近完成的 n 次执行，
+// 通过 Recent 或 sfdebug.Handler 读取。n <= 0 表示不记录。
+//
+// 事故往往在挂上 profiler 之前就已结束，这段历史是事后唯一的线索。
+// 开启后所有执行都会计时（同 WithTiming），记录在 Group 锁内完成，只写入预分配的槽位。
+func WithFlightRecorder(n int) Option {
+	return func(o *options) {
+		o.recorder = n
+		if n > 0 {
+			o.timing = true
+		}
+	}
+}
+
+// Record 描述一次已完成的执行，由 Recent 返回。
+type Record[K comparable] struct {
+	Key K
+	// Start 与 End 为 Leader 开始与完成执行的时刻，Duration 为两者之差。
+	Start    time.Time
+	End      time.Time
+	Duration time.Duration
+	// Waiters 为执行完成时共享结果的 Follower 数量，Shared 即 Waiters > 0。
+	Waiters int
+	Shared  bool
+	// Err 为执行的错误，panic 时为 *PanicError。
+	Err error
+	// Forgotten 表示执行完成前 key 已被 Forget（或被 WithWatchdog 释放）。
+	Forgotten bool
+}
+
+// flightRecorder 是定长的环形缓冲区，由 g.mu 保护。
+type flightRecorder[K comparable] struct {
+	ring []Record[K]
+	next int
+	full bool
+}
+
+// Recent 返回 WithFlightRecorder 保留的执行记录，按完成先后排列，最近的在最后。
+// 返回的切片是副本，调用方可以自由修改。
+func (g *Group[K, V]) Recent() []Record[K] {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	r := g.recorder
+	if r == nil {
+		return nil
+	}
+	if !r.full {
+		return append([]Record[K](nil), r.ring[:r.next]...)
+	}
+	out := make([]Record[K], 0, len(r.ring))
+	out = append(out, r.ring[r.next:]...)
+	return append(out, r.ring[:r.next]...)
+}
+
+// recordLocked 把执行 c 写入环形缓冲区，覆盖最旧的记录。
+func (g *Group[K, V]) recordLocked(key K, c *call[V], waiters int) {
+	r := g.recorder
+	if r == nil {
+		r = &flightRecorder[K]{ring: make([]Record[K], g.cfg.recorder)}
+		g.recorder = r
+	}
+	rec := Record[K]{
+		Key:       key,
+		Start:     c.start,
+		End:       c.end,
+		Duration:  c.dur,
+		Waiters:   waiters,
+		Shared:    waiters > 0,
+		Err:       c.err,
+		Forgotten: c.forgotten,
+	}
+	if c.panicErr != nil {
+		rec.Err = c.panicErr
+	}
+	r.ring[r.next] = rec
+	if r.next++; r.next == len(r.ring) {
+		r.next, r.full = 0, true
+	}
+}
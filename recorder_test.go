@@ -0,0 +1,70 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFlightRecorder(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	g := NewGroup[string, int](WithClock(clock), WithFlightRecorder(2))
+	ctx := context.Background()
+	boom := errors.New("boom")
+
+	if r := g.Recent(); len(r) != 0 {
+		t.Fatalf("Recent before any call = %v", r)
+	}
+	g.Do(ctx, "a", func(context.Context) (int, error) {
+		clock.Advance(time.Second)
+		return 1, nil
+	})
+	g.Do(ctx, "b", func(context.Context) (int, error) { return 0, boom })
+	func() {
+		defer func() { recover() }()
+		g.Do(ctx, "c", func(context.Context) (int, error) { panic("bad") })
+	}()
+
+	// 容量为 2，最旧的 a 被覆盖，其余按完成先后排列。
+	r := g.Recent()
+	if len(r) != 2 || r[0].Key != "b" || r[1].Key != "c" {
+		t.Fatalf("Recent = %+v", r)
+	}
+	if r[0].Err != boom || r[0].Shared || r[0].End.IsZero() {
+		t.Fatalf("b = %+v", r[0])
+	}
+	var pe *PanicError
+	if !errors.As(r[1].Err, &pe) {
+		t.Fatalf("c.Err = %v, want *PanicError", r[1].Err)
+	}
+}
+
+func TestFlightRecorder_Shared(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	ctx := context.Background()
+	installed := make(chan struct{})
+	joined := make(chan struct{})
+	g := NewGroup[string, int](WithClock(clock), WithFlightRecorder(4), WithHooks(Hooks[string]{
+		LeaderInstalled: func(string) { close(installed) },
+		FollowerJoined:  func(string) { close(joined) },
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		g.Do(ctx, "k", func(context.Context) (int, error) {
+			<-joined
+			clock.Advance(3 * time.Second)
+			return 1, nil
+		})
+	}()
+	<-installed
+	g.Do(ctx, "k", func(context.Context) (int, error) { return 2, nil })
+	<-done
+
+	r := g.Recent()
+	if len(r) != 1 || !r[0].Shared || r[0].Waiters != 1 || r[0].Duration != 3*time.Second {
+		t.Fatalf("Recent = %+v", r)
+	}
+}
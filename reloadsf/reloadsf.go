@@ -0,0 +1,106 @@
+// Package reloadsf 在配置源变化时重新加载配置，并把一阵变化事件合并为一次加载。
+//
+// 编辑器保存、配置管理工具下发时一次修改往往产生多个文件事件，
+// 逐个事件重新加载既浪费，又可能读到写了一半的文件。Reloader 在最后一个事件之后
+// 安静 Window 时长才加载一次，读者始终拿到某次完整加载的结果。
+package reloadsf
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+// Reloader 持有最近一次成功加载的配置，并发安全。Load 必须设置，其余字段零值可用。
+type Reloader[T any] struct {
+	// Load 读取并解析全部配置源，返回完整的配置。
+	Load func(ctx context.Context) (T, error)
+
+	// Window 为合并变化事件的安静期，默认 100 毫秒。
+	Window time.Duration
+
+	// OnError 在后台的重新加载失败时调用，此时读者继续拿到之前的配置。
+	OnError func(error)
+
+	// Clock 用于合并窗口的计时，nil 时使用 singleflight.SystemClock。
+	Clock singleflight.Clock
+
+	// group 合并首次加载与后台重新加载，首次读取的并发调用者只触发一次加载。
+	group singleflight.Group[struct{}, T]
+
+	cur atomic.Pointer[snapshot[T]]
+	seq atomic.Uint64
+
+	mu    sync.Mutex
+	timer singleflight.Timer
+}
+
+// snapshot 是一次完整加载的结果，seq 为该次加载开始的顺序，用于丢弃更早开始、
+// 却更晚完成的加载结果。
+type snapshot[T any] struct {
+	val T
+	seq uint64
+}
+
+// Current 返回最近一次成功加载的配置。尚未加载过时以 Load 加载，
+// 并发的首次调用只加载一次。
+func (r *Reloader[T]) Current(ctx context.Context) (T, error) {
+	if s := r.cur.Load(); s != nil {
+		return s.val, nil
+	}
+	v, err, _ := r.group.Do(ctx, struct{}{}, r.reload)
+	return v, err
+}
+
+// Changed 通知配置源已经变化。最后一次通知之后安静 Window 时长，配置在后台重新加载一次。
+//
+// 不使用 singleflight.WithDebounce：它在安静期之后的第一次调用立即执行，
+// 而第一个事件到达时文件往往还没有写完。
+func (r *Reloader[T]) Changed() {
+	window := r.Window
+	if window <= 0 {
+		window = 100 * time.Millisecond
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.timer == nil {
+		clock := r.Clock
+		if clock == nil {
+			clock = singleflight.SystemClock
+		}
+		r.timer = clock.AfterFunc(window, r.fire)
+		return
+	}
+	r.timer.Reset(window)
+}
+
+// fire 在合并窗口结束后重新加载。进行中的加载可能读到了变化之前的内容，
+// 先 Forget 使本次成为新的执行，而不是加入它。
+func (r *Reloader[T]) fire() {
+	r.group.Forget(struct{}{})
+	if _, err, _ := r.group.Do(context.Background(), struct{}{}, r.reload); err != nil && r.OnError != nil {
+		r.OnError(err)
+	}
+}
+
+// reload 执行一次加载，成功时发布结果，除非已有更晚开始的加载发布过。
+func (r *Reloader[T]) reload(ctx context.Context) (T, error) {
+	seq := r.seq.Add(1)
+	v, err := r.Load(ctx)
+	if err != nil {
+		return v, err
+	}
+	next := &snapshot[T]{val: v, seq: seq}
+	for {
+		cur := r.cur.Load()
+		if cur != nil && cur.seq > seq {
+			return v, nil
+		}
+		if r.cur.CompareAndSwap(cur, next) {
+			return v, nil
+		}
+	}
+}
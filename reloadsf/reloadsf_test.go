@@ -0,0 +1,136 @@
+package reloadsf
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+func TestReloader_CoalescesEvents(t *testing.T) {
+	clock := singleflight.NewFakeClock(time.Unix(0, 0))
+	var version, loads atomic.Int32
+	var failed atomic.Value
+	boom := errors.New("parse error")
+	r := &Reloader[int32]{
+		Window:  time.Second,
+		Clock:   clock,
+		OnError: func(err error) { failed.Store(err) },
+		Load: func(ctx context.Context) (int32, error) {
+			loads.Add(1)
+			if version.Load() < 0 {
+				return 0, boom
+			}
+			return version.Load(), nil
+		},
+	}
+	ctx := context.Background()
+	if v, _ := r.Current(ctx); v != 0 {
+		t.Fatalf("initial = %d", v)
+	}
+
+	version.Store(1)
+	for range 5 {
+		r.Changed()
+		clock.Advance(500 * time.Millisecond)
+	}
+	if v, _ := r.Current(ctx); v != 0 || loads.Load() != 1 {
+		t.Fatalf("during burst = %d, loads = %d", v, loads.Load())
+	}
+	clock.Advance(500 * time.Millisecond)
+	if v, _ := r.Current(ctx); v != 1 || loads.Load() != 2 {
+		t.Fatalf("after burst = %d, loads = %d, want one reload", v, loads.Load())
+	}
+
+	// 加载失败时读者继续拿到之前的配置。
+	version.Store(-1)
+	r.Changed()
+	clock.Advance(time.Second)
+	if v, _ := r.Current(ctx); v != 1 {
+		t.Fatalf("after failed reload = %d", v)
+	}
+	if err, _ := failed.Load().(error); !errors.Is(err, boom) {
+		t.Fatalf("OnError got %v", err)
+	}
+}
+
+func TestReloader_StaleLoadDiscarded(t *testing.T) {
+	clock := singleflight.NewFakeClock(time.Unix(0, 0))
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var version atomic.Int32
+	r := &Reloader[int32]{
+		Clock: clock,
+		Load: func(ctx context.Context) (int32, error) {
+			v := version.Load()
+			if v == 0 {
+				close(started)
+				<-release
+			}
+			return v, nil
+		},
+	}
+	first := make(chan int32)
+	go func() {
+		v, _ := r.Current(context.Background())
+		first <- v
+	}()
+	<-started
+	// 首次加载仍在进行时配置变化，后台加载不能加入它。
+	version.Store(1)
+	r.Changed()
+	clock.Advance(time.Second)
+	close(release)
+	if v := <-first; v != 0 {
+		t.Fatalf("first caller = %d", v)
+	}
+	if v, _ := r.Current(context.Background()); v != 1 {
+		t.Fatalf("Current = %d, an earlier load overwrote a later one", v)
+	}
+}
+
+func TestReloader_Watch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.conf")
+	if err := os.WriteFile(path, []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := &Reloader[string]{
+		Window: 10 * time.Millisecond,
+		Load: func(context.Context) (string, error) {
+			b, err := os.ReadFile(path)
+			return string(b), err
+		},
+	}
+	if v, _ := r.Current(context.Background()); v != "a" {
+		t.Fatalf("initial = %q", v)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- r.Watch(ctx, path) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// 以重命名替换文件，与编辑器的保存方式相同。Watch 可能尚未开始监视，重复写入直到生效。
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		tmp := filepath.Join(dir, ".app.conf.tmp")
+		os.WriteFile(tmp, []byte("b"), 0o644)
+		os.Rename(tmp, path)
+		time.Sleep(20 * time.Millisecond)
+		if v, _ := r.Current(context.Background()); v == "b" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("change was not picked up")
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package reloadsf
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watch 监视 files 的变化并调用 Changed，阻塞直到 ctx 结束，之后返回 nil。
+//
+// 监视的是文件所在的目录而不是文件本身：编辑器和 Kubernetes ConfigMap
+// 常以重命名替换文件，直接监视的文件被替换后就不再产生事件。
+// 监视器本身的错误交给 OnError。
+func (r *Reloader[T]) Watch(ctx context.Context, files ...string) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	watched := make(map[string]bool, len(files))
+	dirs := make(map[string]bool)
+	for _, f := range files {
+		f, err := filepath.Abs(f)
+		if err != nil {
+			return err
+		}
+		watched[f] = true
+		dir := filepath.Dir(f)
+		if dirs[dir] {
+			continue
+		}
+		if err := w.Add(dir); err != nil {
+			return err
+		}
+		dirs[dir] = true
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			// ConfigMap 通过替换目录下的 ..data 符号链接更新，文件本身不产生事件。
+			if watched[filepath.Clean(ev.Name)] || filepath.Base(ev.Name) == "..data" {
+				r.Changed()
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			if r.OnError != nil {
+				r.OnError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package singleflight
+
+import (
+	"context"
+	"hash/crc32"
+	"slices"
+	"strconv"
+	"sync/atomic"
+)
+
+// Ring 是带虚拟节点的一致性哈希环，用于把 key 映射到唯一的 owner 节点。
+// 并发安全：SetPeers 原子替换整张环，读路径无锁。
+type Ring struct {
+	replicas int
+	state    atomic.Pointer[ringState]
+}
+
+type ringState struct {
+	hashes []uint32
+	owners map[uint32]string
+}
+
+// NewRing 创建哈希环。replicas 为每个节点的虚拟节点数，<= 0 时取 50。
+func NewRing(replicas int, peers ...string) *Ring {
+	if replicas <= 0 {
+		replicas = 50
+	}
+	r := &Ring{replicas: replicas}
+	r.SetPeers(peers...)
+	return r
+}
+
+// SetPeers 替换节点列表。成员变化时只有约 1/N 的 key 会迁移。
+func (r *Ring) SetPeers(peers ...string) {
+	s := &ringState{owners: make(map[uint32]string, len(peers)*r.replicas)}
+	for _, p := range peers {
+		for i := 0; i < r.replicas; i++ {
+			h := crc32.ChecksumIEEE([]byte(strconv.Itoa(i) + p))
+			s.hashes = append(s.hashes, h)
+			s.owners[h] = p
+		}
+	}
+	slices.Sort(s.hashes)
+	r.state.Store(s)
+}
+
+// Owner 返回 key 的 owner 节点，环为空时返回 ""。
+func (r *Ring) Owner(key string) string {
+	s := r.state.Load()
+	if s == nil || len(s.hashes) == 0 {
+		return ""
+	}
+	h := crc32.ChecksumIEEE([]byte(key))
+	i, _ := slices.BinarySearch(s.hashes, h)
+	if i == len(s.hashes) {
+		i = 0
+	}
+	return s.owners[s.hashes[i]]
+}
+
+// PeerTransport 把一次加载转发给 owner 节点。
+// 对端收到请求后应调用其 Router.ServeLocal。
+type PeerTransport[K comparable, V any] interface {
+	Fetch(ctx context.Context, peer string, key K) (V, error)
+}
+
+// Router 实现 groupcache 风格的集群级合并：每个 key 有唯一 owner，
+// 非 owner 把请求转发给 owner，由 owner 的本地 Group 合并所有节点的请求。
+// 无需共享锁服务即可获得集群范围内每 key 一次执行。
+//
+// 由于执行可能发生在远端，加载逻辑以 Load 注册，而非每次调用传入闭包。
+type Router[K comparable, V any] struct {
+	// Self 为本节点在 Ring 中的名字。
+	Self string
+
+	Ring *Ring
+
+	// Key 把 K 编码为哈希用的字符串。
+	Key func(K) string
+
+	// Load 在 owner 节点上实际加载 key。
+	Load func(ctx context.Context, key K) (V, error)
+
+	Transport PeerTransport[K, V]
+
+	// FallbackLocal 为 true 时，转发失败降级为本地加载。
+	FallbackLocal bool
+
+	// Local 为本地加载的合并组，nil 时使用私有 Group。
+	Local *Group[K, V]
+
+	// Forward 合并发往远端的请求，同一节点上对远端 key 的并发请求只转发一次。
+	// nil 时使用私有 Group。它不能与 Local 是同一个 Group：成员视图不一致、
+	// 两个节点互相转发同一 key 时，ServeLocal 会加入本节点正在等待对端的转发，
+	// 形成跨节点死锁。
+	Forward *Group[K, V]
+
+	local   Group[K, V]
+	forward Group[K, V]
+}
+
+func (r *Router[K, V]) group() *Group[K, V] {
+	if r.Local != nil {
+		return r.Local
+	}
+	return &r.local
+}
+
+func (r *Router[K, V]) forwards() *Group[K, V] {
+	if r.Forward != nil {
+		return r.Forward
+	}
+	return &r.forward
+}
+
+// Do 加载 key：本节点是 owner 时本地执行，否则转发给 owner。
+func (r *Router[K, V]) Do(ctx context.Context, key K) (v V, err error, shared bool) {
+	owner := r.Ring.Owner(r.Key(key))
+	if owner == "" || owner == r.Self {
+		return r.ServeLocal(ctx, key)
+	}
+	return r.forwards().Do(ctx, key, func(ctx context.Context) (V, error) {
+		v, err := r.Transport.Fetch(ctx, owner, key)
+		if err != nil && r.FallbackLocal {
+			return r.Load(ctx, key)
+		}
+		return v, err
+	})
+}
+
+// ServeLocal 在本节点执行加载而不再转发，供传输层的服务端调用。
+// 即使成员视图不一致，也不会在节点之间来回转发。
+func (r *Router[K, V]) ServeLocal(ctx context.Context, key K) (v V, err error, shared bool) {
+	return r.group().Do(ctx, key, func(ctx context.Context) (V, error) {
+		return r.Load(ctx, key)
+	})
+}
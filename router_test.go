@@ -0,0 +1,150 @@
+package singleflight
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memTransport 直接调用对端 Router.ServeLocal，模拟网络转发。
+type memTransport struct {
+	peers map[string]*Router[string, string]
+}
+
+func (m *memTransport) Fetch(ctx context.Context, peer string, key string) (string, error) {
+	v, err, _ := m.peers[peer].ServeLocal(ctx, key)
+	return v, err
+}
+
+func TestRing_StableOwner(t *testing.T) {
+	r := NewRing(0, "a", "b", "c")
+	moved := 0
+	before := make(map[string]string)
+	for i := 0; i < 1000; i++ {
+		k := fmt.Sprint(i)
+		before[k] = r.Owner(k)
+	}
+	r.SetPeers("a", "b", "c", "d")
+	for k, o := range before {
+		if now := r.Owner(k); now != o {
+			// 只有划给新节点的 key 会迁移，其余 key 的 owner 不变。
+			if now != "d" {
+				t.Fatalf("key %s moved from %s to %s", k, o, now)
+			}
+			moved++
+		}
+	}
+	// 新增 1 个节点，理想迁移比例约 1/4。
+	if moved < 100 || moved > 400 {
+		t.Fatalf("moved %d of 1000 keys", moved)
+	}
+}
+
+func TestRouter_ClusterWideDedup(t *testing.T) {
+	const perPeer = 10
+	ring := NewRing(0, "a", "b", "c")
+	tr := &memTransport{peers: make(map[string]*Router[string, string])}
+
+	// 每个节点 perPeer 个调用中除 Leader 外都加入本地执行或转发，owner 另有两个
+	// 转发请求加入：全部到齐后才放行加载，结果与调度无关。
+	const joins = 3*(perPeer-1) + 2
+	var joined atomic.Int32
+	release := make(chan struct{})
+	hooks := WithHooks(Hooks[string]{FollowerJoined: func(string) {
+		if joined.Add(1) == joins {
+			close(release)
+		}
+	}})
+	var loads atomic.Int32
+	for _, name := range []string{"a", "b", "c"} {
+		tr.peers[name] = &Router[string, string]{
+			Self:      name,
+			Ring:      ring,
+			Key:       func(s string) string { return s },
+			Transport: tr,
+			Local:     NewGroup[string, string](hooks),
+			Forward:   NewGroup[string, string](hooks),
+			Load: func(ctx context.Context, key string) (string, error) {
+				loads.Add(1)
+				<-release
+				return "v:" + key + "@" + name, nil
+			},
+		}
+	}
+
+	type result struct {
+		v      string
+		err    error
+		shared bool
+	}
+	var wg sync.WaitGroup
+	results := make(chan result, 3*perPeer)
+	for _, r := range tr.peers {
+		for range perPeer {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				v, err, shared := r.Do(context.Background(), "hot")
+				results <- result{v, err, shared}
+			}()
+		}
+	}
+	wg.Wait()
+	close(results)
+
+	owner := ring.Owner("hot")
+	for r := range results {
+		if r.err != nil || r.v != "v:hot@"+owner || !r.shared {
+			t.Fatalf("result = %+v, want a shared value produced by owner %q", r, owner)
+		}
+	}
+	// 所有节点的请求都汇聚到 owner，只加载一次。
+	if n := loads.Load(); n != 1 {
+		t.Fatalf("loads = %d, want 1", n)
+	}
+}
+
+// swapTransport 在两个转发都已发出后才把请求交给对端，构造互相转发的交错。
+type swapTransport struct {
+	memTransport
+	barrier sync.WaitGroup
+}
+
+func (s *swapTransport) Fetch(ctx context.Context, peer string, key string) (string, error) {
+	s.barrier.Done()
+	s.barrier.Wait()
+	return s.memTransport.Fetch(ctx, peer, key)
+}
+
+func TestRouter_SwappedRingViews(t *testing.T) {
+	tr := &swapTransport{memTransport: memTransport{peers: make(map[string]*Router[string, string])}}
+	tr.barrier.Add(2)
+	// a 认为 b 是 owner，b 认为 a 是 owner。
+	for self, other := range map[string]string{"a": "b", "b": "a"} {
+		tr.peers[self] = &Router[string, string]{
+			Self:      self,
+			Ring:      NewRing(0, other),
+			Key:       func(s string) string { return s },
+			Transport: tr,
+			Load: func(ctx context.Context, key string) (string, error) {
+				return "v@" + self, nil
+			},
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var wg sync.WaitGroup
+	for self, other := range map[string]string{"a": "b", "b": "a"} {
+		wg.Go(func() {
+			// 转发到对端后由对端本地加载，不会再转发回来。
+			if v, err, _ := tr.peers[self].Do(ctx, "k"); err != nil || v != "v@"+other {
+				t.Errorf("%s: Do = %q, %v, want v@%s", self, v, err, other)
+			}
+		})
+	}
+	wg.Wait()
+}
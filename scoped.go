@@ -0,0 +1,53 @@
+package singleflight
+
+import "context"
+
+// Scoped 是底层 Group 上按命名空间隔离的视图，典型用法是多租户服务
+// 为每个租户创建一个视图而共享同一个 Group：视图内的 key 自动带上命名空间，
+// 不同视图的同名 key 互不合并，也无需在每个调用点拼接字符串。
+type Scoped[K comparable, V any] struct {
+	g  *Group[Key2[string, K], V]
+	ns string
+}
+
+// Scope 返回 g 上命名空间为 ns 的视图。视图本身不持有状态，可以随用随建。
+func Scope[K comparable, V any](g *Group[Key2[string, K], V], ns string) *Scoped[K, V] {
+	return &Scoped[K, V]{g: g, ns: ns}
+}
+
+func (s *Scoped[K, V]) key(key K) Key2[string, K] {
+	return Key2[string, K]{s.ns, key}
+}
+
+// Namespace 返回视图的命名空间。
+func (s *Scoped[K, V]) Namespace() string { return s.ns }
+
+// Do 语义同 Group.Do。
+func (s *Scoped[K, V]) Do(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) (V, error),
+) (v V, err error, shared bool) {
+	return s.g.Do(ctx, s.key(key), fn)
+}
+
+// DoChan 语义同 Group.DoChan。
+func (s *Scoped[K, V]) DoChan(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) (V, error),
+) <-chan Result[V] {
+	return s.g.DoChan(ctx, s.key(key), fn)
+}
+
+// Forget 语义同 Group.Forget。
+func (s *Scoped[K, V]) Forget(key K) {
+	s.g.Forget(s.key(key))
+}
+
+// ForgetAll 忘记命名空间内所有进行中的 key，返回忘记的数量。
+func (s *Scoped[K, V]) ForgetAll() int {
+	return s.g.ForgetFunc(func(k Key2[string, K]) bool { return k.K1 == s.ns })
+}
+
+var _ Doer[string, any] = (*Scoped[string, any])(nil)
@@ -0,0 +1,45 @@
+package singleflight
+
+import (
+	"context"
+	"testing"
+)
+
+func TestScoped_IsolatesAndForgetsAsUnit(t *testing.T) {
+	var g Group[Key2[string, string], string]
+	acme, globex := Scope(&g, "acme"), Scope(&g, "globex")
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 3)
+	block := func(v string) func(context.Context) (string, error) {
+		return func(context.Context) (string, error) {
+			started <- struct{}{}
+			<-release
+			return v, nil
+		}
+	}
+	a1 := acme.DoChan(context.Background(), "user:1", block("acme-1"))
+	a2 := acme.DoChan(context.Background(), "user:2", block("acme-2"))
+	// 同名 key 在不同命名空间下各自执行。
+	g1 := globex.DoChan(context.Background(), "user:1", block("globex-1"))
+	for range 3 {
+		<-started
+	}
+
+	if n := acme.ForgetAll(); n != 2 {
+		t.Fatalf("ForgetAll forgot %d keys, want 2", n)
+	}
+	g.mu.Lock()
+	_, alive := g.calls[MakeKey2("globex", "user:1")]
+	g.mu.Unlock()
+	if !alive {
+		t.Fatal("ForgetAll must not touch other namespaces")
+	}
+
+	close(release)
+	for ch, want := range map[<-chan Result[string]]string{a1: "acme-1", a2: "acme-2", g1: "globex-1"} {
+		if r := <-ch; r.Val != want {
+			t.Fatalf("got %q, want %q", r.Val, want)
+		}
+	}
+}
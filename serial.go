@@ -0,0 +1,66 @@
+package singleflight
+
+import "context"
+
+// WithSerial 让同一个 key 的执行逐个进行：执行开始之后才到达的调用不再加入它，
+// 而是排队等它完成，之后所有排队者合并为下一次执行并共享其结果。
+//
+// 默认的合并只保证"共享正在进行的结果"，写入类操作需要的却是"一次一个、按先后"：
+// 执行开始后才提交的修改必须由一次更晚开始的执行处理。排队者成为 Leader 时使用自己的 fn，
+// 因此 fn 不能为 nil。排队期间 ctx 结束时调用者以 ErrWaiterCancelled 返回。
+// WithResultHold 与 DoDetachedWait 保留的结果同样开始于调用到达之前，串行模式下不会被取用。
+func WithSerial() Option {
+	return func(o *options) { o.serial = true }
+}
+
+// joinableLocked 报告调用能否加入 key 正在进行的执行 c：非串行模式下总是可以，
+// 串行模式下只有排队后重试、且 c 在其排队之后才开始的调用可以。
+func (g *Group[K, V]) joinableLocked(c *call[V], co *callOpts[V]) bool {
+	if g.cfg == nil || !g.cfg.serial {
+		return true
+	}
+	return co != nil && co.queued && c.seq > co.after
+}
+
+// queueLocked 等待 c 完成后重新发起调用。调用时必须持有 g.mu，返回前释放。
+// 排队者不登记为 c 的 Follower，c 的 Waiters 与回收都不受影响。
+func (g *Group[K, V]) queueLocked(
+	ctx context.Context,
+	key K,
+	c *call[V],
+	fn func(ctx context.Context) (V, error),
+	co *callOpts[V],
+) (V, error, flight) {
+	if c.next == nil {
+		c.next = make(chan struct{})
+	}
+	next := c.next
+	q := &callOpts[V]{}
+	if co != nil {
+		*q = *co
+	}
+	q.queued, q.after = true, c.seq
+	g.mu.Unlock()
+	g.hookQueued(key)
+
+	select {
+	case <-next:
+	case <-ctx.Done():
+		return g.cancelled(ctx, key, co, flight{})
+	}
+	return g.doCanonical(ctx, key, fn, q)
+}
+
+// nextLocked 记录 c 的序号，供排队者判断之后的执行是否开始于它们排队之后。
+func (g *Group[K, V]) nextLocked(c *call[V]) {
+	g.seq++
+	c.seq = g.seq
+}
+
+// releaseQueueLocked 在 c 完成、key 已移出 map 之后放行排在它后面的调用者。
+func (c *call[V]) releaseQueueLocked() {
+	if c.next != nil {
+		close(c.next)
+		c.next = nil
+	}
+}
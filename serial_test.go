@@ -0,0 +1,110 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSerial(t *testing.T) {
+	queued := make(chan struct{}, 4)
+	joined := make(chan struct{}, 4)
+	started := make(chan struct{}, 4)
+	g := NewGroup[string, int](WithSerial(), WithHooks(Hooks[string]{
+		Queued:         func(string) { queued <- struct{}{} },
+		FollowerJoined: func(string) { joined <- struct{}{} },
+	}))
+	ctx := context.Background()
+	var execs atomic.Int32
+	release := make(chan struct{})
+	fn := func(context.Context) (int, error) {
+		n := int(execs.Add(1))
+		started <- struct{}{}
+		<-release
+		return n, nil
+	}
+
+	type res struct {
+		v      int
+		shared bool
+	}
+	results := make(chan res, 4)
+	call := func() {
+		v, _, shared := g.Do(ctx, "k", fn)
+		results <- res{v, shared}
+	}
+	go call()
+	<-started
+
+	// 执行开始后才到达的调用排队，而不是加入它。
+	go call()
+	go call()
+	<-queued
+	<-queued
+
+	release <- struct{}{}
+	if r := <-results; r.v != 1 || r.shared {
+		t.Fatalf("first = %+v", r)
+	}
+
+	// 两个排队者合并为第二次执行；此后到达的调用排在它后面。
+	<-started
+	<-joined
+	go call()
+	<-queued
+	release <- struct{}{}
+	for range 2 {
+		if r := <-results; r.v != 2 {
+			t.Fatalf("queued batch got %+v, want 2", r)
+		}
+	}
+	<-started
+	release <- struct{}{}
+	if r := <-results; r.v != 3 {
+		t.Fatalf("last = %+v, want 3", r)
+	}
+	if n := execs.Load(); n != 3 {
+		t.Fatalf("executions = %d, want 3", n)
+	}
+}
+
+func TestSerial_CancelWhileQueued(t *testing.T) {
+	queued := make(chan struct{})
+	g := NewGroup[string, int](WithSerial(), WithHooks(Hooks[string]{
+		Queued: func(string) { close(queued) },
+	}))
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go g.Do(context.Background(), "k", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err, _ := g.Do(ctx, "k", func(context.Context) (int, error) { return 2, nil })
+		errc <- err
+	}()
+	<-queued
+	cancel()
+	if err := <-errc; !errors.Is(err, ErrWaiterCancelled) {
+		t.Fatalf("err = %v, want ErrWaiterCancelled", err)
+	}
+}
+
+func TestSerial_IgnoresHeldResult(t *testing.T) {
+	g := NewGroup[string, int](WithSerial(), WithResultHold(time.Hour))
+	var execs atomic.Int32
+	fn := func(context.Context) (int, error) { return int(execs.Add(1)), nil }
+	for want := 1; want <= 2; want++ {
+		if v, err, shared := g.Do(context.Background(), "k", fn); v != want || err != nil || shared {
+			t.Fatalf("call %d = %d, %v, shared=%v; want a fresh execution", want, v, err, shared)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Package sfdebug 提供以 JSON 展示 singleflight.Group 内部状态的调试 http.Handler，
+// 用来代替翻阅 goroutine dump 排查卡住的执行。
+//
+// Handler 只读取 Group 的公开快照（Range、Leaks、Recent），不会修改 Group，
+// 但输出包含 key 与调用栈，应当只挂在内部调试端口上。
+package sfdebug
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+// Call 是进行中的一次执行在 JSON 中的形式。
+type Call struct {
+	Key       string        `json:"key"`
+	Start     time.Time     `json:"start,omitzero"`
+	Elapsed   time.Duration `json:"elapsed"`
+	Waiters   int           `json:"waiters"`
+	Shared    bool          `json:"shared"`
+	Forgotten bool          `json:"forgotten,omitempty"`
+	// Origin 为 singleflight.Origin.String 的输出，未开启 WithLeaderOrigin 时为空。
+	Origin string `json:"origin,omitempty"`
+}
+
+// Record 是一次已完成的执行在 JSON 中的形式。
+type Record struct {
+	Key       string        `json:"key"`
+	Start     time.Time     `json:"start"`
+	End       time.Time     `json:"end"`
+	Duration  time.Duration `json:"duration"`
+	Waiters   int           `json:"waiters"`
+	Shared    bool          `json:"shared"`
+	Err       string        `json:"err,omitempty"`
+	Forgotten bool          `json:"forgotten,omitempty"`
+}
+
+// Snapshot 是 Handler 返回的 JSON 文档。
+type Snapshot struct {
+	Name string `json:"name,omitempty"`
+	// Calls 为所有进行中的执行，包括已被 Forget 的。
+	Calls []Call `json:"calls"`
+	// Leaks 为被 WithLeakMonitor 标记的执行，Waiters 即阻塞在其上的 goroutine 数。
+	Leaks []Call `json:"leaks"`
+	// Recent 为 WithFlightRecorder 保留的执行记录，最近的在最后。
+	Recent []Record `json:"recent"`
+}
+
+// Handler 返回对每个请求输出 g 当前 Snapshot 的 http.Handler。
+// key 以 fmt.Sprint 格式化。
+func Handler[K comparable, V any](g *singleflight.Group[K, V]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(Take(g))
+	})
+}
+
+// Take 返回 g 当前的 Snapshot。
+func Take[K comparable, V any](g *singleflight.Group[K, V]) Snapshot {
+	s := Snapshot{Name: g.Name(), Calls: []Call{}, Leaks: []Call{}, Recent: []Record{}}
+	g.Range(func(key K, info singleflight.CallInfo) bool {
+		s.Calls = append(s.Calls, call(key, info))
+		return true
+	})
+	for _, l := range g.Leaks() {
+		s.Leaks = append(s.Leaks, call(l.Key, l.Info))
+	}
+	for _, r := range g.Recent() {
+		rec := Record{
+			Key:       fmt.Sprint(r.Key),
+			Start:     r.Start,
+			End:       r.End,
+			Duration:  r.Duration,
+			Waiters:   r.Waiters,
+			Shared:    r.Shared,
+			Forgotten: r.Forgotten,
+		}
+		if r.Err != nil {
+			rec.Err = r.Err.Error()
+		}
+		s.Recent = append(s.Recent, rec)
+	}
+	return s
+}
+
+func call[K comparable](key K, info singleflight.CallInfo) Call {
+	c := Call{
+		Key:       fmt.Sprint(key),
+		Start:     info.Start,
+		Elapsed:   info.Elapsed,
+		Waiters:   info.Waiters,
+		Shared:    info.Shared,
+		Forgotten: info.Forgotten,
+	}
+	if info.Origin != nil {
+		c.Origin = info.Origin.String()
+	}
+	return c
+}
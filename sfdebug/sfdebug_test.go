@@ -0,0 +1,106 @@
+package sfdebug
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+// get 请求 h 并解码返回的 Snapshot。
+func get(t *testing.T, h http.Handler) Snapshot {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/singleflight", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	var s Snapshot
+	if err := json.Unmarshal(rec.Body.Bytes(), &s); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestHandler_Leaks(t *testing.T) {
+	clock := singleflight.NewFakeClock(time.Unix(0, 0))
+	joined := make(chan struct{}, 1)
+	g := singleflight.NewGroup[int, int](
+		singleflight.WithName("users"),
+		singleflight.WithClock(clock),
+		singleflight.WithLeakMonitor[int](time.Minute, nil),
+		singleflight.WithHooks(singleflight.Hooks[int]{FollowerJoined: func(int) { joined <- struct{}{} }}),
+	)
+	h := Handler(g)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	leader := g.DoChan(context.Background(), 7, func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 0, nil
+	})
+	<-started
+	follower := g.DoChan(context.Background(), 7, nil)
+	<-joined
+
+	s := get(t, h)
+	if s.Name != "users" || len(s.Calls) != 1 || len(s.Leaks) != 0 {
+		t.Fatalf("before age: %+v", s)
+	}
+	if c := s.Calls[0]; c.Key != "7" || c.Waiters != 1 || !c.Shared {
+		t.Fatalf("call = %+v", c)
+	}
+
+	clock.Advance(time.Minute)
+	s = get(t, h)
+	if len(s.Leaks) != 1 || s.Leaks[0].Key != "7" || s.Leaks[0].Waiters != 1 || s.Leaks[0].Elapsed != time.Minute {
+		t.Fatalf("after age: %+v", s)
+	}
+
+	close(release)
+	<-leader
+	<-follower
+	if s = get(t, h); len(s.Calls) != 0 || len(s.Leaks) != 0 {
+		t.Fatalf("after completion: %+v", s)
+	}
+}
+
+func TestHandler_Recent(t *testing.T) {
+	clock := singleflight.NewFakeClock(time.Unix(0, 0))
+	g := singleflight.NewGroup[string, int](
+		singleflight.WithClock(clock),
+		singleflight.WithFlightRecorder(2),
+	)
+	h := Handler(g)
+	if s := get(t, h); len(s.Recent) != 0 {
+		t.Fatalf("empty recorder: %+v", s.Recent)
+	}
+
+	errBoom := errors.New("boom")
+	for i, key := range []string{"a", "b", "c"} {
+		g.Do(context.Background(), key, func(context.Context) (int, error) {
+			clock.Advance(time.Duration(i+1) * time.Second)
+			if key == "c" {
+				return 0, errBoom
+			}
+			return 0, nil
+		})
+	}
+
+	s := get(t, h)
+	if len(s.Recent) != 2 {
+		t.Fatalf("Recent = %+v", s.Recent)
+	}
+	if r := s.Recent[0]; r.Key != "b" || r.Duration != 2*time.Second || r.Err != "" {
+		t.Fatalf("Recent[0] = %+v", r)
+	}
+	if r := s.Recent[1]; r.Key != "c" || r.Duration != 3*time.Second || r.Err != "boom" {
+		t.Fatalf("Recent[1] = %+v", r)
+	}
+}
@@ -0,0 +1,64 @@
+package sflru
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+
+	"github.com/oy3o/singleflight"
+)
+
+// Codec 在值与其存储形式之间转换，例如压缩大的二进制对象。
+// singleflight.JSONCodec 等内置实现同样可用。
+type Codec[V any] = singleflight.Codec[V]
+
+// NewEncoded 创建以 codec 编码保存条目的 Cache：写入时编码，Get 时解码。
+// 适用于缓存的值远大于其编码形式、内存主要消耗在缓存结果上的场景。
+//
+// maxBytes > 0 时除条目数外还按编码后的总大小淘汰，最近写入的条目总会被保留。
+// 每次 Get 都会解码，调用方得到的是独立的副本。
+func NewEncoded[K comparable, V any](size int, maxBytes int64, codec Codec[V], opts ...singleflight.Option) *Cache[K, V] {
+	c := New[K, V](size, opts...)
+	c.codec = codec
+	c.maxBytes = maxBytes
+	return c
+}
+
+// Bytes 返回所有条目编码后的总大小，未配置 Codec 时为 0。
+func (c *Cache[K, V]) Bytes() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.bytes
+}
+
+// Flate 以 DEFLATE 压缩 []byte 值，Level 为 compress/flate 的压缩级别，
+// 零值为 flate.DefaultCompression。
+type Flate struct {
+	Level int
+}
+
+// Marshal 压缩 v。
+func (f Flate) Marshal(v []byte) ([]byte, error) {
+	level := f.Level
+	if level == 0 {
+		level = flate.DefaultCompression
+	}
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(v); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	// 压缩结果长期驻留，去掉 Buffer 增长留下的余量。
+	return bytes.Clone(buf.Bytes()), nil
+}
+
+// Unmarshal 解压 data。
+func (Flate) Unmarshal(data []byte) ([]byte, error) {
+	return io.ReadAll(flate.NewReader(bytes.NewReader(data)))
+}
@@ -0,0 +1,75 @@
+package sflru
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+// Expiry 配置条目的过期时间与提前刷新，见 NewExpiring。
+type Expiry struct {
+	// TTL 为条目写入后的有效期。
+	TTL time.Duration
+
+	// Beta 为 XFetch 的提前系数，越大刷新越早。0 使用推荐值 1，
+	// 小于 0 表示不提前刷新，条目只在过期后重新加载。
+	Beta float64
+
+	// Jitter 把每个条目的 TTL 随机缩短至多 Jitter 比例（0 到 1），
+	// 使同时写入的条目分散过期。XFetch 只让每个 key 由单个调用者提前刷新，
+	// 成批写入的大量 key 仍会在同一时段一起刷新并同时打到后端，Jitter 把它们错开。
+	// 只缩短不延长，条目不会超过 TTL。
+	Jitter float64
+
+	// Clock 用于过期判断与记录加载耗时，nil 时使用 singleflight.SystemClock。
+	Clock singleflight.Clock
+}
+
+func (x *Expiry) now() time.Time {
+	if x.Clock != nil {
+		return x.Clock.Now()
+	}
+	return time.Now()
+}
+
+// ttl 返回一个新条目的有效期，已按 Jitter 随机化。
+func (x *Expiry) ttl() time.Duration {
+	if x.Jitter <= 0 {
+		return x.TTL
+	}
+	return x.TTL - time.Duration(rand.Float64()*min(x.Jitter, 1)*float64(x.TTL))
+}
+
+// NewExpiring 创建条目在 exp.TTL 后过期的 Cache。
+//
+// 同一时刻写入的热点条目会同时过期，即使有执行合并，过期瞬间的所有调用者
+// 仍要等待一次完整的加载。GetOrLoad 因此按 XFetch 算法
+// (Vattani et al., "Optimal Probabilistic Cache Stampede Prevention") 提前刷新：
+// 每次命中以随过期临近而增大的概率触发刷新，加载越慢越早开始，
+// 通常在过期前就由单个调用者完成了重新加载。触发刷新的调用者自己执行加载，
+// 已有刷新在进行时直接返回旧值，其余调用者不受影响。
+//
+// exp.TTL <= 0 时 panic。
+func NewExpiring[K comparable, V any](size int, exp Expiry, opts ...singleflight.Option) *Cache[K, V] {
+	if exp.TTL <= 0 {
+		panic("sflru: TTL must be positive")
+	}
+	if exp.Beta == 0 {
+		exp.Beta = 1
+	}
+	c := New[K, V](size, opts...)
+	c.exp = &exp
+	return c
+}
+
+// refreshEarly 按 XFetch 判断命中的条目 e 是否应由本次调用提前刷新：
+// now - delta*beta*ln(rand) >= expires，其中 rand 在 (0, 1] 上均匀分布。
+func (c *Cache[K, V]) refreshEarly(e *entry[K, V]) bool {
+	if c.exp == nil || c.exp.Beta < 0 || e.delta <= 0 {
+		return false
+	}
+	gap := -float64(e.delta) * c.exp.Beta * math.Log(1-rand.Float64())
+	return !c.exp.now().Add(time.Duration(gap)).Before(e.expires)
+}
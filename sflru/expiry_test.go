@@ -0,0 +1,106 @@
+package sflru
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+func TestExpiring(t *testing.T) {
+	clock := singleflight.NewFakeClock(time.Unix(0, 0))
+	c := NewExpiring[string, int](8, Expiry{TTL: 10 * time.Second, Beta: -1, Clock: clock})
+	ctx := context.Background()
+	loads := 0
+	loader := func(context.Context) (int, error) {
+		loads++
+		clock.Advance(time.Second)
+		return loads, nil
+	}
+
+	c.GetOrLoad(ctx, "k", loader)
+	clock.Advance(10*time.Second - time.Nanosecond)
+	if v, _ := c.GetOrLoad(ctx, "k", loader); v != 1 {
+		t.Fatalf("before expiry = %d, want 1 without early refresh", v)
+	}
+	clock.Advance(time.Nanosecond)
+	if _, ok := c.Get("k"); ok {
+		t.Fatal("expired entry was returned")
+	}
+	if v, _ := c.GetOrLoad(ctx, "k", loader); v != 2 {
+		t.Fatalf("after expiry = %d, want 2", v)
+	}
+}
+
+func TestExpiring_EarlyRefresh(t *testing.T) {
+	clock := singleflight.NewFakeClock(time.Unix(0, 0))
+	c := NewExpiring[string, int](8, Expiry{TTL: 10 * time.Second, Clock: clock})
+	ctx := context.Background()
+	loads := 0
+	refreshing := make(chan struct{})
+	release := make(chan struct{})
+	loader := func(context.Context) (int, error) {
+		loads++
+		if loads == 2 {
+			close(refreshing)
+			<-release
+		}
+		clock.Advance(time.Second)
+		return loads, nil
+	}
+
+	c.GetOrLoad(ctx, "k", loader)
+	// 距过期只剩 1ns 而加载耗时 1s，XFetch 几乎必然触发刷新。
+	clock.Advance(10*time.Second - time.Nanosecond)
+	res := make(chan int)
+	go func() {
+		v, _ := c.GetOrLoad(ctx, "k", loader)
+		res <- v
+	}()
+	<-refreshing
+	// 刷新进行中，其余调用者直接拿到尚未过期的旧值。
+	if v, _ := c.GetOrLoad(ctx, "k", loader); v != 1 {
+		t.Fatalf("during refresh = %d, want the old value", v)
+	}
+	close(release)
+	if v := <-res; v != 2 {
+		t.Fatalf("refresher = %d, want 2", v)
+	}
+	if v, ok := c.Get("k"); !ok || v != 2 {
+		t.Fatalf("after refresh = %d, %v", v, ok)
+	}
+}
+
+func TestExpiring_Jitter(t *testing.T) {
+	clock := singleflight.NewFakeClock(time.Unix(0, 0))
+	c := NewExpiring[int, int](100, Expiry{TTL: time.Minute, Beta: -1, Jitter: 0.5, Clock: clock})
+	ctx := context.Background()
+	for i := range 100 {
+		c.GetOrLoad(ctx, i, func(context.Context) (int, error) { return i, nil })
+	}
+
+	// 同时写入的条目都不早于 TTL 的一半过期、不晚于 TTL，且不在同一时刻过期。
+	clock.Advance(30*time.Second - time.Nanosecond)
+	for i := range 100 {
+		if _, ok := c.Get(i); !ok {
+			t.Fatalf("key %d expired before TTL*(1-Jitter)", i)
+		}
+	}
+	clock.Advance(15 * time.Second)
+	live := 0
+	for i := range 100 {
+		if _, ok := c.Get(i); ok {
+			live++
+		}
+	}
+	if live == 0 || live == 100 {
+		t.Fatalf("%d of 100 entries live halfway through the jitter window", live)
+	}
+	clock.Advance(15 * time.Second)
+	for i := range 100 {
+		if _, ok := c.Get(i); ok {
+			t.Fatalf("key %d outlived TTL", i)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+// Package sflru 把容量有限的 LRU 缓存与按 key 的执行合并放在同一个结构中。
+//
+// 分开使用缓存与 singleflight 时，"查缓存、未命中、加载" 之间存在缝隙：
+// 一次加载刚完成、结果尚未写入缓存时到达的调用者既看不到缓存也加入不了执行，
+// 于是再加载一次。Cache 在执行结束之前就写入缓存，消除了这个缝隙。
+package sflru
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+// Cache 是带执行合并的 LRU 缓存，并发安全。失败的加载不会被缓存。
+type Cache[K comparable, V any] struct {
+	group *singleflight.Group[K, V]
+
+	// codec 非 nil 时条目以编码后的字节保存，bytes 为其总大小，
+	// maxBytes > 0 时按总大小淘汰。见 NewEncoded。
+	codec    Codec[V]
+	maxBytes int64
+
+	// exp 非 nil 时条目会过期并被提前刷新，见 NewExpiring。
+	exp *Expiry
+
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[K]*list.Element
+	bytes int64
+	// gen 在每次 Remove / Purge 时递增，进行中的加载据此放弃写入已失效的结果。
+	gen uint64
+}
+
+type entry[K comparable, V any] struct {
+	key K
+	val V
+	enc []byte
+
+	// expires 与 delta 仅在配置了 Expiry 时使用：过期时刻与产生该值的加载耗时。
+	expires time.Time
+	delta   time.Duration
+}
+
+// New 创建至多保存 size 个条目的 Cache，opts 用于配置内部的 Group。
+// size <= 0 时 panic。
+func New[K comparable, V any](size int, opts ...singleflight.Option) *Cache[K, V] {
+	if size <= 0 {
+		panic("sflru: size must be positive")
+	}
+	return &Cache[K, V]{
+		group: singleflight.NewGroup[K, V](opts...),
+		size:  size,
+		ll:    list.New(),
+		items: make(map[K]*list.Element),
+	}
+}
+
+// Get 返回缓存的值并将其标记为最近使用。
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	v, _, ok := c.get(key)
+	return v, ok
+}
+
+// get 同时返回条目本身，调用方据此判断是否提前刷新。
+func (c *Cache[K, V]) get(key K) (V, *entry[K, V], bool) {
+	var zero V
+	c.mu.Lock()
+	el, ok := c.items[key]
+	if !ok {
+		c.mu.Unlock()
+		return zero, nil, false
+	}
+	e := el.Value.(*entry[K, V])
+	if c.exp != nil && !c.exp.now().Before(e.expires) {
+		c.removeLocked(el)
+		c.mu.Unlock()
+		return zero, nil, false
+	}
+	c.ll.MoveToFront(el)
+	c.mu.Unlock()
+	if c.codec == nil {
+		return e.val, e, true
+	}
+	// enc 写入后不再修改，解码放在锁外。无法解码的条目视为未命中并删除。
+	v, err := c.codec.Unmarshal(e.enc)
+	if err != nil {
+		c.mu.Lock()
+		if c.items[key] == el {
+			c.removeLocked(el)
+		}
+		c.mu.Unlock()
+		return zero, nil, false
+	}
+	return v, e, true
+}
+
+// Add 写入 key，容量已满时淘汰最久未使用的条目。
+// 配置了 Codec 时编码失败的值不会被写入。
+func (c *Cache[K, V]) Add(key K, val V) {
+	e, ok := c.encode(key, val, 0)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	c.addLocked(e)
+	c.mu.Unlock()
+}
+
+// encode 在锁外构造条目，编码大的值可能很慢。delta 为产生 val 的加载耗时。
+func (c *Cache[K, V]) encode(key K, val V, delta time.Duration) (*entry[K, V], bool) {
+	e := &entry[K, V]{key: key, val: val, delta: delta}
+	if c.exp != nil {
+		e.expires = c.exp.now().Add(c.exp.ttl())
+	}
+	if c.codec == nil {
+		return e, true
+	}
+	enc, err := c.codec.Marshal(val)
+	if err != nil {
+		return nil, false
+	}
+	var zero V
+	e.val, e.enc = zero, enc
+	return e, true
+}
+
+func (c *Cache[K, V]) addLocked(e *entry[K, V]) {
+	if el, ok := c.items[e.key]; ok {
+		c.bytes += int64(len(e.enc) - len(el.Value.(*entry[K, V]).enc))
+		el.Value = e
+		c.ll.MoveToFront(el)
+	} else {
+		c.items[e.key] = c.ll.PushFront(e)
+		c.bytes += int64(len(e.enc))
+	}
+	// 刚写入的条目即使单独超过 maxBytes 也保留，否则大值永远无法命中。
+	for c.ll.Len() > c.size || c.maxBytes > 0 && c.bytes > c.maxBytes && c.ll.Len() > 1 {
+		c.removeLocked(c.ll.Back())
+	}
+}
+
+func (c *Cache[K, V]) removeLocked(el *list.Element) {
+	e := el.Value.(*entry[K, V])
+	c.ll.Remove(el)
+	delete(c.items, e.key)
+	c.bytes -= int64(len(e.enc))
+}
+
+// Remove 删除 key，并让进行中的加载既不写入缓存、也不再被新的调用者加入。
+func (c *Cache[K, V]) Remove(key K) {
+	c.mu.Lock()
+	if el, ok := c.items[key]; ok {
+		c.removeLocked(el)
+	}
+	c.gen++
+	c.mu.Unlock()
+	c.group.Forget(key)
+}
+
+// Purge 清空缓存。
+func (c *Cache[K, V]) Purge() {
+	c.mu.Lock()
+	c.ll.Init()
+	clear(c.items)
+	c.bytes = 0
+	c.gen++
+	c.mu.Unlock()
+}
+
+// Len 返回缓存的条目数。
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// GetOrLoad 返回缓存的值；未命中时以 loader 加载，同一 key 的并发未命中只加载一次。
+// 成功的结果在加载结束、等待者被唤醒之前写入缓存。
+//
+// 配置了 Expiry 时，临近过期的命中可能由本调用提前刷新，见 NewExpiring。
+func (c *Cache[K, V]) GetOrLoad(ctx context.Context, key K, loader func(ctx context.Context) (V, error)) (V, error) {
+	if v, e, ok := c.get(key); ok {
+		if !c.refreshEarly(e) {
+			return v, nil
+		}
+		// 已有人在刷新或刷新失败时，未过期的旧值仍然可用，不必等待。
+		if nv, err := c.group.TryDo(ctx, key, c.load(key, loader, false)); err == nil {
+			return nv, nil
+		}
+		return v, nil
+	}
+	v, err, _ := c.group.Do(ctx, key, c.load(key, loader, true))
+	return v, err
+}
+
+// load 包装 loader，在结束前写入缓存。recheck 为 true 时先查一次缓存：
+// 上一次加载可能在调用者未命中之后、成为 Leader 之前刚写入。
+func (c *Cache[K, V]) load(key K, loader func(ctx context.Context) (V, error), recheck bool) func(ctx context.Context) (V, error) {
+	return func(ctx context.Context) (V, error) {
+		if recheck {
+			if v, ok := c.Get(key); ok {
+				return v, nil
+			}
+		}
+		c.mu.Lock()
+		gen := c.gen
+		c.mu.Unlock()
+
+		var start time.Time
+		if c.exp != nil {
+			start = c.exp.now()
+		}
+		v, err := loader(ctx)
+		if err != nil {
+			return v, err
+		}
+		var delta time.Duration
+		if c.exp != nil {
+			delta = c.exp.now().Sub(start)
+		}
+		if e, ok := c.encode(key, v, delta); ok {
+			c.mu.Lock()
+			if c.gen == gen {
+				c.addLocked(e)
+			}
+			c.mu.Unlock()
+		}
+		return v, nil
+	}
+}
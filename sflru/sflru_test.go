@@ -0,0 +1,94 @@
+package sflru
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestCache_LRU(t *testing.T) {
+	c := New[string, int](2)
+	c.Add("a", 1)
+	c.Add("b", 2)
+	c.Get("a")
+	c.Add("c", 3)
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("least recently used entry was not evicted")
+	}
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("a = %d, %v", v, ok)
+	}
+	if c.Len() != 2 {
+		t.Fatalf("Len = %d", c.Len())
+	}
+}
+
+func TestCache_GetOrLoad(t *testing.T) {
+	c := New[string, int](8)
+	ctx := context.Background()
+	loads := 0
+	loader := func(context.Context) (int, error) { loads++; return 7, nil }
+
+	for range 3 {
+		if v, err := c.GetOrLoad(ctx, "k", loader); v != 7 || err != nil {
+			t.Fatalf("GetOrLoad = %d, %v", v, err)
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("loads = %d, want 1", loads)
+	}
+
+	boom := errors.New("boom")
+	if _, err := c.GetOrLoad(ctx, "bad", func(context.Context) (int, error) { return 0, boom }); !errors.Is(err, boom) {
+		t.Fatalf("err = %v", err)
+	}
+	if _, ok := c.Get("bad"); ok {
+		t.Fatal("failed load was cached")
+	}
+}
+
+func TestCache_RemoveDuringLoad(t *testing.T) {
+	c := New[string, int](8)
+	ctx := context.Background()
+	// 加载期间 Remove 的 key 不应被过期的结果重新填充。
+	v, _ := c.GetOrLoad(ctx, "k", func(context.Context) (int, error) {
+		c.Remove("k")
+		return 1, nil
+	})
+	if v != 1 {
+		t.Fatalf("GetOrLoad = %d", v)
+	}
+	if _, ok := c.Get("k"); ok {
+		t.Fatal("removed key was repopulated by an in-flight load")
+	}
+}
+
+func TestCache_Encoded(t *testing.T) {
+	c := NewEncoded[string, []byte](8, 1<<10, Flate{})
+	blob := bytes.Repeat([]byte("moonlight "), 1000)
+	c.Add("a", blob)
+	if n := c.Bytes(); n <= 0 || n >= int64(len(blob)) {
+		t.Fatalf("Bytes = %d, want compressed size below %d", n, len(blob))
+	}
+	v, ok := c.Get("a")
+	if !ok || !bytes.Equal(v, blob) {
+		t.Fatalf("Get = %d bytes, %v", len(v), ok)
+	}
+
+	// 超出 maxBytes 时按最久未使用淘汰，最近写入的条目保留。
+	random := make([]byte, 2<<10)
+	rand.Read(random)
+	c.Add("b", random)
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("entry over the byte budget was not evicted")
+	}
+	if _, ok := c.Get("b"); !ok || c.Len() != 1 {
+		t.Fatalf("newest entry evicted, Len = %d", c.Len())
+	}
+	c.Remove("b")
+	if c.Bytes() != 0 {
+		t.Fatalf("Bytes after Remove = %d", c.Bytes())
+	}
+}
@@ -0,0 +1,105 @@
+package singleflight
+
+import (
+	"context"
+	"reflect"
+)
+
+// Clone 返回一个配置与 g 相同、但不共享任何执行、按 key 状态与并发额度的新 Group：
+// WithWeightedLimit 的额度、执行池、自适应超时的延迟统计与 WithLimiter 的令牌桶
+// 都重新分配，克隆上的负载不会占用 g 的容量或影响其统计。
+func (g *Group[K, V]) Clone() *Group[K, V] {
+	n := new(Group[K, V])
+	if g.cfg != nil {
+		n.cfg = newConfig[K, V](g.cfg.options)
+		if l := g.cfg.limiter; l != nil {
+			n.cfg.limiter = &KeyedLimiter[K]{Every: l.Every, Burst: l.Burst, Clock: l.Clock}
+		}
+	}
+	return n
+}
+
+// Divergence 描述一次影子执行与主执行结果不一致。
+type Divergence[K comparable, V any] struct {
+	Key        K
+	Primary    V
+	PrimaryErr error
+	Shadow     V
+	ShadowErr  error
+}
+
+// Shadow 把对 Primary 的调用镜像到以 Loader 执行的影子 Group 上并比较结果，
+// 用于在迁移前以真实流量验证新数据源。调用者拿到的永远是 Primary 的结果，
+// 影子执行在后台进行，不影响返回值与延迟。
+//
+// 影子 Group 由 Primary.Clone 得到，同样合并并发调用；
+// 每次 Primary 实际执行只比较一次，共享结果的 Follower 不重复上报。
+type Shadow[K comparable, V any] struct {
+	Primary *Group[K, V]
+
+	// Loader 为被验证的新加载函数。
+	Loader func(ctx context.Context, key K) (V, error)
+
+	// Equal 判断两次成功执行的结果是否一致，nil 时使用 reflect.DeepEqual。
+	// 两侧错误是否为 nil 不同即视为不一致，错误内容不参与比较。
+	Equal func(a, b V) bool
+
+	// OnDiverge 在结果不一致时于后台 goroutine 上调用，必须并发安全。
+	// Loader panic 时 ShadowErr 为 *PanicError。
+	OnDiverge func(Divergence[K, V])
+
+	shadow *Group[K, V]
+}
+
+// NewShadow 创建镜像 primary 的 Shadow。
+func NewShadow[K comparable, V any](
+	primary *Group[K, V],
+	loader func(ctx context.Context, key K) (V, error),
+	onDiverge func(Divergence[K, V]),
+) *Shadow[K, V] {
+	return &Shadow[K, V]{
+		Primary:   primary,
+		Loader:    loader,
+		OnDiverge: onDiverge,
+		shadow:    primary.Clone(),
+	}
+}
+
+// Do 语义同 Primary.Do。影子执行使用脱离取消的 ctx，调用者返回后仍会完成。
+func (s *Shadow[K, V]) Do(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) (V, error),
+) (v V, err error, shared bool) {
+	sh := s.shadow.DoChan(context.WithoutCancel(ctx), key, func(ctx context.Context) (v V, err error) {
+		// 影子流量绝不能拖垮主路径：Loader 的 panic 作为影子侧的错误上报。
+		defer func() {
+			if r := recover(); r != nil {
+				err = newPanicError(r, s.shadow.panicStack())
+			}
+		}()
+		return s.Loader(ctx, key)
+	})
+	r := s.Primary.DoResult(ctx, key, fn)
+	if r.Leader {
+		go s.compare(key, r, sh)
+	}
+	return r.Val, r.Err, r.Shared
+}
+
+func (s *Shadow[K, V]) compare(key K, p Result[V], sh <-chan Result[V]) {
+	r := <-sh
+	if (p.Err == nil) == (r.Err == nil) && (p.Err != nil || s.equal(p.Val, r.Val)) {
+		return
+	}
+	if s.OnDiverge != nil {
+		s.OnDiverge(Divergence[K, V]{Key: key, Primary: p.Val, PrimaryErr: p.Err, Shadow: r.Val, ShadowErr: r.Err})
+	}
+}
+
+func (s *Shadow[K, V]) equal(a, b V) bool {
+	if s.Equal != nil {
+		return s.Equal(a, b)
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,90 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestShadow_ReportsDivergence(t *testing.T) {
+	primary := NewGroup[string, string](WithMaxWaiters(4))
+	diverged := make(chan Divergence[string, string], 1)
+	s := NewShadow(primary, func(ctx context.Context, key string) (string, error) {
+		if key == "bad" {
+			return "new-" + key, nil
+		}
+		return "v-" + key, nil
+	}, func(d Divergence[string, string]) { diverged <- d })
+
+	if s.shadow.cfg.maxWaiters != 4 {
+		t.Fatal("shadow group did not inherit primary configuration")
+	}
+
+	for _, key := range []string{"ok", "bad"} {
+		v, err, _ := s.Do(context.Background(), key, func(context.Context) (string, error) { return "v-" + key, nil })
+		if v != "v-"+key || err != nil {
+			t.Fatalf("%s: caller got %q, %v; shadow must not affect results", key, v, err)
+		}
+	}
+
+	d := <-diverged
+	if d.Key != "bad" || d.Primary != "v-bad" || d.Shadow != "new-bad" {
+		t.Fatalf("divergence = %+v", d)
+	}
+	select {
+	case d := <-diverged:
+		t.Fatalf("unexpected divergence %+v", d)
+	default:
+	}
+
+	boom := errors.New("boom")
+	s.Do(context.Background(), "err", func(context.Context) (string, error) { return "", boom })
+	if d := <-diverged; d.PrimaryErr != boom || d.ShadowErr != nil {
+		t.Fatalf("error divergence = %+v", d)
+	}
+}
+
+func TestShadow_LoaderPanicIsReported(t *testing.T) {
+	diverged := make(chan Divergence[string, string], 1)
+	s := NewShadow(NewGroup[string, string](), func(context.Context, string) (string, error) {
+		panic("shadow bug")
+	}, func(d Divergence[string, string]) { diverged <- d })
+
+	v, err, _ := s.Do(context.Background(), "k", func(context.Context) (string, error) { return "v", nil })
+	if v != "v" || err != nil {
+		t.Fatalf("caller got %q, %v", v, err)
+	}
+	d := <-diverged
+	var pe *PanicError
+	if !errors.As(d.ShadowErr, &pe) || pe.Value() != "shadow bug" {
+		t.Fatalf("ShadowErr = %v, want *PanicError", d.ShadowErr)
+	}
+}
+
+func TestClone_DoesNotShareState(t *testing.T) {
+	l := &KeyedLimiter[string]{Every: time.Hour}
+	g := NewGroup[string, int](
+		WithWeightedLimit[string](1, nil),
+		WithLimiter(l),
+		WithAdaptiveTimeout(AdaptiveTimeout[string]{Min: time.Second, Max: time.Minute}),
+		WithWorkers(1),
+	)
+	c := g.Clone()
+	if c.cfg.sem == g.cfg.sem || c.cfg.limiter == g.cfg.limiter || c.cfg.adaptive == g.cfg.adaptive || c.cfg.pool == g.cfg.pool {
+		t.Fatal("clone shares state with the original group")
+	}
+
+	// 原 Group 的额度与令牌被占满时克隆照常执行。
+	release := make(chan struct{})
+	busy := g.DoChan(context.Background(), "k", func(context.Context) (int, error) {
+		<-release
+		return 0, nil
+	})
+	defer func() { close(release); <-busy }()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if v, err, _ := c.Do(ctx, "k", func(context.Context) (int, error) { return 1, nil }); v != 1 || err != nil {
+		t.Fatalf("clone Do = %v, %v", v, err)
+	}
+}
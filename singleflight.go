@@ -2,9 +2,12 @@ package singleflight
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"runtime/debug"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Group 是 singleflight 的泛型实现，支持零值初始化。
@@ -16,6 +19,47 @@ type Group[K comparable, V any] struct {
 	mu    sync.Mutex
 	calls map[K]*call[V]
 	pool  sync.Pool
+
+	// states 保存按 key 的策略状态（熔断、限频等），仅在配置了此类策略时分配。
+	// statesSwept 为上次清理后剩余的数量，见 sweepLocked。
+	states      map[K]*keyState[V]
+	statesSwept int
+
+	// parked 保存无人接收的后台执行结果，见 DoDetachedWait。
+	parked      map[K]parkedResult[V]
+	parkedSwept int
+
+	// seq 为 WithSerial 分配的最近一个执行序号。
+	seq uint64
+
+	// closed 由 Close 设置，之后的调用直接返回 ErrGroupClosed。
+	closed bool
+
+	// lasts 保存 WithLastValues 保留的结果，首次记录时分配。
+	lasts *lastValues[K, V]
+	// failures 保存 WithLastErrors 保留的失败，首次记录时分配。
+	failures *lastErrors[K]
+	// recorder 为 WithFlightRecorder 的环形缓冲区，首次记录时分配。
+	recorder *flightRecorder[K]
+
+	// stats 为累计统计的计数器，不受 mu 保护，见 Stats。completed 记录各 key
+	// 最近的完成时间，仅在配置了 WithNearMissWindow 时分配，清理方式与 states 相同。
+	stats          atomic.Pointer[counters]
+	completed      map[K]time.Time
+	completedSwept int
+
+	// orphans 保存已被 Forget 但仍在运行的执行，供 Inspect / Range 观察。
+	orphans map[K][]*call[V]
+
+	// running 为正在进行的执行数，包括已被 Forget 的；quiet 在其归零时关闭，
+	// paused 与 resumed 实现 Pause。见 Drain。
+	running int
+	quiet   chan struct{}
+	paused  int
+	resumed chan struct{}
+
+	// cfg 为 NewGroup 的可选配置，零值 Group 为 nil。
+	cfg *config[K, V]
 }
 
 type call[V any] struct {
@@ -24,121 +68,571 @@ type call[V any] struct {
 	val V
 	err error
 
-	panicErr *panicError
+	panicErr *PanicError
 
 	// done 仅在有可取消 context 的 Follower 加入时才分配（懒初始化）。
 	// Leader 独占或仅有 Background context 时保持 nil，避免 channel 分配（~96 bytes）。
 	done chan struct{}
 
-	dups int
+	// state 交接 done 的关闭，见 publish。
+	state atomic.Uint32
+
+	// dups 的写入都在锁内；Leader 发布结果时可能不持锁读取，因此为原子类型。
+	dups atomic.Int32
 
-	// shared 在持锁期间由 doCall 设置，
-	// 避免 Leader 返回时无锁读 dups 导致的 data race。
-	shared bool
+	// start 为 Leader 开始执行的时刻；end、dur 与 waiters 在唤醒等待者之前写入，
+	// Follower 被唤醒后读取，不需要额外同步。
+	start   time.Time
+	end     time.Time
+	dur     time.Duration
+	waiters int
 
 	forgotten bool
+	// superseded 表示 c 因 WithMinFreshness 被新的执行取代，
+	// 不是被 Forget，已加入的 Follower 照常拿到它的结果。
+	superseded bool
+
+	// job 为交给 WithWorkers 执行池的任务，仅在配置了执行池时非 nil。
+	job *poolJob
+
+	// park 为放弃等待的 DoDetachedWait 调用者要求的结果保留时长，由 g.mu 保护。
+	park time.Duration
+
+	// handoff 表示 Leader 因自身调用者取消而失败，Follower 应接手重新执行，
+	// 见 WithHandoff 与 WithCancelHandoff。fn 为 Leader 的 fn，供未提供 fn 的 Follower 接手时使用。
+	handoff bool
+	fn      func(ctx context.Context) (V, error)
+
+	// forgot 仅在配置了 ForgetSignal / ForgetRetry 且有 Follower 加入时分配，
+	// Forget 关闭它以立即通知等待者。
+	forgot chan struct{}
+
+	// wedged 仅在配置了 WithWatchdog 且有 Follower 加入时分配，看门狗关闭它以
+	// 让等待者收到 ErrLeaderStuck；finished 在完成时于锁内设置，看门狗据此判断是否已迟到。
+	wedged   chan struct{}
+	finished bool
+
+	// leaked 由 WithLeakMonitor 的定时器在锁内设置。
+	leaked bool
+
+	// origin 为 WithLeaderOrigin 记录的发起方，登记后不再修改。
+	origin *Origin
+
+	// seq 为 WithSerial 下的登记序号；next 在有调用排在 c 之后时分配，c 完成时关闭。
+	seq  uint64
+	next chan struct{}
+
+	// dbg 仅在 singleflightdebug 构建标签下记录状态，用于不变量检查。
+	dbg debugCall
 }
 
 // Do 对同一个 key 只允许一个 fn 在执行（Leader），
 // 后续调用者（Follower）阻塞等待并共享结果。
 //
 // shared 表示结果是否被多个调用者共享。
+// 调用者自身 context 结束导致的错误满足 errors.Is(err, ErrWaiterCancelled)。
 func (g *Group[K, V]) Do(
 	ctx context.Context,
 	key K,
 	fn func(ctx context.Context) (V, error),
 ) (v V, err error, shared bool) {
+	v, err, f := g.do(ctx, key, fn, nil)
+	return v, err, f.shared
+}
 
+// callOpts 是单次调用的内部选项，由 DoResult 等变体设置；Do 传 nil。
+type callOpts[V any] struct {
+	// timed 要求记录执行耗时，即使 Group 未开启 WithTiming。
+	timed bool
+	// trailing 表示这是预约的后台执行，不再参与防抖。
+	trailing bool
+	// detach 让 Leader 的 fn 在独立 goroutine 上以脱离取消的 ctx 执行，
+	// 发起者像 Follower 一样等待，自身 ctx 结束不会中断执行。
+	detach bool
+	// hasDef 表示等待被取消时返回 key 最近的结果或 def，而不是错误。
+	hasDef bool
+	def    V
+	// park 表示放弃等待时，执行的成功结果应保留多久供之后的调用者直接取用。
+	park time.Duration
+	// queued 表示本调用已排队等过一次执行，只加入序号大于 after 的执行，见 WithSerial。
+	queued bool
+	after  uint64
+	// hasMaxAge 表示本调用只接受产生于 maxAge 之内的已完成结果，见 DoMaxAge。
+	hasMaxAge bool
+	maxAge    time.Duration
+}
+
+// flight 是一次调用观察到的执行元信息，供 DoResult 等变体使用。
+type flight struct {
+	shared  bool
+	leader  bool
+	waiters int
+	dur     time.Duration
+
+	// lockWait 与 wakeDelay 仅在开启 WithLatencyBreakdown 时记录。
+	lockWait  time.Duration
+	wakeDelay time.Duration
+}
+
+func (g *Group[K, V]) do(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) (V, error),
+	co *callOpts[V],
+) (V, error, flight) {
+	key = g.canonical(key)
+	v, err, f := g.doCanonical(ctx, key, fn, co)
+	if err != nil && g.cfg != nil && g.cfg.keyedErrors {
+		err = g.keyedError(key, err)
+	}
+	return v, err, f
+}
+
+// doCanonical 是 do 在 key 规范化之后的部分。
+func (g *Group[K, V]) doCanonical(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) (V, error),
+	co *callOpts[V],
+) (V, error, flight) {
 	// 已取消的 context 不值得进入临界区。
-	if err := ctx.Err(); err != nil {
-		var zero V
-		return zero, err, false
+	if ctx.Err() != nil {
+		return g.cancelled(ctx, key, co, flight{})
 	}
 
+	if g.cfg != nil && g.cfg.latency {
+		entered := g.now()
+		g.mu.Lock()
+		lockWait := g.now().Sub(entered)
+		v, err, f := g.doLocked(ctx, key, fn, co)
+		f.lockWait = lockWait
+		return v, err, f
+	}
 	g.mu.Lock()
+	return g.doLocked(ctx, key, fn, co)
+}
+
+// doLocked 是 do 取得 g.mu 之后的部分，返回前释放。
+func (g *Group[K, V]) doLocked(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) (V, error),
+	co *callOpts[V],
+) (V, error, flight) {
+	if g.closed {
+		g.mu.Unlock()
+		var zero V
+		return zero, ErrGroupClosed, flight{}
+	}
+
+	minFresh, hasMinFresh := MinFreshnessFrom(ctx)
+
+	if len(g.parked) > 0 && !hasMinFresh {
+		if v, ok := g.parkedLocked(key, co); ok {
+			g.mu.Unlock()
+			return v, nil, flight{shared: true}
+		}
+	}
 
 	// Follower 路径
 	if c, ok := g.calls[key]; ok {
-		c.dups++
+		if !g.joinableLocked(c, co) {
+			return g.queueLocked(ctx, key, c, fn, co)
+		}
+		if !hasMinFresh || !c.start.IsZero() && !c.start.Before(minFresh) {
+			return g.wait(ctx, key, c, fn, co)
+		}
+		g.supersedeLocked(key, c)
+	}
 
-		// context.Background() 的 Done() 返回 nil，
-		// 此时无须支持 context 取消，直接使用 WaitGroup 等待，完全避免 channel 分配。
-		if doneCh := ctx.Done(); doneCh == nil {
+	if g.paused > 0 {
+		return g.awaitResume(ctx, key, fn, co)
+	}
+
+	if g.cfg != nil && g.cfg.perKey {
+		if v, err, reused, handled := g.admitLocked(ctx, key, fn, co); handled {
 			g.mu.Unlock()
-			c.wg.Wait()
-		} else {
-			if c.done == nil {
-				c.done = make(chan struct{})
-			}
-			done := c.done
+			return v, err, flight{shared: reused}
+		}
+	}
+	if hasMinFresh {
+		co = timedCall(co)
+	}
+
+	return g.lead(ctx, key, fn, co)
+}
+
+// TryDo 与 Do 相同，但 key 已有执行在进行时不等待，直接返回 ErrInFlight。
+func (g *Group[K, V]) TryDo(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) (V, error),
+) (V, error) {
+	key = g.canonical(key)
+	v, err := g.tryDo(ctx, key, fn)
+	if err != nil && g.cfg != nil && g.cfg.keyedErrors {
+		err = g.keyedError(key, err)
+	}
+	return v, err
+}
+
+func (g *Group[K, V]) tryDo(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) (V, error),
+) (V, error) {
+	if err := ctx.Err(); err != nil {
+		var zero V
+		return zero, waitError(ctx)
+	}
+
+	g.mu.Lock()
+
+	if g.closed {
+		g.mu.Unlock()
+		var zero V
+		return zero, ErrGroupClosed
+	}
+	if _, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		var zero V
+		return zero, ErrInFlight
+	}
+	if g.paused > 0 {
+		resumed := g.resumed
+		g.mu.Unlock()
+		select {
+		case <-resumed:
+			return g.tryDo(ctx, key, fn)
+		case <-ctx.Done():
+			var zero V
+			return zero, waitError(ctx)
+		}
+	}
+	if g.cfg != nil && g.cfg.perKey {
+		if v, err, _, handled := g.admitLocked(ctx, key, fn, nil); handled {
 			g.mu.Unlock()
+			return v, err
+		}
+	}
+
+	v, err, _ := g.lead(ctx, key, fn, nil)
+	return v, err
+}
+
+// wait 以 Follower 身份等待 c 完成。调用时必须持有 g.mu，返回前释放。
+func (g *Group[K, V]) wait(
+	ctx context.Context,
+	key K,
+	c *call[V],
+	fn func(ctx context.Context) (V, error),
+	co *callOpts[V],
+) (V, error, flight) {
+	if g.cfg != nil && g.cfg.maxWaiters > 0 && int(c.dups.Load()) >= g.cfg.maxWaiters {
+		g.mu.Unlock()
+		var zero V
+		return zero, ErrTooManyWaiters, flight{}
+	}
+
+	c.dups.Add(1)
+	c.debugJoin(key)
+	// 排队中的执行继承等待者中的最高优先级，避免交互请求被批量预热的 Leader 拖住。
+	if c.job != nil {
+		g.cfg.pool.raise(c.job, PriorityFrom(ctx))
+	}
+
+	policy := ForgetShare
+	if g.cfg != nil {
+		policy = g.cfg.forgetPolicy
+	}
 
-			select {
-			case <-done:
-			case <-doneCh:
-				// Follower 提前退出，必须递减 dups，
-				// 否则 Leader 的 shared 判断和 pool 回收逻辑都会出错。
-				g.mu.Lock()
-				c.dups--
-				g.mu.Unlock()
-				var zero V
-				return zero, ctx.Err(), true
+	// context.Background() 的 Done() 返回 nil，
+	// 此时无须支持 context 取消，直接使用 WaitGroup 等待，完全避免 channel 分配。
+	watched := g.cfg != nil && g.cfg.watchdog > 0
+	if doneCh := ctx.Done(); doneCh == nil && policy == ForgetShare && !watched {
+		g.mu.Unlock()
+		g.hookFollowerJoined(key)
+		c.wg.Wait()
+	} else {
+		if c.done == nil {
+			c.done = make(chan struct{})
+		}
+		done := c.done
+		// 结果已提前发布时 Leader 不会再关闭 done。
+		if c.state.Or(callWaiting)&callPublished != 0 {
+			done = closedChan
+		}
+		var forgot chan struct{}
+		if policy != ForgetShare {
+			if c.forgot == nil {
+				c.forgot = make(chan struct{})
+			}
+			forgot = c.forgot
+		}
+		var wedged chan struct{}
+		if watched {
+			if c.wedged == nil {
+				c.wedged = make(chan struct{})
 			}
+			wedged = c.wedged
 		}
+		g.mu.Unlock()
+		g.hookFollowerJoined(key)
+
+		select {
+		case <-done:
+		case <-doneCh:
+			// 取消与结果几乎同时到达时 select 随机选择，
+			// 非阻塞地再检查一次 done，让已完成的结果胜出而不是被白白丢弃。
+			if !isClosed(done) {
+				if !g.awaitLeader(done) {
+					// Follower 提前退出，必须递减 dups，
+					// 否则 Leader 的 shared 判断和 pool 回收逻辑都会出错。
+					g.leave(key, c, co)
+					return g.cancelled(ctx, key, co, flight{shared: true})
+				}
+				return g.cancelledAfterLeader(ctx, key, c, co)
+			}
+		case <-forgot:
+			g.leave(key, c, nil)
+			return g.afterForget(ctx, key, fn, co, policy)
+		case <-wedged:
+			g.leave(key, c, nil)
+			var zero V
+			return zero, ErrLeaderStuck, flight{shared: true}
+		}
+	}
 
-		// panic 必须传播给每个 Follower，保持与标准库一致的语义。
-		if c.panicErr != nil {
-			panic(c.panicErr)
+	// done 与 forgot 同时就绪时 select 可能选中 done，
+	// forgotten 在完成前已于锁内设置，此处无锁读取是安全的。
+	if policy != ForgetShare && c.forgotten && !c.superseded {
+		return g.afterForget(ctx, key, fn, co, policy)
+	}
+
+	if c.handoff {
+		return g.takeOver(ctx, key, c, fn, co)
+	}
+
+	f := flight{shared: true, waiters: c.waiters, dur: c.dur}
+	if g.cfg != nil && g.cfg.latency {
+		f.wakeDelay = g.now().Sub(c.end)
+	}
+
+	// panic 默认传播给每个 Follower，保持与标准库一致的语义。
+	if c.panicErr != nil {
+		if g.cfg != nil && g.cfg.panicPolicy == PanicLeaderOnly {
+			var zero V
+			return zero, c.panicErr, f
 		}
-		return c.val, c.err, true
+		panic(c.panicErr)
 	}
+	return c.val, c.err, f
+}
 
-	//  Leader 路径
+// isClosed 非阻塞地判断 ch 是否已关闭。
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
 
-	// 支持零值初始化：首次使用时分配 map。
-	if g.calls == nil {
-		g.calls = make(map[K]*call[V])
+// call.state 的标志位。
+const (
+	// callWaiting 表示 done 已分配且有人在等它，由分配者在锁内设置。
+	callWaiting uint32 = 1 << iota
+	// callPublished 表示结果已写入，由 Leader 在 publish 中设置。
+	callPublished
+)
+
+// closedChan 供结果已发布后才登记的等待者直接使用。
+var closedChan = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+// publish 唤醒等待 c 的调用者，调用前必须已写入结果。不要求持有 g.mu：
+// 分配 done 的一方先置 callWaiting，Leader 再置 callPublished，
+// 两个原子操作的先后决定 done 由 Leader 关闭还是由等待者视为已关闭。
+func (c *call[V]) publish(key any) {
+	if c.state.Or(callPublished)&callWaiting != 0 {
+		c.debugClose(key)
+		close(c.done)
 	}
+	c.wg.Done()
+}
 
-	// 从 pool 复用 call 对象。不设置 pool.New，
-	// 因为 Get 返回 nil 时直接 new 比闭包更轻。
-	c, _ := g.pool.Get().(*call[V])
-	if c == nil {
-		c = new(call[V])
+// wake 在 key 移除之后唤醒等待 c 的调用者，done 为锁内取出的 c.done。
+// 此时分配 done 的等待者都已在锁内登记，不会与 Leader 竞争，无需 publish 的原子标志。
+func (c *call[V]) wake(key any, done chan struct{}) {
+	if done != nil {
+		c.debugClose(key)
+		close(done)
 	}
-	c.wg.Add(1)
-	c.dups = 0
-	c.forgotten = false
-	c.panicErr = nil
-	c.shared = false
-	// c.done 在回收前已被置为 nil，无需重置。
+	c.wg.Done()
+}
 
-	g.calls[key] = c
+// earlyWake 报告 Leader 能否在重新获取 g.mu 之前发布结果。
+// 被唤醒的 Follower 只读取发布前写入的字段才能提前发布：
+// ForgetShare 以外的策略要读 forgotten，看门狗要在锁内判断 finished，
+// BeforeWake 则约定 key 移除之后才唤醒。
+func (g *Group[K, V]) earlyWake() bool {
+	return g.cfg == nil ||
+		g.cfg.forgetPolicy == ForgetShare && g.cfg.watchdog == 0 && g.cfg.hooks.BeforeWake == nil
+}
+
+// leave 撤销一个提前退出的 Follower 的登记。
+func (g *Group[K, V]) leave(key K, c *call[V], co *callOpts[V]) {
+	g.mu.Lock()
+	c.dups.Add(-1)
+	c.debugLeave(key)
+	if co != nil && co.park > c.park {
+		c.park = co.park
+	}
+	g.mu.Unlock()
+}
+
+// afterForget 处理等待期间 key 被 Forget 的 Follower。
+func (g *Group[K, V]) afterForget(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) (V, error),
+	co *callOpts[V],
+	policy ForgetPolicy,
+) (V, error, flight) {
+	if policy == ForgetRetry {
+		return g.do(ctx, key, fn, co)
+	}
+	var zero V
+	return zero, ErrForgotten, flight{shared: true}
+}
+
+// lead 以 Leader 身份登记并执行 fn。调用时必须持有 g.mu，返回前释放。
+func (g *Group[K, V]) lead(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) (V, error),
+	co *callOpts[V],
+) (V, error, flight) {
+	c := g.installLocked(ctx, key, fn, co)
+
+	// 异步执行时 Leader 也要等待，done 必须在登记前分配并在锁内取出。
+	pooled := g.cfg != nil && g.cfg.pool != nil
+	async := pooled || co != nil && co.detach || g.asyncLeader()
+	var done chan struct{}
+	if pooled {
+		c.job = &poolJob{prio: PriorityFrom(ctx), index: -1}
+	}
+	if async && ctx.Done() != nil {
+		c.done = make(chan struct{})
+		c.state.Store(callWaiting)
+		done = c.done
+	}
 	g.mu.Unlock()
+	g.hookLeaderInstalled(key)
 
-	g.doCall(c, key, fn, ctx)
+	if async {
+		return g.leadAsync(ctx, key, c, fn, co, done)
+	}
+
+	shared, recycle := g.doCall(c, key, fn, ctx)
 
 	val := c.val
-	err = c.err
-	shared = c.shared
+	err := c.err
+	f := flight{shared: shared, leader: true, waiters: c.waiters, dur: c.dur}
 	panicked := c.panicErr != nil
 
 	// 仅当无 Follower 且无 panic 时回收。
 	// 有 Follower 意味着 done channel 已分配且 Follower 可能仍在读 c.val，
 	// 此时回收会导致 use-after-free。
-	// 使用 !shared 避免对 c.dups 的内存重读。
-	if !panicked && !shared && c.done == nil {
-		var zero V
-		c.val = zero
-		c.err = nil
-		g.pool.Put(c)
+	// recycle 由 doCall 在锁内快照，避免无锁重读 c.dups / c.done。
+	if !panicked && recycle {
+		g.recycle(key, c)
 	}
 
 	if panicked {
 		panic(c.panicErr)
 	}
 
-	return val, err, shared
+	return val, err, f
+}
+
+// installLocked 为 key 登记一个新的执行并返回它，调用时必须持有 g.mu。
+func (g *Group[K, V]) installLocked(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) (V, error),
+	co *callOpts[V],
+) *call[V] {
+	// 支持零值初始化：首次使用时分配 map。
+	if g.calls == nil {
+		g.calls = make(map[K]*call[V])
+	}
+
+	// 从 pool 复用 call 对象。不设置 pool.New，
+	// 因为 Get 返回 nil 时直接 new 比闭包更轻。
+	c, _ := g.pool.Get().(*call[V])
+	if c == nil {
+		c = new(call[V])
+	}
+	c.debugReuse(key)
+	c.wg.Add(1)
+	// 回收的 call 多数从未被等待过，只在需要时写入，避免原子写的开销。
+	if c.dups.Load() != 0 {
+		c.dups.Store(0)
+	}
+	if c.state.Load() != 0 {
+		c.state.Store(0)
+	}
+	// 读时钟在部分虚拟化环境中代价可观，默认快路径不计时。
+	c.start = time.Time{}
+	c.dur = 0
+	if co != nil && co.timed || g.cfg != nil && g.cfg.timing {
+		c.start = g.now()
+	}
+	if g.cfg != nil && g.cfg.nearMiss > 0 {
+		now := c.start
+		if now.IsZero() {
+			now = g.now()
+		}
+		g.nearMissLocked(key, now)
+	}
+	c.forgotten = false
+	c.superseded = false
+	c.finished = false
+	c.leaked = false
+	c.panicErr = nil
+	c.park = 0
+	c.handoff = false
+	c.origin = nil
+	if g.cfg != nil && g.cfg.serial {
+		g.nextLocked(c)
+	}
+	if g.cfg != nil && g.cfg.origin > 0 {
+		c.origin = captureOrigin(g.cfg.origin)
+	}
+	if g.cfg != nil && (g.cfg.handoff || g.cfg.cancelHandoff || g.cfg.sem != nil) {
+		c.fn = fn
+	}
+	// c.done 在回收前已被置为 nil，无需重置。
+	c.job = nil
+
+	g.calls[key] = c
+	g.running++
+	return c
+}
+
+// recycle 把已完成且无人引用的 c 放回 pool。
+func (g *Group[K, V]) recycle(key K, c *call[V]) {
+	var zero V
+	c.val = zero
+	c.err = nil
+	c.fn = nil
+	c.debugRecycle(key)
+	g.pool.Put(c)
 }
 
 func (g *Group[K, V]) doCall(
@@ -146,59 +640,248 @@ func (g *Group[K, V]) doCall(
 	key K,
 	fn func(context.Context) (V, error),
 	ctx context.Context,
-) {
+) (shared, recycle bool) {
+	callerCtx := ctx
+	var watchdog, monitor Timer
 	defer func() {
 		if r := recover(); r != nil {
-			c.panicErr = &panicError{value: r, stack: debug.Stack()}
+			c.panicErr = newPanicError(r, g.panicStack())
+		}
+		// 看门狗或泄漏检测已触发时其回调可能仍持有 c，不能回收。
+		wedged := watchdog != nil && !watchdog.Stop()
+		if monitor != nil && !monitor.Stop() {
+			wedged = true
+		}
+		// 读时钟放在锁外，不拉长临界区。
+		var end time.Time
+		if !c.start.IsZero() || g.cfg != nil && (g.cfg.lastValues > 0 || g.cfg.lastErrors > 0 || g.cfg.nearMiss > 0) {
+			end = g.now()
+		}
+		if !c.start.IsZero() {
+			c.dur = end.Sub(c.start)
+		}
+		c.end = end
+
+		if g.cfg != nil && (g.cfg.handoff || g.cfg.cancelHandoff) && !c.handoff {
+			c.handoff = g.handsOff(c, callerCtx)
+		}
+
+		// 可以提前发布时，等待者不必再等 Leader 抢回锁并完成清理。
+		// 无人等待的执行照常在锁内移除 key 后唤醒，省去发布用的原子操作。
+		early := g.earlyWake() && (c.dups.Load() > 0 || c.state.Load()&callWaiting != 0)
+		if early {
+			c.waiters = int(c.dups.Load())
+			c.publish(key)
 		}
 
 		g.mu.Lock()
+		c.finished = true
+		if g.cfg != nil && g.cfg.nearMiss > 0 {
+			g.completedLocked(key, end)
+		}
+		if g.running--; g.running == 0 && g.quiet != nil {
+			close(g.quiet)
+			g.quiet = nil
+		}
 		if !c.forgotten {
 			delete(g.calls, key)
+		} else {
+			g.dropOrphanLocked(key, c)
 		}
-		// 在锁内捕获 shared 状态，
-		// 防止 Leader 返回路径无锁读 dups 产生 data race。
-		c.shared = c.dups > 0
+		c.releaseQueueLocked()
+		if g.cfg != nil && g.cfg.perKey {
+			g.settleLocked(key, c)
+		}
+		if park := g.holdFor(c); park > 0 && c.panicErr == nil && c.err == nil {
+			g.parkLocked(key, c.val, park)
+		}
+		if g.cfg != nil && g.cfg.lastValues > 0 && c.panicErr == nil && c.err == nil {
+			g.rememberLocked(key, c.val, end)
+		}
+		if g.cfg != nil && g.cfg.lastErrors > 0 {
+			if c.panicErr != nil {
+				g.recordOutcomeLocked(key, c.panicErr, end)
+			} else {
+				g.recordOutcomeLocked(key, c.err, end)
+			}
+		}
+		// 在锁内捕获 shared 与可回收状态，
+		// 防止 Leader 返回路径无锁读 dups / done 与提前退出的 Follower 产生 data race。
+		// 此后 key 已不在 map 中，不会再有新的 Follower 加入。
+		waiters := int(c.dups.Load())
+		shared = waiters > 0
+		if g.cfg != nil && g.cfg.recorder > 0 {
+			g.recordLocked(key, c, waiters)
+		}
+		if !early {
+			c.waiters = waiters
+		}
+		recycle = !shared && c.done == nil && !wedged
 		done := c.done
+		sample := g.cfg != nil && g.cfg.adaptive != nil && adaptiveSample(c.panicErr != nil, c.err)
+		dur := c.dur
 		g.mu.Unlock()
 
 		// 唤醒大量 Follower 会触发调度器，必须放在锁外。
-		if done != nil {
-			close(done)
+		if !early {
+			g.hookBeforeWake(key)
+			c.wake(key, done)
+		}
+
+		// 统计不影响结果，放在唤醒等待者之后。
+		if g.cfg != nil && g.cfg.stats {
+			sh := g.counters().shard()
+			sh.executions.Add(1)
+			sh.fanIn[FanInBucket(waiters)].Add(1)
+		}
+		if sample {
+			g.cfg.adaptive.record(key, dur)
 		}
-		c.wg.Done()
 	}()
 
-	c.val, c.err = fn(ctx)
+	if g.cfg != nil {
+		// 泄漏检测包括排队等待并发额度的时间，卡在额度上的执行同样会被发现。
+		if g.cfg.leakAge > 0 {
+			monitor = g.monitor(key, c)
+		}
+		// 先取得并发额度再开始计算执行超时，排队时间不计入执行时长。
+		if g.cfg.sem != nil {
+			release, err := g.acquire(ctx, key)
+			if err != nil {
+				// 排队等待的是 Leader 自己，它的放弃不能作为执行结果交给
+				// 仍在等待的 Follower：由其中一个以自己的 ctx 接手。
+				c.err = err
+				c.handoff = true
+				return
+			}
+			defer release()
+		}
+		if timeout := g.execTimeout(key); timeout > 0 {
+			var cancel context.CancelFunc
+			if g.cfg.maxExtension > 0 {
+				ctx, cancel = g.withExtendableTimeout(ctx, timeout)
+			} else {
+				ctx, cancel = context.WithTimeoutCause(ctx, timeout, ErrExecTimeout)
+			}
+			defer cancel()
+		}
+		if g.cfg.watchdog > 0 {
+			var cancel context.CancelCauseFunc
+			ctx, cancel, watchdog = g.watch(ctx, key, c)
+			defer cancel(nil)
+		}
+		if g.cfg.chaos != nil {
+			if err := g.cfg.chaos.inject(ctx, clockOrSystem(g.cfg.clock)); err != nil {
+				c.err = err
+				return
+			}
+		}
+	}
+	if g.cfg != nil && len(g.cfg.interceptors) > 0 {
+		c.val, c.err = g.intercept(fn)(ctx, key)
+	} else {
+		c.val, c.err = fn(ctx)
+	}
+
+	// fn 通常只返回 ctx.Err()，补上 cause 让调用方能区分超时来源。
+	if c.err != nil && context.Cause(ctx) == ErrExecTimeout && !errors.Is(c.err, ErrExecTimeout) {
+		c.err = fmt.Errorf("%w: %w", ErrExecTimeout, c.err)
+	}
+	return
 }
 
 // Forget 使 Group 忘记指定 key。
-// 下一次对该 key 的 Do 调用将执行 fn 而非等待先前的调用。
+// 下一次对该 key 的 Do 调用将执行 fn 而非等待先前的调用，也不会取用先前保留的结果。
+// 正在等待的 Follower 如何处理由 WithForgetPolicy 决定。
 func (g *Group[K, V]) Forget(key K) {
+	key = g.canonical(key)
 	g.mu.Lock()
+	var forgot chan struct{}
 	if c, ok := g.calls[key]; ok {
-		c.forgotten = true
-		delete(g.calls, key)
+		forgot = c.forgot
+		g.forgetLocked(key, c)
 	}
+	delete(g.parked, key)
 	g.mu.Unlock()
-}
 
-// panicError 包装 panic 值和调用栈，
-// 使 Follower 收到的 panic 包含原始现场信息而非二次 panic 的栈。
-type panicError struct {
-	value any
-	stack []byte
+	// call 从 map 移除后不会再被 Forget 找到，close 至多执行一次。
+	if forgot != nil {
+		close(forgot)
+	}
 }
 
-func (p *panicError) Error() string {
-	return fmt.Sprintf("%v\n\n%s", p.value, p.stack)
+// ForgetFunc 忘记所有满足 match 的进行中 key，返回忘记的数量。
+// match 在持有 Group 锁时调用，不能回调 Group。
+func (g *Group[K, V]) ForgetFunc(match func(key K) bool) int {
+	var forgot []chan struct{}
+	n := 0
+	g.mu.Lock()
+	for key, c := range g.calls {
+		if !match(key) {
+			continue
+		}
+		if c.forgot != nil {
+			forgot = append(forgot, c.forgot)
+		}
+		g.forgetLocked(key, c)
+		n++
+	}
+	for key := range g.parked {
+		if match(key) {
+			delete(g.parked, key)
+		}
+	}
+	g.mu.Unlock()
+
+	for _, ch := range forgot {
+		close(ch)
+	}
+	return n
 }
 
-// Unwrap 允许 errors.Is / errors.As 穿透到原始 error。
-func (p *panicError) Unwrap() error {
-	err, ok := p.value.(error)
+// ForgetUnshared 仅在没有 Follower 加入时忘记 key，
+// 返回 key 是否已被忘记或本就不存在，即是否没有其他调用者依赖这次执行。
+// 典型用法是放弃自己发起的推测性加载，除非已有他人在等待其结果。
+func (g *Group[K, V]) ForgetUnshared(key K) bool {
+	key = g.canonical(key)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	c, ok := g.calls[key]
 	if !ok {
-		return nil
+		return true
+	}
+	if c.dups.Load() > 0 {
+		return false
+	}
+	g.forgetLocked(key, c)
+	return true
+}
+
+// Close 关闭 Group：之后的 Do 直接返回 ErrGroupClosed，
+// 已在执行的调用不受影响，其 Follower 仍会收到结果；预约的后台执行被取消。
+func (g *Group[K, V]) Close() {
+	g.mu.Lock()
+	g.closed = true
+	g.stopPendingLocked()
+	g.mu.Unlock()
+}
+
+// forgetLocked 把 c 从 calls 移到 orphans。c 必须是 key 当前的执行。
+func (g *Group[K, V]) forgetLocked(key K, c *call[V]) {
+	c.forgotten = true
+	delete(g.calls, key)
+	if g.orphans == nil {
+		g.orphans = make(map[K][]*call[V])
+	}
+	g.orphans[key] = append(g.orphans[key], c)
+}
+
+// dropOrphanLocked 在被遗忘的执行完成时移除它的记录。
+func (g *Group[K, V]) dropOrphanLocked(key K, c *call[V]) {
+	cs := slices.DeleteFunc(g.orphans[key], func(o *call[V]) bool { return o == c })
+	if len(cs) == 0 {
+		delete(g.orphans, key)
+		return
 	}
-	return err
+	g.orphans[key] = cs
 }
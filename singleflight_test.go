@@ -2,7 +2,9 @@ package singleflight
 
 import (
 	"context"
+	"runtime"
 	"strconv"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -22,6 +24,154 @@ func expensiveWork() (string, error) {
 	return "moonlight-value", nil
 }
 
+// -----------------------------------------------------------------------------
+// 正确性 (Correctness)
+// -----------------------------------------------------------------------------
+
+// TestDo_SharedAccountingRace 让 Follower 在 Leader 完成的同一时刻加入或取消，
+// 配合 -race 验证 dups / shared 的读写都在锁内完成，且 call 不会在仍被引用时回收。
+func TestDo_SharedAccountingRace(t *testing.T) {
+	var g Group[int, int]
+	const rounds, followers = 200, 8
+
+	for r := 0; r < rounds; r++ {
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		for i := 0; i < followers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				<-start
+				if i%2 == 0 {
+					go cancel()
+				}
+				v, err, _ := g.Do(ctx, r, func(ctx context.Context) (int, error) { return r, nil })
+				if err == nil && v != r {
+					t.Errorf("round %d: got value %d from another round", r, v)
+				}
+			}()
+		}
+		close(start)
+		wg.Wait()
+	}
+}
+
+// TestDo_ResultWinsTieWithCancel 让 Follower 在进入等待前同时面对
+// 已完成的结果与已取消的 context，Follower 必须拿到结果而不是取消错误。
+func TestDo_ResultWinsTieWithCancel(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		joined := make(chan struct{})
+		var leader <-chan Result[int]
+		g := NewGroup[string, int](WithHooks(Hooks[string]{
+			// 运行在 Follower 的 goroutine 上、开始等待之前：
+			// 先放行 Leader 并等它彻底完成，再取消 context。
+			FollowerJoined: func(string) {
+				close(joined)
+				<-leader
+				cancel()
+			},
+		}))
+		started := make(chan struct{})
+		leader = g.DoChan(context.Background(), "k", func(ctx context.Context) (int, error) {
+			close(started)
+			<-joined
+			return 1, nil
+		})
+		<-started
+		v, err, _ := g.Do(ctx, "k", nil)
+		if err != nil || v != 1 {
+			t.Fatalf("iteration %d: got %d, %v; want the completed result", i, v, err)
+		}
+	}
+}
+
+// TestDo_WakeBeforeCleanup 在 Leader 完成时占住 g.mu，
+// 两种等待方式的 Follower 都必须无需等 Leader 重新拿到锁就收到结果。
+func TestDo_WakeBeforeCleanup(t *testing.T) {
+	var g Group[string, int]
+	started := make(chan struct{})
+	release := make(chan struct{})
+	leader := g.DoChan(context.Background(), "k", func(ctx context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	plain := g.DoChan(context.Background(), "k", nil)
+	cancellable := g.DoChan(ctx, "k", nil)
+	for {
+		g.mu.Lock()
+		n := g.calls["k"].dups.Load()
+		g.mu.Unlock()
+		if n == 2 {
+			break
+		}
+		runtime.Gosched()
+	}
+
+	g.mu.Lock()
+	close(release)
+	for _, ch := range []<-chan Result[int]{plain, cancellable} {
+		if r := <-ch; r.Val != 1 || r.Err != nil || r.Waiters != 2 {
+			t.Errorf("follower got %+v", r)
+		}
+	}
+	g.mu.Unlock()
+	if r := <-leader; !r.Shared {
+		t.Fatalf("leader got %+v", r)
+	}
+}
+
+func TestForgetUnshared(t *testing.T) {
+	var g Group[string, int]
+	if !g.ForgetUnshared("missing") {
+		t.Fatal("unknown key must report true")
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	res := g.DoChan(context.Background(), "k", func(ctx context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	if !g.ForgetUnshared("k") {
+		t.Fatal("unshared call must be forgotten")
+	}
+	// 被忘记后新的调用成为独立的 Leader。
+	if v, _, shared := g.Do(context.Background(), "k", func(ctx context.Context) (int, error) { return 2, nil }); v != 2 || shared {
+		t.Fatalf("after forget got %d, shared=%v", v, shared)
+	}
+	close(release)
+	<-res
+
+	joined := make(chan struct{})
+	g2 := NewGroup[string, int](WithHooks(Hooks[string]{FollowerJoined: func(string) { close(joined) }}))
+	started = make(chan struct{})
+	release = make(chan struct{})
+	g2.DoChan(context.Background(), "k", func(ctx context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	follower := g2.DoChan(context.Background(), "k", nil)
+	<-joined
+	if g2.ForgetUnshared("k") {
+		t.Fatal("shared call must not be forgotten")
+	}
+	close(release)
+	if r := <-follower; r.Val != 1 {
+		t.Fatalf("follower got %+v", r)
+	}
+}
+
 // -----------------------------------------------------------------------------
 // 场景 1: 惊群效应 (Thundering Herd)
 // 所有并发请求都打同一个 Key，测试锁竞争和 WaitGroup 的唤醒性能
@@ -0,0 +1,112 @@
+// Package singleflighttest 提供测试 singleflight 使用方的工具。
+package singleflighttest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/oy3o/singleflight"
+)
+
+// Outcome 是 Mock 对一次调用给出的脚本化结果。
+// Panic 非 nil 时 Do 以该值 panic。
+type Outcome[V any] struct {
+	Val    V
+	Err    error
+	Shared bool
+	Panic  any
+}
+
+// Call 记录一次对 Mock 的调用。Method 为 "Do"、"DoChan" 或 "Forget"。
+type Call[K comparable] struct {
+	Method string
+	Key    K
+}
+
+// Mock 是可控的 singleflight.Doer 实现。
+//
+// 为 key 排好的 Outcome 按先进先出依次返回且不调用 fn；
+// 没有脚本时直接调用 fn（不做任何合并），shared 为 false。
+type Mock[K comparable, V any] struct {
+	mu     sync.Mutex
+	script map[K][]Outcome[V]
+	calls  []Call[K]
+}
+
+var _ singleflight.Doer[string, any] = (*Mock[string, any])(nil)
+
+// NewMock 创建一个 Mock。
+func NewMock[K comparable, V any]() *Mock[K, V] {
+	return &Mock[K, V]{script: make(map[K][]Outcome[V])}
+}
+
+// On 为 key 追加脚本化结果。
+func (m *Mock[K, V]) On(key K, outcomes ...Outcome[V]) *Mock[K, V] {
+	m.mu.Lock()
+	m.script[key] = append(m.script[key], outcomes...)
+	m.mu.Unlock()
+	return m
+}
+
+// Do 实现 singleflight.Doer。
+func (m *Mock[K, V]) Do(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) (V, error),
+) (v V, err error, shared bool) {
+	out, ok := m.next("Do", key)
+	if !ok {
+		v, err = fn(ctx)
+		return v, err, false
+	}
+	if out.Panic != nil {
+		panic(out.Panic)
+	}
+	return out.Val, out.Err, out.Shared
+}
+
+// DoChan 实现 singleflight.Doer。结果在返回前已写入 channel。
+func (m *Mock[K, V]) DoChan(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) (V, error),
+) <-chan singleflight.Result[V] {
+	ch := make(chan singleflight.Result[V], 1)
+	out, ok := m.next("DoChan", key)
+	switch {
+	case !ok:
+		v, err := fn(ctx)
+		ch <- singleflight.Result[V]{Val: v, Err: err}
+	case out.Panic != nil:
+		panic(out.Panic)
+	default:
+		ch <- singleflight.Result[V]{Val: out.Val, Err: out.Err, Shared: out.Shared}
+	}
+	return ch
+}
+
+// Forget 实现 singleflight.Doer，仅记录调用。
+func (m *Mock[K, V]) Forget(key K) {
+	m.mu.Lock()
+	m.calls = append(m.calls, Call[K]{Method: "Forget", Key: key})
+	m.mu.Unlock()
+}
+
+// Calls 返回按发生顺序记录的所有调用。
+func (m *Mock[K, V]) Calls() []Call[K] {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call[K](nil), m.calls...)
+}
+
+func (m *Mock[K, V]) next(method string, key K) (Outcome[V], bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call[K]{Method: method, Key: key})
+	q := m.script[key]
+	if len(q) == 0 {
+		return Outcome[V]{}, false
+	}
+	m.script[key] = q[1:]
+	return q[0], true
+}
@@ -0,0 +1,37 @@
+package singleflighttest
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMock_ScriptedThenPassthrough(t *testing.T) {
+	boom := errors.New("boom")
+	m := NewMock[string, int]().On("k", Outcome[int]{Val: 1, Shared: true}, Outcome[int]{Err: boom})
+
+	fn := func(ctx context.Context) (int, error) { return 42, nil }
+	ctx := context.Background()
+
+	if v, err, shared := m.Do(ctx, "k", fn); v != 1 || err != nil || !shared {
+		t.Fatalf("first = %d, %v, %v", v, err, shared)
+	}
+	if r := <-m.DoChan(ctx, "k", fn); !errors.Is(r.Err, boom) {
+		t.Fatalf("second err = %v", r.Err)
+	}
+	if v, _, shared := m.Do(ctx, "k", fn); v != 42 || shared {
+		t.Fatalf("passthrough = %d, shared=%v", v, shared)
+	}
+	m.Forget("k")
+
+	calls := m.Calls()
+	want := []string{"Do", "DoChan", "Do", "Forget"}
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %v", calls)
+	}
+	for i, c := range calls {
+		if c.Method != want[i] || c.Key != "k" {
+			t.Fatalf("call %d = %+v, want %s", i, c, want[i])
+		}
+	}
+}
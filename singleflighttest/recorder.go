@@ -0,0 +1,113 @@
+package singleflighttest
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/oy3o/singleflight"
+)
+
+// KeyStats 是 Recorder 对单个 key 的统计。
+type KeyStats struct {
+	// Calls 为返回的调用次数（Do 与 DoChan）。
+	Calls int
+	// Executions 为 fn 实际执行的次数。
+	Executions int
+	// Shared 为得到 shared=true 的调用次数。
+	Shared int
+}
+
+// Recorder 包装一个 Doer，记录每个 key 的调用与执行次数，
+// 免去在每个测试中手写原子计数器来验证合并是否发生。
+type Recorder[K comparable, V any] struct {
+	d singleflight.Doer[K, V]
+
+	mu    sync.Mutex
+	stats map[K]*KeyStats
+}
+
+var _ singleflight.Doer[string, any] = (*Recorder[string, any])(nil)
+
+// Record 包装 d。
+func Record[K comparable, V any](d singleflight.Doer[K, V]) *Recorder[K, V] {
+	return &Recorder[K, V]{d: d, stats: make(map[K]*KeyStats)}
+}
+
+// Do 实现 singleflight.Doer。
+func (r *Recorder[K, V]) Do(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) (V, error),
+) (v V, err error, shared bool) {
+	v, err, shared = r.d.Do(ctx, key, r.wrap(key, fn))
+	r.served(key, shared)
+	return v, err, shared
+}
+
+// DoChan 实现 singleflight.Doer。调用在结果被读取时计入统计。
+func (r *Recorder[K, V]) DoChan(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) (V, error),
+) <-chan singleflight.Result[V] {
+	in := r.d.DoChan(ctx, key, r.wrap(key, fn))
+	out := make(chan singleflight.Result[V], 1)
+	go func() {
+		res := <-in
+		r.served(key, res.Shared)
+		out <- res
+	}()
+	return out
+}
+
+// Forget 实现 singleflight.Doer。
+func (r *Recorder[K, V]) Forget(key K) { r.d.Forget(key) }
+
+// Stats 返回 key 的统计快照。
+func (r *Recorder[K, V]) Stats(key K) KeyStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.stats[key]; ok {
+		return *s
+	}
+	return KeyStats{}
+}
+
+// AssertDeduplicated 断言 key 的 fn 恰好执行一次且服务了 callers 个调用者。
+func (r *Recorder[K, V]) AssertDeduplicated(t testing.TB, key K, callers int) {
+	t.Helper()
+	s := r.Stats(key)
+	if s.Executions != 1 || s.Calls != callers {
+		t.Errorf("singleflighttest: key %v: executions=%d calls=%d, want executions=1 calls=%d",
+			key, s.Executions, s.Calls, callers)
+	}
+}
+
+func (r *Recorder[K, V]) wrap(key K, fn func(context.Context) (V, error)) func(context.Context) (V, error) {
+	return func(ctx context.Context) (V, error) {
+		r.mu.Lock()
+		r.statsLocked(key).Executions++
+		r.mu.Unlock()
+		return fn(ctx)
+	}
+}
+
+func (r *Recorder[K, V]) served(key K, shared bool) {
+	r.mu.Lock()
+	s := r.statsLocked(key)
+	s.Calls++
+	if shared {
+		s.Shared++
+	}
+	r.mu.Unlock()
+}
+
+func (r *Recorder[K, V]) statsLocked(key K) *KeyStats {
+	s, ok := r.stats[key]
+	if !ok {
+		s = new(KeyStats)
+		r.stats[key] = s
+	}
+	return s
+}
@@ -0,0 +1,46 @@
+package singleflighttest
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/oy3o/singleflight"
+)
+
+func TestRecorder_AssertDeduplicated(t *testing.T) {
+	const callers = 16
+	joined := make(chan struct{}, callers)
+	g := singleflight.NewGroup[string, int](singleflight.WithHooks(singleflight.Hooks[string]{
+		FollowerJoined: func(string) { joined <- struct{}{} },
+	}))
+	r := Record[string, int](g)
+
+	started := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		r.Do(context.Background(), "k", func(ctx context.Context) (int, error) {
+			close(started)
+			for i := 0; i < callers-1; i++ {
+				<-joined
+			}
+			return 1, nil
+		})
+	}()
+	<-started
+	for i := 0; i < callers-1; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Do(context.Background(), "k", func(ctx context.Context) (int, error) { return 0, nil })
+		}()
+	}
+	wg.Wait()
+
+	r.AssertDeduplicated(t, "k", callers)
+	if s := r.Stats("k"); s.Shared != callers {
+		t.Fatalf("shared = %d, want %d", s.Shared, callers)
+	}
+}
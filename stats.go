@@ -0,0 +1,140 @@
+package singleflight
+
+import (
+	"math/bits"
+	"math/rand/v2"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// Stats 是 Group 的累计统计，由 Group.Stats 返回其快照。
+type Stats struct {
+	// Executions 为已完成的执行次数，包括失败与 panic。
+	Executions uint64
+
+	// NearMisses 为新的执行在同一 key 的上一次执行完成后 WithNearMissWindow
+	// 时长内开始的次数，即同样长的 WithResultHold 本可以吸收的执行。
+	NearMisses uint64
+
+	// FanIn 是每次完成的执行所共享的 Follower 数的分布：FanIn[0] 为无人共享的
+	// 执行数，FanIn[i] (i > 0) 为 Follower 数落在 [2^(i-1), 2^i) 的执行数，
+	// 最后一个桶包含所有更大的值。见 FanInBucket。
+	FanIn [fanInBuckets]uint64
+}
+
+// fanInBuckets 覆盖到 2^14 个 Follower，再大的合并倍数已无区分意义。
+const fanInBuckets = 16
+
+// FanInBucket 返回 Follower 数 waiters 在 Stats.FanIn 中对应的下标。
+func FanInBucket(waiters int) int {
+	if waiters <= 0 {
+		return 0
+	}
+	return min(bits.Len(uint(waiters)), fanInBuckets-1)
+}
+
+// WithStats 开启 Group.Stats 的统计。未开启时执行完成不触碰任何计数器，
+// Stats 返回零值。WithNearMissWindow 隐含开启。
+func WithStats() Option {
+	return func(o *options) { o.stats = true }
+}
+
+// WithNearMissWindow 统计 Stats.NearMisses：key 的执行完成后 d 时长内
+// 又有新的执行开始时记为一次 near miss。用于在开启 WithResultHold 前评估其收益。
+// d <= 0 表示不统计。
+//
+// 开启后每次执行完成时都会读取时钟，并记录每个 key 最近的完成时间。
+func WithNearMissWindow(d time.Duration) Option {
+	return func(o *options) { o.nearMiss = d }
+}
+
+// Stats 返回 Group 当前的统计快照，未开启 WithStats 时为零值。读取不持有 Group 的锁，
+// 各计数器分别汇总，彼此之间不保证是同一时刻的值。
+func (g *Group[K, V]) Stats() Stats {
+	var s Stats
+	c := g.stats.Load()
+	if c == nil {
+		return s
+	}
+	for i := range c.shards {
+		sh := &c.shards[i]
+		s.Executions += sh.executions.Load()
+		s.NearMisses += sh.nearMisses.Load()
+		for b := range s.FanIn {
+			s.FanIn[b] += sh.fanIn[b].Load()
+		}
+	}
+	return s
+}
+
+// counters 是分片的统计计数器。写入随机选择一个分片，读取时汇总，
+// 高并发下的计数不会集中在同一条缓存行上，也不需要 Group 的锁。
+type counters struct {
+	shards []counterShard
+	mask   uint32
+}
+
+// counterShard 填充到缓存行的整数倍，相邻分片不会伪共享。
+type counterShard struct {
+	executions atomic.Uint64
+	nearMisses atomic.Uint64
+	fanIn      [fanInBuckets]atomic.Uint64
+	_          [64 - (2+fanInBuckets)*8%64]byte
+}
+
+// counters 返回 g 的计数器，首次使用时分配。分片数为不小于 GOMAXPROCS 的
+// 2 的幂，最多 64 个。
+func (g *Group[K, V]) counters() *counters {
+	if c := g.stats.Load(); c != nil {
+		return c
+	}
+	n := shardCount()
+	g.stats.CompareAndSwap(nil, &counters{shards: make([]counterShard, n), mask: uint32(n - 1)})
+	return g.stats.Load()
+}
+
+// shardCount 返回不小于 GOMAXPROCS 的 2 的幂，最多 64。
+func shardCount() int {
+	n := 1
+	for n < runtime.GOMAXPROCS(0) && n < 64 {
+		n <<= 1
+	}
+	return n
+}
+
+// shard 随机选择一个分片。math/rand/v2 的全局函数使用运行时按线程的随机状态，
+// 本身没有竞争。
+func (c *counters) shard() *counterShard {
+	return &c.shards[rand.Uint32()&c.mask]
+}
+
+// completedLocked 记录 key 的执行完成时间，供 nearMissLocked 判断。
+func (g *Group[K, V]) completedLocked(key K, at time.Time) {
+	if g.completed == nil {
+		g.completed = make(map[K]time.Time)
+	}
+	// 与 sweepLocked 相同，翻倍时清理窗口外的记录，兜底不再被访问的 key。
+	if len(g.completed) >= 2*g.completedSwept+16 {
+		for k, t := range g.completed {
+			if at.Sub(t) > g.cfg.nearMiss {
+				delete(g.completed, k)
+			}
+		}
+		g.completedSwept = len(g.completed)
+	}
+	g.completed[key] = at
+}
+
+// nearMissLocked 在 key 开始新的执行时检查其上一次完成是否仍在窗口内。
+// 记录随即删除，同一次完成至多计一次。
+func (g *Group[K, V]) nearMissLocked(key K, now time.Time) {
+	at, ok := g.completed[key]
+	if !ok {
+		return
+	}
+	delete(g.completed, key)
+	if now.Sub(at) <= g.cfg.nearMiss {
+		g.counters().shard().nearMisses.Add(1)
+	}
+}
@@ -0,0 +1,90 @@
+package singleflight
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStats_NearMisses(t *testing.T) {
+	clock := NewFakeClock(time.Unix(100, 0))
+	g := NewGroup[string, int](WithClock(clock), WithNearMissWindow(10*time.Millisecond))
+	ctx := context.Background()
+	fn := func(context.Context) (int, error) { return 1, nil }
+
+	g.Do(ctx, "k", fn)
+	clock.Advance(5 * time.Millisecond)
+	g.Do(ctx, "k", fn) // 窗口内：near miss
+	clock.Advance(20 * time.Millisecond)
+	g.Do(ctx, "k", fn) // 窗口外
+	g.Do(ctx, "other", fn)
+
+	if s := g.Stats(); s.Executions != 4 || s.NearMisses != 1 {
+		t.Fatalf("Stats() = %+v, want 4 executions and 1 near miss", s)
+	}
+}
+
+func TestStats_FanIn(t *testing.T) {
+	var joined atomic.Int32
+	g := NewGroup[string, int](WithStats(), WithHooks(Hooks[string]{FollowerJoined: func(string) { joined.Add(1) }}))
+	ctx := context.Background()
+	g.Do(ctx, "solo", func(context.Context) (int, error) { return 1, nil })
+
+	started, release := make(chan struct{}), make(chan struct{})
+	leader := g.DoChan(ctx, "k", func(context.Context) (int, error) {
+		close(started)
+		<-release
+		return 1, nil
+	})
+	<-started
+	const followers = 5
+	var wg sync.WaitGroup
+	for range followers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.Do(ctx, "k", nil)
+		}()
+	}
+	for joined.Load() < followers {
+		runtime.Gosched()
+	}
+	close(release)
+	<-leader
+	wg.Wait()
+
+	s := g.Stats()
+	if s.FanIn[0] != 1 || s.FanIn[FanInBucket(followers)] != 1 {
+		t.Fatalf("FanIn = %v", s.FanIn)
+	}
+	for n, want := range map[int]int{0: 0, 1: 1, 2: 2, 3: 2, 4: 3, 1 << 20: fanInBuckets - 1} {
+		if got := FanInBucket(n); got != want {
+			t.Errorf("FanInBucket(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
+
+func BenchmarkStats_Parallel(b *testing.B) {
+	g := NewGroup[int, int](WithStats())
+	var next atomic.Int64
+	b.RunParallel(func(pb *testing.PB) {
+		key := int(next.Add(1))
+		for pb.Next() {
+			g.Do(context.Background(), key, func(context.Context) (int, error) { return 1, nil })
+		}
+	})
+	if s := g.Stats(); s.Executions != uint64(b.N) {
+		b.Fatalf("Executions = %d, want %d", s.Executions, b.N)
+	}
+}
+
+func TestStats_DisabledByDefault(t *testing.T) {
+	var g Group[string, int]
+	g.Do(context.Background(), "k", func(context.Context) (int, error) { return 1, nil })
+	if s := g.Stats(); s != (Stats{}) || g.stats.Load() != nil {
+		t.Fatalf("Stats() = %+v without WithStats", s)
+	}
+}
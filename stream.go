@@ -0,0 +1,208 @@
+package singleflight
+
+import (
+	"context"
+	"io"
+	"iter"
+	"sync"
+)
+
+// Stream 合并对同一 key 的流式生产：第一个调用者启动 fn，fn 每产生一个值就
+// 立即分发给所有读者，而不必等到整个结果完成。生产进行中到达的调用者挂到
+// 同一次生产上，并从第一个值开始重放，因此每个读者都看到完整的序列。
+//
+// 生产与发起者的 ctx 解耦，只有当所有读者都关闭时才会被取消。
+// 已产生的值保存在内存中直到生产结束且所有读者关闭，不适合无界的流。
+// 零值可用。
+type Stream[K comparable, T any] struct {
+	mu     sync.Mutex
+	active map[K]*streamLog[T]
+}
+
+// Open 返回 key 对应生产的一个独立读者，调用方必须 Close。
+//
+// fn 通过 emit 逐个交付值；emit 在所有读者都已关闭后返回 ctx 的错误，fn 应据此停止。
+// fn 返回的错误在读者读完所有值后由 Next 返回，nil 时 Next 返回 io.EOF。
+func (s *Stream[K, T]) Open(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context, emit func(T) error) error,
+) *StreamReader[T] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.active[key]; ok {
+		l.mu.Lock()
+		// 最后一个读者刚刚放弃、生产正在被取消时不能再挂上去。
+		joined := !l.abandoned
+		if joined {
+			l.refs++
+		}
+		l.mu.Unlock()
+		if joined {
+			return &StreamReader[T]{log: l}
+		}
+	}
+
+	pctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	l := &streamLog[T]{wait: make(chan struct{}), refs: 1, cancel: cancel}
+	if s.active == nil {
+		s.active = make(map[K]*streamLog[T])
+	}
+	s.active[key] = l
+	l.detach = func() {
+		s.mu.Lock()
+		if s.active[key] == l {
+			delete(s.active, key)
+		}
+		s.mu.Unlock()
+	}
+
+	go func() {
+		defer cancel()
+		var err error
+		defer func() {
+			if r := recover(); r != nil {
+				err = newPanicError(r, 0)
+			}
+			l.finish(err)
+		}()
+		err = fn(pctx, func(v T) error {
+			if err := pctx.Err(); err != nil {
+				return err
+			}
+			l.append(v)
+			return nil
+		})
+	}()
+	return &StreamReader[T]{log: l}
+}
+
+// streamLog 是一次生产已交付的值，追加写入、可被多个读者独立重放。
+type streamLog[T any] struct {
+	mu    sync.Mutex
+	items []T
+	done  bool
+	err   error
+	// wait 在每次追加或结束时被 close 并替换，用于广播唤醒阻塞的读者。
+	wait      chan struct{}
+	refs      int
+	abandoned bool
+
+	cancel context.CancelFunc
+	// detach 把本次生产从 Stream 中移除，之后的 Open 会发起新的生产。
+	detach func()
+}
+
+func (l *streamLog[T]) append(v T) {
+	l.mu.Lock()
+	l.items = append(l.items, v)
+	close(l.wait)
+	l.wait = make(chan struct{})
+	l.mu.Unlock()
+}
+
+func (l *streamLog[T]) finish(err error) {
+	// 先移除再广播，读者读到结束后重新 Open 必然发起新的生产。
+	l.detach()
+	l.mu.Lock()
+	l.done, l.err = true, err
+	close(l.wait)
+	l.wait = make(chan struct{})
+	l.mu.Unlock()
+}
+
+// StreamReader 从头读取一次生产交付的值。不可并发使用。
+type StreamReader[T any] struct {
+	log    *streamLog[T]
+	off    int
+	closed bool
+}
+
+// Next 返回下一个值，必要时等待生产者交付。
+// 序列结束时返回 io.EOF 或 fn 的错误；ctx 结束时返回 *WaitError，读者仍可继续使用。
+func (r *StreamReader[T]) Next(ctx context.Context) (T, error) {
+	var zero T
+	if r.closed {
+		return zero, io.ErrClosedPipe
+	}
+	l := r.log
+	for {
+		l.mu.Lock()
+		if r.off < len(l.items) {
+			v := l.items[r.off]
+			r.off++
+			l.mu.Unlock()
+			return v, nil
+		}
+		if l.done {
+			err := l.err
+			l.mu.Unlock()
+			if err == nil {
+				err = io.EOF
+			}
+			return zero, err
+		}
+		wait := l.wait
+		l.mu.Unlock()
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return zero, waitError(ctx)
+		}
+	}
+}
+
+// Close 释放读者。最后一个读者在生产结束前关闭时取消生产。
+func (r *StreamReader[T]) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	l := r.log
+	l.mu.Lock()
+	l.refs--
+	abandon := l.refs == 0 && !l.done
+	l.abandoned = abandon
+	l.mu.Unlock()
+	if abandon {
+		l.detach()
+		l.cancel()
+	}
+	return nil
+}
+
+// All 是 Open 的迭代器形式：fn 以 iter.Seq2 产生值，非 nil 的错误结束序列。
+// 返回的序列在每次迭代时打开一个读者，中途加入的迭代者先重放已产生的值，
+// 序列以 fn 的错误（如有）作为最后一个元素结束；提前 break 即关闭读者。
+//
+// ctx 只约束迭代者自身的等待，结束时产出 *WaitError 并停止。
+func (s *Stream[K, T]) All(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) iter.Seq2[T, error],
+) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		r := s.Open(ctx, key, func(ctx context.Context, emit func(T) error) error {
+			for v, err := range fn(ctx) {
+				if err != nil {
+					return err
+				}
+				if err := emit(v); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		defer r.Close()
+		for {
+			v, err := r.Next(ctx)
+			if err == io.EOF {
+				return
+			}
+			if !yield(v, err) || err != nil {
+				return
+			}
+		}
+	}
+}
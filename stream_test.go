@@ -0,0 +1,158 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestStream_TeesAndReplays(t *testing.T) {
+	var s Stream[string, int]
+	var starts int
+	step := make(chan struct{})
+	boom := errors.New("upstream reset")
+	fn := func(ctx context.Context, emit func(int) error) error {
+		starts++
+		for i := 1; i <= 3; i++ {
+			<-step
+			if err := emit(i); err != nil {
+				return err
+			}
+		}
+		<-step
+		return boom
+	}
+	ctx := context.Background()
+
+	first := s.Open(ctx, "k", fn)
+	defer first.Close()
+	step <- struct{}{}
+	if v, err := first.Next(ctx); v != 1 || err != nil {
+		t.Fatalf("first reader got %d, %v before completion", v, err)
+	}
+
+	// 中途加入的读者从头重放。
+	second := s.Open(ctx, "k", fn)
+	defer second.Close()
+	step <- struct{}{}
+	step <- struct{}{}
+	step <- struct{}{}
+	for _, r := range []*StreamReader[int]{first, second} {
+		var got []int
+		for {
+			v, err := r.Next(ctx)
+			if err != nil {
+				if !errors.Is(err, boom) {
+					t.Fatalf("terminal error = %v", err)
+				}
+				break
+			}
+			got = append(got, v)
+		}
+		if r == second && len(got) != 3 || r == first && len(got) != 2 {
+			t.Fatalf("reader got %v", got)
+		}
+	}
+	if starts != 1 {
+		t.Fatalf("fn started %d times", starts)
+	}
+}
+
+func TestStream_LastCloseCancels(t *testing.T) {
+	var s Stream[string, int]
+	stopped := make(chan error, 1)
+	r := s.Open(context.Background(), "k", func(ctx context.Context, emit func(int) error) error {
+		<-ctx.Done()
+		stopped <- emit(1)
+		return ctx.Err()
+	})
+	r.Close()
+	if err := <-stopped; !errors.Is(err, context.Canceled) {
+		t.Fatalf("emit after abandon = %v", err)
+	}
+	if _, err := r.Next(context.Background()); err != io.ErrClosedPipe {
+		t.Fatalf("Next after Close = %v", err)
+	}
+
+	// 之后的 Open 发起新的生产。
+	r2 := s.Open(context.Background(), "k", func(ctx context.Context, emit func(int) error) error {
+		return emit(7)
+	})
+	defer r2.Close()
+	if v, err := r2.Next(context.Background()); v != 7 || err != nil {
+		t.Fatalf("fresh stream got %d, %v", v, err)
+	}
+}
+
+func TestStream_AllReplaysAndSharesError(t *testing.T) {
+	var s Stream[string, string]
+	boom := errors.New("boom")
+	step := make(chan struct{})
+	var starts int
+	fn := func(ctx context.Context) iter.Seq2[string, error] {
+		starts++
+		return func(yield func(string, error) bool) {
+			for _, v := range []string{"a", "b"} {
+				<-step
+				if !yield(v, nil) {
+					return
+				}
+			}
+			<-step
+			yield("", boom)
+		}
+	}
+	ctx := context.Background()
+
+	collect := func(out chan<- []string) {
+		var got []string
+		for v, err := range s.All(ctx, "k", fn) {
+			if err != nil {
+				v = err.Error()
+			}
+			got = append(got, v)
+		}
+		out <- got
+	}
+	// waitLog 等待 key 的生产满足 cond。
+	waitLog := func(cond func(l *streamLog[string]) bool) {
+		for {
+			s.mu.Lock()
+			l := s.active["k"]
+			s.mu.Unlock()
+			if l != nil {
+				l.mu.Lock()
+				ok := cond(l)
+				l.mu.Unlock()
+				if ok {
+					return
+				}
+			}
+			runtime.Gosched()
+		}
+	}
+
+	leader, follower := make(chan []string, 1), make(chan []string, 1)
+	go collect(leader)
+	step <- struct{}{}
+	waitLog(func(l *streamLog[string]) bool { return len(l.items) == 1 })
+	// 第一个值产出之后才加入的迭代者同样从头看到完整序列。
+	go collect(follower)
+	waitLog(func(l *streamLog[string]) bool { return l.refs == 2 })
+	step <- struct{}{}
+	step <- struct{}{}
+
+	want := []string{"a", "b", "boom"}
+	for _, ch := range []chan []string{leader, follower} {
+		if got := <-ch; !slices.Equal(got, want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+	if starts != 1 {
+		t.Fatalf("fn started %d times", starts)
+	}
+}
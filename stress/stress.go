@@ -0,0 +1,199 @@
+// Package stress 提供可编程的压力 / 浸泡测试工具，
+// 用于在调用方自己的选项组合下验证 singleflight.Group 的行为。
+package stress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+// Value 是压测 fn 的返回值，携带生成它的 key 以便校验结果没有串 key。
+type Value struct {
+	Key string
+	Seq uint64
+}
+
+// Config 描述压测负载。零值字段使用默认值。
+type Config struct {
+	// Keys 为 key 空间大小，越小争用越激烈。默认 8。
+	Keys int
+	// Goroutines 为并发调用者数量。默认 64。
+	Goroutines int
+	// Duration 为压测时长。默认 1s。
+	Duration time.Duration
+	// Work 为单次 fn 执行的最大耗时，实际耗时在 [0, Work) 内随机。默认 100µs。
+	Work time.Duration
+	// CancelRate 为调用者在等待期间取消 context 的概率。
+	CancelRate float64
+	// PanicRate 为 fn panic 的概率。
+	PanicRate float64
+}
+
+// Report 汇总压测结果。Anomalies 为空表示未发现异常。
+type Report struct {
+	Calls      uint64
+	Executions uint64
+	Shared     uint64
+	Cancelled  uint64
+	Panics     uint64
+
+	// Anomalies 最多保留前 100 条异常描述。
+	Anomalies []string
+}
+
+// OK 报告是否未发现异常。
+func (r *Report) OK() bool { return len(r.Anomalies) == 0 }
+
+// injectedPanic 是压测注入的 panic 值，实现 error 以便穿透 panic 包装被识别。
+type injectedPanic struct{ key string }
+
+func (p *injectedPanic) Error() string { return "stress: injected panic for " + p.key }
+
+const maxAnomalies = 100
+
+type runner struct {
+	g   singleflight.Doer[string, Value]
+	cfg Config
+
+	seq      atomic.Uint64
+	inflight []atomic.Int32
+
+	calls, execs, shared, cancelled, panics atomic.Uint64
+
+	mu        sync.Mutex
+	anomalies []string
+}
+
+// Run 在 g 上执行压测并返回报告。检测的异常包括：
+//   - 同一 key 的 fn 并发执行（合并失效）
+//   - 返回的值属于其他 key
+//   - 成功返回了零值，或返回了未注入的错误
+//   - 未注入的 panic，或注入的 panic 未以可识别的形式传播
+//   - 压测结束后仍有调用未返回（疑似泄漏或死锁）
+func Run(g singleflight.Doer[string, Value], cfg Config) Report {
+	if cfg.Keys <= 0 {
+		cfg.Keys = 8
+	}
+	if cfg.Goroutines <= 0 {
+		cfg.Goroutines = 64
+	}
+	if cfg.Duration <= 0 {
+		cfg.Duration = time.Second
+	}
+	if cfg.Work <= 0 {
+		cfg.Work = 100 * time.Microsecond
+	}
+
+	r := &runner{g: g, cfg: cfg, inflight: make([]atomic.Int32, cfg.Keys)}
+	deadline := time.Now().Add(cfg.Duration)
+
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.Goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for time.Now().Before(deadline) {
+				r.once(rand.IntN(cfg.Keys))
+			}
+		}()
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+	// 单次调用的耗时上限远小于该宽限期，超时即视为卡死。
+	grace := time.NewTimer(10*cfg.Work + 5*time.Second)
+	defer grace.Stop()
+	select {
+	case <-finished:
+	case <-grace.C:
+		r.anomaly("calls still blocked %v after the run ended", 10*cfg.Work+5*time.Second)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Report{
+		Calls:      r.calls.Load(),
+		Executions: r.execs.Load(),
+		Shared:     r.shared.Load(),
+		Cancelled:  r.cancelled.Load(),
+		Panics:     r.panics.Load(),
+		Anomalies:  append([]string(nil), r.anomalies...),
+	}
+}
+
+func (r *runner) once(k int) {
+	key := strconv.Itoa(k)
+	ctx := context.Background()
+	if rand.Float64() < r.cfg.CancelRate {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		t := time.AfterFunc(rand.N(r.cfg.Work+1), cancel)
+		defer t.Stop()
+		defer cancel()
+	}
+
+	defer func() {
+		p := recover()
+		if p == nil {
+			return
+		}
+		r.panics.Add(1)
+		var ip *injectedPanic
+		if err, ok := p.(error); !ok || !errors.As(err, &ip) {
+			r.anomaly("key %s: unexpected panic %v", key, p)
+		} else if ip.key != key {
+			r.anomaly("key %s: received panic injected for key %s", key, ip.key)
+		}
+	}()
+
+	r.calls.Add(1)
+	v, err, shared := r.g.Do(ctx, key, func(ctx context.Context) (Value, error) {
+		return r.exec(k, key)
+	})
+	if shared {
+		r.shared.Add(1)
+	}
+	switch {
+	case err == nil:
+		if v.Key != key {
+			r.anomaly("key %s: received value produced for key %q", key, v.Key)
+		}
+	case errors.Is(err, context.Canceled):
+		r.cancelled.Add(1)
+	default:
+		r.anomaly("key %s: unexpected error %v", key, err)
+	}
+}
+
+func (r *runner) exec(k int, key string) (Value, error) {
+	r.execs.Add(1)
+	if n := r.inflight[k].Add(1); n > 1 {
+		r.anomaly("key %s: %d concurrent executions", key, n)
+	}
+	defer r.inflight[k].Add(-1)
+
+	time.Sleep(rand.N(r.cfg.Work))
+	if rand.Float64() < r.cfg.PanicRate {
+		panic(&injectedPanic{key: key})
+	}
+	return Value{Key: key, Seq: r.seq.Add(1)}, nil
+}
+
+func (r *runner) anomaly(format string, args ...any) {
+	r.mu.Lock()
+	if len(r.anomalies) < maxAnomalies {
+		r.anomalies = append(r.anomalies, fmt.Sprintf(format, args...))
+	}
+	r.mu.Unlock()
+}
@@ -0,0 +1,24 @@
+package stress
+
+import (
+	"testing"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+func TestRun_DefaultGroup(t *testing.T) {
+	rep := Run(new(singleflight.Group[string, Value]), Config{
+		Keys:       4,
+		Goroutines: 32,
+		Duration:   200 * time.Millisecond,
+		CancelRate: 0.2,
+		PanicRate:  0.05,
+	})
+	if !rep.OK() {
+		t.Fatalf("anomalies: %v", rep.Anomalies)
+	}
+	if rep.Calls == 0 || rep.Executions == 0 || rep.Shared == 0 {
+		t.Fatalf("report = %+v", rep)
+	}
+}
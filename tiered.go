@@ -0,0 +1,223 @@
+package singleflight
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// LockBackend 是集群级互斥的最小抽象，可由 Redis SET NX、etcd lease 等实现。
+type LockBackend interface {
+	// TryLock 尝试获取 key 的集群锁，不阻塞。
+	// acquired 为 true 时调用方必须在结束后调用 unlock。
+	// ttl 是锁的兜底过期时间，防止持锁进程崩溃后死锁。
+	TryLock(ctx context.Context, key string, ttl time.Duration) (unlock func(context.Context) error, acquired bool, err error)
+}
+
+// ResultStore 在实例之间传递编码后的执行结果，可由带 TTL 的结果 key、
+// pub/sub 的最近消息等实现。
+type ResultStore interface {
+	// Publish 保存 key 的结果，ttl 之后不再可见。
+	Publish(ctx context.Context, key string, data []byte, ttl time.Duration) error
+	// Lookup 返回 key 仍然可见的结果。
+	Lookup(ctx context.Context, key string) (data []byte, ok bool, err error)
+}
+
+// TieredGroup 组合进程内合并与集群级合并。
+//
+// 本地等待者先在 Local 中合并，只有本进程的 Leader 去竞争集群锁：
+//   - 抢到锁：执行 fn，结束后释放锁。
+//   - 未抢到：按 PollInterval 轮询，直到锁被释放或等待超过 MaxWait，
+//     然后在本地执行 fn。fn 通常先查共享缓存，从而命中其他实例的结果。
+//
+// 因此 N 个实例、每实例 M 个并发调用者，最多只有 N 次 fn 执行，
+// 其中只有一次在持锁状态下访问后端。
+//
+// 设置 Results 后，持锁的 Leader 在释放锁之前发布编码后的成功结果，
+// 等待过的实例抢到锁或等待超时后先查看它，拿到即返回，其余 N-1 次执行也随之避免。
+// 只有等待过锁的实例会读取已发布的结果，新到达的调用仍然先竞争锁。
+type TieredGroup[K comparable, V any] struct {
+	// Local 为进程内合并组，nil 时使用私有 Group。
+	Local *Group[K, V]
+
+	// Backend 为集群锁后端。
+	Backend LockBackend
+
+	// Key 把 K 编码为集群范围内唯一的锁名。
+	Key func(K) string
+
+	// LockTTL 为锁的过期时间，应大于 fn 的最长执行时间。默认 30s。
+	LockTTL time.Duration
+
+	// PollInterval 为未抢到锁时的重试间隔。默认 50ms。
+	PollInterval time.Duration
+
+	// MaxWait 为等待其他实例释放锁的上限，超过后放弃等待直接本地执行。
+	// 0 表示只受调用者 ctx 约束。
+	MaxWait time.Duration
+
+	// FailOpen 为 true 时，后端错误降级为本地执行；否则错误直接返回。
+	FailOpen bool
+
+	// Clock 驱动轮询与 MaxWait 计时，nil 时使用 SystemClock。
+	Clock Clock
+
+	// Results 非 nil 时在实例之间传递结果，见类型说明。
+	Results ResultStore
+
+	// Codec 编码经 Results 传递的结果，所有实例必须一致。nil 时使用 GobCodec。
+	Codec Codec[V]
+
+	// ResultTTL 为已发布结果的可见时长，默认 5s。等待过的实例会接受这段时间内
+	// 发布的任何结果，包括更早一次执行的结果，因此它同时是可接受的最大陈旧程度。
+	ResultTTL time.Duration
+
+	local Group[K, V]
+}
+
+// NewTieredGroup 创建 TieredGroup。
+func NewTieredGroup[K comparable, V any](backend LockBackend, key func(K) string) *TieredGroup[K, V] {
+	return &TieredGroup[K, V]{Backend: backend, Key: key}
+}
+
+// Do 语义同 Group.Do。shared 只反映进程内是否共享。
+//
+// 错误语义：
+//   - fn 的错误原样返回。
+//   - 后端错误以 *BackendError 返回（FailOpen 时降级执行，不返回）。
+//   - 等待集群锁期间 ctx 结束，返回 ErrWaiterCancelled，fn 不会执行。
+func (t *TieredGroup[K, V]) Do(
+	ctx context.Context,
+	key K,
+	fn func(ctx context.Context) (V, error),
+) (v V, err error, shared bool) {
+	local := t.Local
+	if local == nil {
+		local = &t.local
+	}
+	return local.Do(ctx, key, func(ctx context.Context) (V, error) {
+		return t.doCluster(ctx, t.Key(key), fn)
+	})
+}
+
+func (t *TieredGroup[K, V]) doCluster(
+	ctx context.Context,
+	name string,
+	fn func(ctx context.Context) (V, error),
+) (V, error) {
+	ttl := t.LockTTL
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	interval := t.PollInterval
+	if interval <= 0 {
+		interval = 50 * time.Millisecond
+	}
+
+	clock := clockOrSystem(t.Clock)
+
+	var deadline <-chan time.Time
+	if t.MaxWait > 0 {
+		timer := clock.NewTimer(t.MaxWait)
+		defer timer.Stop()
+		deadline = timer.C()
+	}
+
+	var poll Timer
+	for {
+		unlock, acquired, err := t.Backend.TryLock(ctx, name, ttl)
+		if err != nil {
+			if t.FailOpen {
+				return fn(ctx)
+			}
+			var zero V
+			return zero, &BackendError{Key: name, Err: err}
+		}
+		if acquired {
+			// 释放锁不应受 fn 期间 ctx 取消的影响，否则锁只能等 TTL 过期。
+			defer unlock(context.WithoutCancel(ctx))
+			// 等待过的实例先查看刚释放锁的 Leader 是否已发布结果。
+			if poll != nil {
+				if v, ok, err := t.lookup(ctx, name); ok || err != nil {
+					return v, err
+				}
+			}
+			v, err := fn(ctx)
+			if err == nil && t.Results != nil {
+				t.publish(context.WithoutCancel(ctx), name, v)
+			}
+			return v, err
+		}
+
+		if poll == nil {
+			poll = clock.NewTimer(interval)
+			defer poll.Stop()
+		} else {
+			poll.Reset(interval)
+		}
+		select {
+		case <-poll.C():
+		case <-deadline:
+			if v, ok, err := t.lookup(ctx, name); ok || err != nil {
+				return v, err
+			}
+			return fn(ctx)
+		case <-ctx.Done():
+			var zero V
+			return zero, waitError(ctx)
+		}
+	}
+}
+
+// publish 发布 Leader 的结果。发布失败只影响其他实例，它们会在锁释放后自行执行。
+func (t *TieredGroup[K, V]) publish(ctx context.Context, name string, v V) {
+	data, err := t.codec().Marshal(v)
+	if err != nil {
+		return
+	}
+	ttl := t.ResultTTL
+	if ttl <= 0 {
+		ttl = 5 * time.Second
+	}
+	t.Results.Publish(ctx, name, data, ttl)
+}
+
+// lookup 读取其他实例发布的结果。后端错误按 FailOpen 处理：降级时视为没有结果。
+func (t *TieredGroup[K, V]) lookup(ctx context.Context, name string) (v V, ok bool, err error) {
+	if t.Results == nil {
+		return v, false, nil
+	}
+	data, ok, err := t.Results.Lookup(ctx, name)
+	if ctx.Err() != nil {
+		return v, false, waitError(ctx)
+	}
+	if err == nil && ok {
+		v, err = t.codec().Unmarshal(data)
+	}
+	if err != nil {
+		if t.FailOpen {
+			return v, false, nil
+		}
+		return v, false, &BackendError{Key: name, Err: err}
+	}
+	return v, ok, nil
+}
+
+func (t *TieredGroup[K, V]) codec() Codec[V] {
+	if t.Codec != nil {
+		return t.Codec
+	}
+	return GobCodec[V]{}
+}
+
+// BackendError 表示集群后端操作失败。
+type BackendError struct {
+	Key string
+	Err error
+}
+
+func (e *BackendError) Error() string {
+	return fmt.Sprintf("singleflight: backend error for %q: %v", e.Key, e.Err)
+}
+
+func (e *BackendError) Unwrap() error { return e.Err }
@@ -0,0 +1,178 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memLocker 是进程内模拟的集群锁，多个 TieredGroup 共享它即模拟多个实例。
+type memLocker struct {
+	mu   sync.Mutex
+	held map[string]bool
+	err  error
+	// busy 为因锁被占用而失败的 TryLock 次数。
+	busy int
+}
+
+func (m *memLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (func(context.Context) error, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return nil, false, m.err
+	}
+	if m.held == nil {
+		m.held = make(map[string]bool)
+	}
+	if m.held[key] {
+		m.busy++
+		return nil, false, nil
+	}
+	m.held[key] = true
+	return func(context.Context) error {
+		m.mu.Lock()
+		delete(m.held, key)
+		m.mu.Unlock()
+		return nil
+	}, true, nil
+}
+
+func TestTieredGroup_OneLockedExecutionAcrossInstances(t *testing.T) {
+	backend := &memLocker{}
+	key := func(s string) string { return "sf:" + s }
+
+	var locked, total atomic.Int32
+	var cached atomic.Value // 模拟共享缓存
+
+	const instances, callers = 3, 10
+	var wg sync.WaitGroup
+	for i := 0; i < instances; i++ {
+		tg := NewTieredGroup[string, string](backend, key)
+		tg.PollInterval = time.Millisecond
+		for j := 0; j < callers; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				v, err, _ := tg.Do(context.Background(), "k", func(ctx context.Context) (string, error) {
+					total.Add(1)
+					if v, ok := cached.Load().(string); ok {
+						return v, nil
+					}
+					locked.Add(1)
+					time.Sleep(10 * time.Millisecond)
+					cached.Store("v")
+					return "v", nil
+				})
+				if err != nil || v != "v" {
+					t.Errorf("Do = %q, %v", v, err)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	if n := locked.Load(); n != 1 {
+		t.Fatalf("backend loads = %d, want 1", n)
+	}
+	if n := total.Load(); n > instances {
+		t.Fatalf("fn executions = %d, want <= %d", n, instances)
+	}
+}
+
+func TestTieredGroup_BackendError(t *testing.T) {
+	boom := errors.New("redis down")
+	tg := NewTieredGroup[string, int](&memLocker{err: boom}, func(s string) string { return s })
+
+	_, err, _ := tg.Do(context.Background(), "k", func(ctx context.Context) (int, error) { return 1, nil })
+	var be *BackendError
+	if !errors.As(err, &be) || !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want BackendError wrapping %v", err, boom)
+	}
+
+	tg.FailOpen = true
+	v, err, _ := tg.Do(context.Background(), "k", func(ctx context.Context) (int, error) { return 1, nil })
+	if err != nil || v != 1 {
+		t.Fatalf("fail-open Do = %d, %v", v, err)
+	}
+}
+
+// memResults 是进程内模拟的结果存储，忽略 TTL。
+type memResults struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func (m *memResults) Publish(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.data == nil {
+		m.data = make(map[string][]byte)
+	}
+	m.data[key] = data
+	return nil
+}
+
+func (m *memResults) Lookup(ctx context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.data[key]
+	return data, ok, nil
+}
+
+func TestTieredGroup_ResultReplication(t *testing.T) {
+	backend, results := &memLocker{}, &memResults{}
+	instance := func() *TieredGroup[string, string] {
+		tg := NewTieredGroup[string, string](backend, func(s string) string { return s })
+		tg.PollInterval = time.Millisecond
+		tg.Results = results
+		return tg
+	}
+	a, b := instance(), instance()
+	ctx := context.Background()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.Do(ctx, "k", func(context.Context) (string, error) {
+			close(started)
+			<-release
+			return "moon", nil
+		})
+	}()
+	<-started
+	res := make(chan string)
+	go func() {
+		v, err, _ := b.Do(ctx, "k", func(context.Context) (string, error) {
+			t.Error("waiting instance executed fn despite a published result")
+			return "", nil
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		res <- v
+	}()
+	for {
+		backend.mu.Lock()
+		busy := backend.busy
+		backend.mu.Unlock()
+		if busy > 0 {
+			break
+		}
+		runtime.Gosched()
+	}
+	close(release)
+	<-done
+	if v := <-res; v != "moon" {
+		t.Fatalf("waiting instance got %q", v)
+	}
+
+	// 未等待过锁的调用不读取已发布的结果。
+	if v, _, _ := b.Do(ctx, "k", func(context.Context) (string, error) { return "fresh", nil }); v != "fresh" {
+		t.Fatalf("new call got %q, want a fresh execution", v)
+	}
+}
@@ -0,0 +1,163 @@
+// Package tlssf 为 tls.Config.GetCertificate 提供按服务器名合并的证书加载与续期。
+//
+// 服务重启后的握手风暴中，每个握手都会发现证书尚未加载，
+// 各自向 ACME 等签发方下单既浪费配额，也可能触发签发方的频率限制。
+// Manager 让每个服务器名同时至多有一次加载，并缓存签发的证书直到需要续期。
+package tlssf
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+// ErrNoServerName 表示客户端未发送 SNI 且 Manager 未设置 DefaultName。
+var ErrNoServerName = errors.New("tlssf: missing server name")
+
+// Manager 缓存各服务器名的证书，并发安全。Load 必须设置，其余字段零值可用。
+type Manager struct {
+	// Load 为 name 加载或签发证书，例如从磁盘读取或完成一次 ACME 订单。
+	// 返回的证书必须至少包含一个可解析的证书链。
+	Load func(ctx context.Context, name string) (*tls.Certificate, error)
+
+	// RenewBefore 为在证书过期前多久开始续期，默认 30 天。
+	// 续期在后台进行，期间握手照常使用旧证书。
+	RenewBefore time.Duration
+
+	// DefaultName 在客户端未发送 SNI 时使用，为空时此类握手失败。
+	DefaultName string
+
+	// RetryInterval 为后台续期失败后再次尝试前的间隔，默认 1 分钟，
+	// 避免签发方故障期间每次握手都发起一次续期。
+	RetryInterval time.Duration
+
+	// Clock 用于过期判断，nil 时使用 singleflight.SystemClock。
+	Clock singleflight.Clock
+
+	group singleflight.Group[string, *tls.Certificate]
+
+	mu    sync.Mutex
+	certs map[string]*entry
+	// gen 在每次 Forget 时递增，进行中的加载据此放弃写入已失效的结果。
+	gen uint64
+}
+
+type entry struct {
+	cert *tls.Certificate
+	// renewing 表示后台续期正在进行，retryAt 为续期失败后下一次允许续期的时刻。
+	renewing bool
+	retryAt  time.Time
+}
+
+func (m *Manager) now() time.Time {
+	if m.Clock != nil {
+		return m.Clock.Now()
+	}
+	return time.Now()
+}
+
+// GetCertificate 可直接赋给 tls.Config.GetCertificate。
+func (m *Manager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	name := strings.TrimSuffix(strings.ToLower(hello.ServerName), ".")
+	if name == "" {
+		name = m.DefaultName
+	}
+	if name == "" {
+		return nil, ErrNoServerName
+	}
+	// 手工构造的 ClientHelloInfo 没有 context。
+	ctx := hello.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return m.Certificate(ctx, name)
+}
+
+// Certificate 返回 name 的证书。没有可用证书时以 Load 加载，同一 name 的并发加载只进行一次；
+// 证书进入续期窗口时在后台续期一次并立即返回仍然有效的旧证书。
+func (m *Manager) Certificate(ctx context.Context, name string) (*tls.Certificate, error) {
+	now := m.now()
+	m.mu.Lock()
+	if e := m.certs[name]; e != nil && now.Before(e.cert.Leaf.NotAfter) {
+		if !e.renewing && !now.Before(e.cert.Leaf.NotAfter.Add(-m.renewBefore())) && !now.Before(e.retryAt) {
+			e.renewing = true
+			go m.renew(context.WithoutCancel(ctx), name, e)
+		}
+		m.mu.Unlock()
+		return e.cert, nil
+	}
+	m.mu.Unlock()
+	cert, err, _ := m.group.Do(ctx, name, m.load(name))
+	return cert, err
+}
+
+// renew 在后台续期 e，不随握手取消。成功时 e 被新条目取代；
+// 失败时推迟下一次尝试，旧证书继续使用。
+func (m *Manager) renew(ctx context.Context, name string, e *entry) {
+	_, err, _ := m.group.Do(ctx, name, m.load(name))
+	retry := m.RetryInterval
+	if retry <= 0 {
+		retry = time.Minute
+	}
+	m.mu.Lock()
+	e.renewing = false
+	if err != nil {
+		e.retryAt = m.now().Add(retry)
+	}
+	m.mu.Unlock()
+}
+
+// Forget 丢弃 name 缓存的证书，例如证书被吊销，下一次握手会重新加载。
+func (m *Manager) Forget(name string) {
+	m.mu.Lock()
+	delete(m.certs, name)
+	m.gen++
+	m.mu.Unlock()
+	m.group.Forget(name)
+}
+
+func (m *Manager) renewBefore() time.Duration {
+	if m.RenewBefore > 0 {
+		return m.RenewBefore
+	}
+	return 30 * 24 * time.Hour
+}
+
+func (m *Manager) load(name string) func(ctx context.Context) (*tls.Certificate, error) {
+	return func(ctx context.Context) (*tls.Certificate, error) {
+		m.mu.Lock()
+		gen := m.gen
+		m.mu.Unlock()
+
+		cert, err := m.Load(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		// 过期判断依赖 Leaf，Load 未填充时在这里解析一次。
+		if cert.Leaf == nil {
+			if len(cert.Certificate) == 0 {
+				return nil, errors.New("tlssf: certificate for " + name + " has no chain")
+			}
+			leaf, err := x509.ParseCertificate(cert.Certificate[0])
+			if err != nil {
+				return nil, err
+			}
+			cert.Leaf = leaf
+		}
+		m.mu.Lock()
+		if m.gen == gen {
+			if m.certs == nil {
+				m.certs = make(map[string]*entry)
+			}
+			m.certs[name] = &entry{cert: cert}
+		}
+		m.mu.Unlock()
+		return cert, nil
+	}
+}
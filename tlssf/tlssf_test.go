@@ -0,0 +1,127 @@
+package tlssf
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+// issue 签发一张在 notAfter 过期的自签名证书，不填充 Leaf。
+func issue(t *testing.T, name string, serial int64, notAfter time.Time) *tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		DNSNames:     []string{name},
+		NotBefore:    notAfter.Add(-90 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestManager_CoalescesLoads(t *testing.T) {
+	var loads atomic.Int32
+	release := make(chan struct{})
+	m := &Manager{Load: func(ctx context.Context, name string) (*tls.Certificate, error) {
+		loads.Add(1)
+		<-release
+		return issue(t, name, 1, time.Now().Add(90*24*time.Hour)), nil
+	}}
+
+	var wg sync.WaitGroup
+	for range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cert, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: "Example.COM."})
+			if err != nil || cert.Leaf.DNSNames[0] != "example.com" {
+				t.Errorf("GetCertificate = %v, %v", cert, err)
+			}
+		}()
+	}
+	for loads.Load() == 0 {
+		runtime.Gosched()
+	}
+	close(release)
+	wg.Wait()
+	if n := loads.Load(); n != 1 {
+		t.Fatalf("loads = %d, want 1", n)
+	}
+
+	if _, err := m.GetCertificate(&tls.ClientHelloInfo{}); !errors.Is(err, ErrNoServerName) {
+		t.Fatalf("no SNI err = %v", err)
+	}
+}
+
+func TestManager_Renewal(t *testing.T) {
+	clock := singleflight.NewFakeClock(time.Now())
+	notAfter := clock.Now().Add(40 * 24 * time.Hour)
+	boom := errors.New("acme down")
+	var serial atomic.Int64
+	var fail atomic.Bool
+	m := &Manager{
+		Clock: clock,
+		Load: func(ctx context.Context, name string) (*tls.Certificate, error) {
+			if fail.Load() {
+				return nil, boom
+			}
+			return issue(t, name, serial.Add(1), notAfter), nil
+		},
+	}
+	ctx := context.Background()
+	old, err := m.Certificate(ctx, "a.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 进入续期窗口：续期失败时握手继续使用旧证书，且在 RetryInterval 内不再尝试。
+	clock.Advance(11 * 24 * time.Hour)
+	fail.Store(true)
+	if cert, _ := m.Certificate(ctx, "a.test"); cert != old {
+		t.Fatal("handshake in renewal window must get the current certificate")
+	}
+	waitRenewed := func() {
+		for {
+			m.mu.Lock()
+			done := !m.certs["a.test"].renewing
+			m.mu.Unlock()
+			if done {
+				return
+			}
+			runtime.Gosched()
+		}
+	}
+	waitRenewed()
+	fail.Store(false)
+	notAfter = notAfter.Add(60 * 24 * time.Hour)
+	if cert, _ := m.Certificate(ctx, "a.test"); cert != old {
+		t.Fatal("renewal retried before RetryInterval")
+	}
+
+	clock.Advance(time.Minute)
+	m.Certificate(ctx, "a.test")
+	waitRenewed()
+	cert, _ := m.Certificate(ctx, "a.test")
+	if cert == old || cert.Leaf.SerialNumber.Int64() != 2 {
+		t.Fatalf("renewed serial = %v", cert.Leaf.SerialNumber)
+	}
+}
@@ -0,0 +1,103 @@
+package tokensf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ClientCredentials 以 OAuth 2.0 客户端凭证模式（RFC 6749 4.4 节）获取令牌。
+// Key.Issuer 为令牌端点 URL，Key.Scope 为以空格分隔的 scope，可为空。
+type ClientCredentials struct {
+	// ClientSecret 返回 clientID 的密钥，以 HTTP Basic 认证发送。必须设置。
+	ClientSecret func(clientID string) (string, error)
+
+	// Client 发送请求，nil 时使用 http.DefaultClient。
+	Client *http.Client
+}
+
+// RetrieveError 是令牌端点返回的错误。
+type RetrieveError struct {
+	StatusCode int
+	// ErrorCode 与 Description 为响应中的 error 与 error_description，可能为空。
+	ErrorCode   string
+	Description string
+}
+
+func (e *RetrieveError) Error() string {
+	if e.ErrorCode == "" {
+		return fmt.Sprintf("tokensf: token endpoint returned %d", e.StatusCode)
+	}
+	if e.Description == "" {
+		return fmt.Sprintf("tokensf: token endpoint returned %d: %s", e.StatusCode, e.ErrorCode)
+	}
+	return fmt.Sprintf("tokensf: token endpoint returned %d: %s: %s", e.StatusCode, e.ErrorCode, e.Description)
+}
+
+// tokenResponse 是 RFC 6749 5.1 / 5.2 节的响应体。
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Fetch 请求 key 的新令牌，可直接用作 Cache.Fetch。
+func (cc ClientCredentials) Fetch(ctx context.Context, key Key) (Token, error) {
+	secret, err := cc.ClientSecret(key.ClientID)
+	if err != nil {
+		return Token{}, err
+	}
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if key.Scope != "" {
+		form.Set("scope", key.Scope)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, key.Issuer, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// RFC 6749 2.3.1 要求先对 client_id 与密钥做表单编码。
+	req.SetBasicAuth(url.QueryEscape(key.ClientID), url.QueryEscape(secret))
+
+	client := cc.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	sent := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return Token{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return Token{}, err
+	}
+	var tr tokenResponse
+	// 错误响应不一定是 JSON，解析失败时仍按状态码报告。
+	jsonErr := json.Unmarshal(body, &tr)
+	if resp.StatusCode != http.StatusOK || tr.Error != "" {
+		return Token{}, &RetrieveError{StatusCode: resp.StatusCode, ErrorCode: tr.Error, Description: tr.ErrorDescription}
+	}
+	if jsonErr != nil {
+		return Token{}, fmt.Errorf("tokensf: decode token response: %w", jsonErr)
+	}
+	if tr.AccessToken == "" {
+		return Token{}, fmt.Errorf("tokensf: token response has no access_token")
+	}
+	tok := Token{AccessToken: tr.AccessToken, TokenType: tr.TokenType}
+	// 有效期从发出请求时算起，网络延迟只会让令牌被认为更早过期。
+	if tr.ExpiresIn > 0 {
+		tok.Expiry = sent.Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	return tok, nil
+}
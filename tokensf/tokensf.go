@@ -0,0 +1,157 @@
+// Package tokensf 缓存访问令牌，并按 (issuer, clientID, scope) 合并并发的刷新。
+//
+// 令牌临近过期时大量请求会同时发现需要刷新，各自请求授权服务器既浪费配额，
+// 也可能触发限流而让所有请求一起失败。Cache 让每个 Key 同时至多有一次刷新，
+// 并把各实例的刷新时刻随机错开。
+package tokensf
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+// Token 是一个访问令牌。
+type Token struct {
+	AccessToken string
+	TokenType   string
+	// Expiry 为令牌的过期时刻，零值表示不过期。
+	Expiry time.Time
+}
+
+// Key 标识一类可以互相替代的令牌。
+type Key struct {
+	Issuer   string
+	ClientID string
+	Scope    string
+}
+
+// Cache 缓存各 Key 的令牌，并发安全。Fetch 必须设置，其余字段零值可用。
+type Cache struct {
+	// Fetch 向授权服务器获取 key 的新令牌，例如 ClientCredentials.Fetch。
+	Fetch func(ctx context.Context, key Key) (Token, error)
+
+	// RefreshBefore 为在令牌过期前多久开始刷新，默认 1 分钟。
+	// 刷新失败时仍未过期的旧令牌照常返回。
+	RefreshBefore time.Duration
+
+	// Jitter 把刷新时刻随机提前至多令牌有效期的 Jitter 比例（0 到 1），
+	// 使同时获取令牌的实例不会在同一时刻刷新。
+	Jitter float64
+
+	// Clock 用于过期判断，nil 时使用 singleflight.SystemClock。
+	Clock singleflight.Clock
+
+	group singleflight.Group[Key, Token]
+
+	mu     sync.Mutex
+	tokens map[Key]entry
+}
+
+// entry 是缓存的令牌，refreshAt 为零值表示不需要刷新。
+type entry struct {
+	tok       Token
+	refreshAt time.Time
+}
+
+// Source 以固定的 Key 从 Cache 取得令牌。
+type Source struct {
+	cache *Cache
+	key   Key
+}
+
+// Source 返回 key 的令牌来源。
+func (c *Cache) Source(key Key) Source {
+	return Source{cache: c, key: key}
+}
+
+// Token 返回一个有效的令牌，需要时刷新。
+func (s Source) Token(ctx context.Context) (Token, error) {
+	return s.cache.Token(ctx, s.key)
+}
+
+func (c *Cache) now() time.Time {
+	if c.Clock != nil {
+		return c.Clock.Now()
+	}
+	return time.Now()
+}
+
+// Token 返回 key 的有效令牌。缓存的令牌到了刷新时刻才获取新令牌，
+// 同一 key 的并发刷新只请求一次。刷新失败时返回仍未过期的旧令牌，
+// 没有可用的令牌时返回 Fetch 的错误。
+func (c *Cache) Token(ctx context.Context, key Key) (Token, error) {
+	now := c.now()
+	c.mu.Lock()
+	e, ok := c.tokens[key]
+	c.mu.Unlock()
+	if ok && e.fresh(now) {
+		return e.tok, nil
+	}
+
+	tok, err, _ := c.group.Do(ctx, key, func(ctx context.Context) (Token, error) {
+		// 上一次刷新可能在我们查询之后、成为 Leader 之前刚完成。
+		c.mu.Lock()
+		e, ok := c.tokens[key]
+		c.mu.Unlock()
+		if ok && e.fresh(c.now()) {
+			return e.tok, nil
+		}
+		tok, err := c.Fetch(ctx, key)
+		if err != nil {
+			return tok, err
+		}
+		c.store(key, tok)
+		return tok, nil
+	})
+	if err != nil {
+		if ok && valid(e.tok, c.now()) {
+			return e.tok, nil
+		}
+		return Token{}, err
+	}
+	return tok, nil
+}
+
+// Invalidate 丢弃 key 缓存的令牌，例如资源服务器以 401 拒绝了它。
+// 下一次 Token 会获取新令牌。
+func (c *Cache) Invalidate(key Key) {
+	c.mu.Lock()
+	delete(c.tokens, key)
+	c.mu.Unlock()
+}
+
+// store 缓存新令牌并计算其刷新时刻。提前量不超过剩余有效期的一半，
+// 否则有效期很短的令牌每次使用都会触发刷新。
+func (c *Cache) store(key Key, tok Token) {
+	var refreshAt time.Time
+	if !tok.Expiry.IsZero() {
+		before := c.RefreshBefore
+		if before <= 0 {
+			before = time.Minute
+		}
+		life := tok.Expiry.Sub(c.now())
+		if c.Jitter > 0 && life > 0 {
+			before += time.Duration(rand.Float64() * min(c.Jitter, 1) * float64(life))
+		}
+		refreshAt = tok.Expiry.Add(-min(before, max(life/2, 0)))
+	}
+	c.mu.Lock()
+	if c.tokens == nil {
+		c.tokens = make(map[Key]entry)
+	}
+	c.tokens[key] = entry{tok: tok, refreshAt: refreshAt}
+	c.mu.Unlock()
+}
+
+// fresh 报告 e 是否还不需要刷新。不过期的令牌只在 Invalidate 之后刷新。
+func (e entry) fresh(now time.Time) bool {
+	return e.refreshAt.IsZero() || now.Before(e.refreshAt)
+}
+
+func valid(tok Token, now time.Time) bool {
+	return tok.Expiry.IsZero() || now.Before(tok.Expiry)
+}
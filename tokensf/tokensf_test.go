@@ -0,0 +1,100 @@
+package tokensf
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+func TestCache_Refresh(t *testing.T) {
+	clock := singleflight.NewFakeClock(time.Unix(0, 0))
+	var fetches atomic.Int32
+	var fail atomic.Bool
+	boom := errors.New("boom")
+	c := &Cache{
+		Clock: clock,
+		Fetch: func(ctx context.Context, key Key) (Token, error) {
+			if fail.Load() {
+				return Token{}, boom
+			}
+			n := fetches.Add(1)
+			return Token{AccessToken: string(rune('a' + n - 1)), Expiry: clock.Now().Add(time.Hour)}, nil
+		},
+	}
+	src := c.Source(Key{Issuer: "https://issuer", ClientID: "svc", Scope: "read"})
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if tok, err := src.Token(ctx); err != nil || tok.AccessToken != "a" {
+				t.Errorf("Token = %+v, %v", tok, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("fetches = %d, want 1", n)
+	}
+
+	// 默认在过期前 1 分钟刷新。
+	clock.Advance(59*time.Minute - time.Second)
+	if tok, _ := src.Token(ctx); tok.AccessToken != "a" {
+		t.Fatalf("before refresh = %q", tok.AccessToken)
+	}
+	clock.Advance(time.Second)
+	fail.Store(true)
+	if tok, err := src.Token(ctx); err != nil || tok.AccessToken != "a" {
+		t.Fatalf("failed refresh must return the unexpired token: %+v, %v", tok, err)
+	}
+	clock.Advance(time.Minute)
+	if _, err := src.Token(ctx); !errors.Is(err, boom) {
+		t.Fatalf("expired with failed refresh err = %v", err)
+	}
+	fail.Store(false)
+	if tok, _ := src.Token(ctx); tok.AccessToken != "b" {
+		t.Fatalf("after refresh = %q", tok.AccessToken)
+	}
+	c.Invalidate(src.key)
+	if tok, _ := src.Token(ctx); tok.AccessToken != "c" {
+		t.Fatalf("after Invalidate = %q", tok.AccessToken)
+	}
+}
+
+func TestClientCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if r.FormValue("grant_type") != "client_credentials" || id != "svc" || secret != "s%3Dcret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client","error_description":"bad secret"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"tok-` + r.FormValue("scope") + `","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer srv.Close()
+
+	cc := ClientCredentials{ClientSecret: func(string) (string, error) { return "s=cret", nil }}
+	tok, err := cc.Fetch(context.Background(), Key{Issuer: srv.URL, ClientID: "svc", Scope: "read"})
+	if err != nil || tok.AccessToken != "tok-read" || tok.TokenType != "Bearer" {
+		t.Fatalf("Fetch = %+v, %v", tok, err)
+	}
+	if d := time.Until(tok.Expiry); d <= 59*time.Minute || d > time.Hour {
+		t.Fatalf("expiry in %v", d)
+	}
+
+	cc.ClientSecret = func(string) (string, error) { return "wrong", nil }
+	var re *RetrieveError
+	if _, err := cc.Fetch(context.Background(), Key{Issuer: srv.URL, ClientID: "svc"}); !errors.As(err, &re) || re.ErrorCode != "invalid_client" {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package unixsf
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Client 是连接 Server 的 singleflight.LockBackend。请求在同一个连接上依次发送，
+// 连接出错后下一次请求重新拨号；断开的连接上持有的锁已被服务端释放。
+//
+// 连接上持有 Client 的所有锁，调用方的 ctx 结束不会断开它：放弃等待的请求在后台
+// 照常读完响应，放弃的 TryLock 若已取得锁则随即释放。
+type Client struct {
+	// Path 为 Server 监听的 socket 路径。
+	Path string
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// TryLock 实现 singleflight.LockBackend。ttl 向下取整到毫秒。
+func (c *Client) TryLock(ctx context.Context, key string, ttl time.Duration) (unlock func(context.Context) error, acquired bool, err error) {
+	if strings.ContainsRune(key, '\n') {
+		return nil, false, ErrBadKey
+	}
+	line := "LOCK " + strconv.FormatInt(ttl.Milliseconds(), 10) + " " + key
+	resp, err := c.roundTrip(ctx, line, func(resp string) {
+		// 调用方已放弃，没有人会释放这把锁。
+		if resp == "OK" {
+			c.exchangeLocked(context.Background(), "UNLOCK "+key)
+		}
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	switch resp {
+	case "OK":
+		return func(ctx context.Context) error { return c.unlock(ctx, key) }, true, nil
+	case "BUSY":
+		return nil, false, nil
+	}
+	return nil, false, responseError(resp)
+}
+
+func (c *Client) unlock(ctx context.Context, key string) error {
+	resp, err := c.roundTrip(ctx, "UNLOCK "+key, nil)
+	if err != nil {
+		return err
+	}
+	if resp != "OK" {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// Close 关闭连接并由此释放 Client 持有的所有锁。
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// 请求的交付状态，由发起请求的调用方与执行它的 goroutine 竞争设置。
+const (
+	pending int32 = iota
+	delivered
+	abandoned
+)
+
+// roundTrip 发送一行请求并返回响应。请求在单独的 goroutine 上执行：ctx 结束时
+// 调用方立即返回，请求照常完成以保持连接上请求与响应对齐，
+// 之后在仍持有 c.mu 时以响应调用 onAbandon（可为 nil）。
+func (c *Client) roundTrip(ctx context.Context, line string, onAbandon func(resp string)) (string, error) {
+	type result struct {
+		resp string
+		err  error
+	}
+	var state atomic.Int32
+	ch := make(chan result, 1)
+	go func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		// 排队等锁期间调用方已放弃时不必再发送。
+		if state.Load() == abandoned {
+			return
+		}
+		resp, err := c.exchangeLocked(ctx, line)
+		if state.CompareAndSwap(pending, delivered) {
+			ch <- result{resp, err}
+		} else if err == nil && onAbandon != nil {
+			onAbandon(resp)
+		}
+	}()
+
+	select {
+	case r := <-ch:
+		return r.resp, r.err
+	case <-ctx.Done():
+		if state.CompareAndSwap(pending, abandoned) {
+			return "", context.Cause(ctx)
+		}
+		r := <-ch
+		return r.resp, r.err
+	}
+}
+
+// exchangeLocked 在持有 c.mu 时发送一行请求并读取一行响应。ctx 只约束拨号：
+// 中途打断读写会使请求与响应错位，只能断开连接并连带释放其他锁。
+func (c *Client) exchangeLocked(ctx context.Context, line string) (string, error) {
+	if c.conn == nil {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "unix", c.Path)
+		if err != nil {
+			return "", err
+		}
+		c.conn, c.r = conn, bufio.NewReader(conn)
+	}
+	resp, err := func() (string, error) {
+		if _, err := c.conn.Write([]byte(line + "\n")); err != nil {
+			return "", err
+		}
+		return c.r.ReadString('\n')
+	}()
+	if err != nil {
+		// 连接已损坏，其上的锁已随之失去。
+		c.conn.Close()
+		c.conn = nil
+		return "", err
+	}
+	return strings.TrimSuffix(resp, "\n"), nil
+}
+
+func responseError(resp string) error {
+	return errors.New("unixsf: " + strings.TrimPrefix(resp, "ERR "))
+}
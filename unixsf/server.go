@@ -0,0 +1,151 @@
+package unixsf
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+// Server 是锁协调器。零值可用。
+type Server struct {
+	// Clock 用于判断锁是否过期，nil 时使用 singleflight.SystemClock。
+	Clock singleflight.Clock
+
+	mu    sync.Mutex
+	locks map[string]lease
+}
+
+// lease 记录锁的持有连接与过期时间，零值 expires 表示不过期。
+type lease struct {
+	owner   *conn
+	expires time.Time
+}
+
+type conn struct {
+	net.Conn
+	// held 为该连接持有的 key，由 Server.mu 保护。
+	held map[string]struct{}
+}
+
+// ListenAndServe 在 path 上监听并处理连接。path 上残留的 socket 文件会被先删除。
+func (s *Server) ListenAndServe(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve 接受 l 上的连接并为每个连接启动一个 goroutine，直到 Accept 失败。
+func (s *Server) Serve(l net.Listener) error {
+	defer l.Close()
+	for {
+		nc, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serve(&conn{Conn: nc, held: make(map[string]struct{})})
+	}
+}
+
+func (s *Server) serve(c *conn) {
+	defer s.release(c)
+	defer c.Close()
+	r := bufio.NewReader(c)
+	w := bufio.NewWriter(c)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fmt.Fprintln(w, s.handle(c, strings.TrimSuffix(line, "\n")))
+		// 客户端可能连续发送多个请求，读缓冲中没有剩余请求时才刷新。
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *Server) handle(c *conn, line string) string {
+	cmd, rest, _ := strings.Cut(line, " ")
+	switch cmd {
+	case "LOCK":
+		ms, key, ok := strings.Cut(rest, " ")
+		ttl, err := strconv.ParseInt(ms, 10, 64)
+		if !ok || err != nil {
+			return "ERR malformed LOCK"
+		}
+		if s.lock(c, key, time.Duration(ttl)*time.Millisecond) {
+			return "OK"
+		}
+		return "BUSY"
+	case "UNLOCK":
+		if s.unlock(c, rest) {
+			return "OK"
+		}
+		return "ERR not held"
+	default:
+		return "ERR unknown command"
+	}
+}
+
+func (s *Server) lock(c *conn, key string, ttl time.Duration) bool {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.locks[key]; ok && (l.expires.IsZero() || now.Before(l.expires)) {
+		return false
+	} else if ok {
+		delete(l.owner.held, key)
+	}
+	var expires time.Time
+	if ttl > 0 {
+		expires = now.Add(ttl)
+	}
+	if s.locks == nil {
+		s.locks = make(map[string]lease)
+	}
+	s.locks[key] = lease{owner: c, expires: expires}
+	c.held[key] = struct{}{}
+	return true
+}
+
+func (s *Server) unlock(c *conn, key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.locks[key]; !ok || l.owner != c {
+		return false
+	}
+	delete(s.locks, key)
+	delete(c.held, key)
+	return true
+}
+
+// release 释放断开的连接持有的所有锁。
+func (s *Server) release(c *conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range c.held {
+		delete(s.locks, key)
+	}
+}
+
+func (s *Server) now() time.Time {
+	if s.Clock != nil {
+		return s.Clock.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,17 @@
+// Package unixsf 提供一个监听 unix socket 的锁协调器及其 Go 客户端，
+// 使同一台主机上的异构进程（包括其他语言编写的进程）共享同一个执行命名空间。
+//
+// 协议是基于行的文本协议，每个请求一行，服务端按顺序对每个请求回复一行：
+//
+//	LOCK <ttl-ms> <key>   ->  OK | BUSY | ERR <message>
+//	UNLOCK <key>          ->  OK | ERR <message>
+//
+// key 为行内剩余的全部字节，不能包含换行；ttl-ms <= 0 表示不过期。
+// 锁属于发起请求的连接：连接断开时其持有的所有锁立即释放，
+// 持锁进程崩溃不需要等待 TTL。只有持有者可以 UNLOCK。
+package unixsf
+
+import "errors"
+
+// ErrBadKey 表示 key 包含换行，无法在协议中表示。
+var ErrBadKey = errors.New("unixsf: key contains newline")
@@ -0,0 +1,145 @@
+package unixsf
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oy3o/singleflight"
+)
+
+var _ singleflight.LockBackend = (*Client)(nil)
+
+func TestClientServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sf.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := singleflight.NewFakeClock(time.Unix(100, 0))
+	srv := &Server{Clock: clock}
+	go srv.Serve(l)
+	defer l.Close()
+
+	ctx := context.Background()
+	a, b := &Client{Path: path}, &Client{Path: path}
+	defer b.Close()
+
+	unlock, ok, err := a.TryLock(ctx, "user 1", time.Minute)
+	if err != nil || !ok {
+		t.Fatalf("TryLock = %v, %v", ok, err)
+	}
+	if _, ok, err := b.TryLock(ctx, "user 1", time.Minute); err != nil || ok {
+		t.Fatalf("contended TryLock = %v, %v", ok, err)
+	}
+	if err := unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// 过期的锁可被他人取得，原持有者不能再释放它。
+	unlock, _, _ = b.TryLock(ctx, "k", time.Second)
+	clock.Advance(2 * time.Second)
+	if _, ok, _ := a.TryLock(ctx, "k", 0); !ok {
+		t.Fatal("expired lock was not taken over")
+	}
+	if err := unlock(ctx); err == nil {
+		t.Fatal("unlock of a lost lock succeeded")
+	}
+
+	// 连接断开释放其持有的锁。服务端异步地察觉断开，因此轮询。
+	a.Close()
+	for i := 0; ; i++ {
+		_, ok, err := b.TryLock(ctx, "k", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			break
+		}
+		if i == 1000 {
+			t.Fatal("lock of closed client not released")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, _, err := b.TryLock(ctx, "a\nb", 0); err != ErrBadKey {
+		t.Fatalf("err = %v, want ErrBadKey", err)
+	}
+}
+
+// gatedListener 让服务端对每个连接的第 n 次写入（即第 n 个响应）阻塞到 gate 关闭。
+type gatedListener struct {
+	net.Listener
+	n    int
+	gate chan struct{}
+}
+
+func (l *gatedListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &gatedConn{Conn: c, l: l}, nil
+}
+
+type gatedConn struct {
+	net.Conn
+	l      *gatedListener
+	writes int
+}
+
+func (c *gatedConn) Write(p []byte) (int, error) {
+	if c.writes++; c.writes == c.l.n {
+		<-c.l.gate
+	}
+	return c.Conn.Write(p)
+}
+
+func TestClient_CancelKeepsOtherLocks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sf.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gate := make(chan struct{})
+	go (&Server{}).Serve(&gatedListener{Listener: ln, n: 2, gate: gate})
+	defer ln.Close()
+
+	ctx := context.Background()
+	a, other := &Client{Path: path}, &Client{Path: path}
+	defer a.Close()
+	defer other.Close()
+	if _, ok, err := a.TryLock(ctx, "B", 0); err != nil || !ok {
+		t.Fatalf("TryLock(B) = %v, %v", ok, err)
+	}
+
+	// 服务端迟迟不回应 A 的请求，调用方放弃等待。
+	cctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := a.TryLock(cctx, "A", 0); err != context.DeadlineExceeded {
+		t.Fatalf("cancelled TryLock(A) err = %v", err)
+	}
+	close(gate)
+
+	// 放弃的请求已取得的 A 在后台被释放。
+	for i := 0; ; i++ {
+		if _, ok, err := other.TryLock(ctx, "A", 0); err != nil {
+			t.Fatal(err)
+		} else if ok {
+			break
+		}
+		if i == 1000 {
+			t.Fatal("abandoned lock A was never released")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// B 的持有者仍在执行，它的锁不能被连带释放。
+	if _, ok, err := other.TryLock(ctx, "B", 0); err != nil || ok {
+		t.Fatalf("TryLock(B) by another client = %v, %v; want BUSY", ok, err)
+	}
+	if _, ok, err := a.TryLock(ctx, "C", 0); err != nil || !ok {
+		t.Fatalf("connection unusable after cancel: %v, %v", ok, err)
+	}
+}
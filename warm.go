@@ -0,0 +1,69 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Warm 以至多 concurrency 的并行度通过 g 加载 keys，用于部署时预热。
+//
+// 已在执行中的 key 不会被加入等待，仍在 WithMinExecInterval 间隔内且有结果的
+// key 视为新鲜而跳过，重复的 key 只加载一次。单个 key 的失败不影响其余 key，
+// 所有失败以 errors.Join 汇总返回；ctx 结束后停止提交新的 key。
+// concurrency <= 0 表示不限制。
+func (g *Group[K, V]) Warm(
+	ctx context.Context,
+	keys []K,
+	concurrency int,
+	fn func(ctx context.Context, key K) (V, error),
+) error {
+	var eg errgroup.Group
+	if concurrency > 0 {
+		eg.SetLimit(concurrency)
+	}
+
+	var mu sync.Mutex
+	var errs []error
+	seen := make(map[K]struct{}, len(keys))
+	for _, key := range keys {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		if ctx.Err() != nil {
+			errs = append(errs, waitError(ctx))
+			break
+		}
+		// 在拿到执行名额之后再检查，排队期间被其他调用者加载的 key 也会被跳过。
+		eg.Go(func() error {
+			if g.fresh(key) {
+				return nil
+			}
+			_, err := g.TryDo(ctx, key, func(ctx context.Context) (V, error) { return fn(ctx, key) })
+			if err != nil && !errors.Is(err, ErrInFlight) {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+			return nil
+		})
+	}
+	eg.Wait()
+	return errors.Join(errs...)
+}
+
+// fresh 报告 key 是否有无需重新加载的结果。
+func (g *Group[K, V]) fresh(key K) bool {
+	if g.cfg == nil || !g.cfg.keepLast {
+		return false
+	}
+	key = g.canonical(key)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	s, ok := g.states[key]
+	return ok && s.last.ok && s.last.err == nil &&
+		g.cfg.interval != nil && g.cfg.interval.wait(key) > 0
+}
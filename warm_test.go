@@ -0,0 +1,49 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWarm(t *testing.T) {
+	g := NewGroup[int, string](WithMinExecInterval(time.Hour))
+	g.Do(context.Background(), 0, func(ctx context.Context) (string, error) { return "fresh", nil })
+
+	var mu sync.Mutex
+	loaded := make(map[int]int)
+	var running, peak atomic.Int32
+	boom := errors.New("boom")
+	err := g.Warm(context.Background(), []int{0, 1, 2, 3, 4, 5, 1, 2}, 2, func(ctx context.Context, key int) (string, error) {
+		if n := running.Add(1); n > peak.Load() {
+			peak.Store(n)
+		}
+		defer running.Add(-1)
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		loaded[key]++
+		mu.Unlock()
+		if key == 3 {
+			return "", boom
+		}
+		return strconv.Itoa(key), nil
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want boom", err)
+	}
+	if peak.Load() > 2 {
+		t.Fatalf("concurrency %d exceeds limit", peak.Load())
+	}
+	if loaded[0] != 0 {
+		t.Fatal("fresh key was reloaded")
+	}
+	for k := 1; k <= 5; k++ {
+		if loaded[k] != 1 {
+			t.Fatalf("key %d loaded %d times", k, loaded[k])
+		}
+	}
+}
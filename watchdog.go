@@ -0,0 +1,50 @@
+package singleflight
+
+import (
+	"context"
+	"time"
+)
+
+// ErrLeaderStuck 表示 Leader 超过 WithWatchdog 的时限仍未完成，等待者被提前释放。
+var ErrLeaderStuck = newGroupError("singleflight: leader stuck")
+
+// WithWatchdog 在 Leader 执行超过 d 仍未完成时，让所有等待中的 Follower 立即收到
+// ErrLeaderStuck，并使 key 被遗忘，之后的调用发起新的执行。
+// 否则一个卡死的 fn 会永远钉住任意多个等待的 goroutine。
+//
+// cancel 为 true 时同时以 ErrLeaderStuck 为 cause 取消 fn 的 ctx；
+// 为 false 时 Leader 继续运行，其结果只交给 Leader 自己的调用者。
+// 与 WithExecTimeout 不同，看门狗不把 Leader 的结果改写为错误。d <= 0 表示不启用。
+func WithWatchdog(d time.Duration, cancel bool) Option {
+	return func(o *options) {
+		o.watchdog = d
+		o.watchdogCancel = cancel
+	}
+}
+
+// watch 为执行 c 启动看门狗，返回 fn 应使用的 ctx、执行结束时的清理函数和定时器。
+func (g *Group[K, V]) watch(ctx context.Context, key K, c *call[V]) (context.Context, context.CancelCauseFunc, Timer) {
+	cancel := context.CancelCauseFunc(func(error) {})
+	if g.cfg.watchdogCancel {
+		ctx, cancel = context.WithCancelCause(ctx)
+	}
+	t := clockOrSystem(g.cfg.clock).AfterFunc(g.cfg.watchdog, func() {
+		g.mu.Lock()
+		if c.finished {
+			g.mu.Unlock()
+			return
+		}
+		if !c.forgotten {
+			g.forgetLocked(key, c)
+		}
+		wedged := c.wedged
+		g.mu.Unlock()
+
+		// key 已移出 map，不会再有 Follower 取得 wedged，close 至多执行一次。
+		if wedged != nil {
+			close(wedged)
+		}
+		cancel(ErrLeaderStuck)
+	})
+	return ctx, cancel, t
+}
@@ -0,0 +1,66 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithWatchdog(t *testing.T) {
+	for _, cancel := range []bool{false, true} {
+		clock := NewFakeClock(time.Unix(0, 0))
+		joined := make(chan struct{}, 1)
+		g := NewGroup[string, int](
+			WithClock(clock),
+			WithWatchdog(time.Second, cancel),
+			WithHooks(Hooks[string]{FollowerJoined: func(string) { joined <- struct{}{} }}),
+		)
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		leader := g.DoChan(context.Background(), "k", func(ctx context.Context) (int, error) {
+			close(started)
+			select {
+			case <-release:
+				return 1, nil
+			case <-ctx.Done():
+				return 0, context.Cause(ctx)
+			}
+		})
+		<-started
+		follower := g.DoChan(context.Background(), "k", nil)
+		<-joined
+
+		clock.Advance(time.Second)
+		if r := <-follower; !errors.Is(r.Err, ErrLeaderStuck) || IsExecutionError(r.Err) {
+			t.Fatalf("cancel=%v: follower got %+v, want ErrLeaderStuck", cancel, r)
+		}
+		// 卡住的 key 已被遗忘，新的调用发起新的执行。
+		if v, _, shared := g.Do(context.Background(), "k", func(context.Context) (int, error) { return 2, nil }); v != 2 || shared {
+			t.Fatalf("cancel=%v: after watchdog got %d, shared=%v", cancel, v, shared)
+		}
+
+		close(release)
+		r := <-leader
+		if cancel && !errors.Is(r.Err, ErrLeaderStuck) {
+			t.Fatalf("leader got %+v, want its ctx cancelled with ErrLeaderStuck", r)
+		}
+		if !cancel && r.Val != 1 {
+			t.Fatalf("leader got %+v, want it to keep running", r)
+		}
+	}
+}
+
+func TestWithWatchdog_FinishedInTime(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	g := NewGroup[string, int](WithClock(clock), WithWatchdog(time.Second, true))
+	for i := range 3 {
+		if v, err, _ := g.Do(context.Background(), "k", func(context.Context) (int, error) { return i, nil }); v != i || err != nil {
+			t.Fatalf("got %d, %v", v, err)
+		}
+	}
+	if n := clock.Timers(); n != 0 {
+		t.Fatalf("%d watchdog timers left running", n)
+	}
+}
@@ -0,0 +1,33 @@
+package singleflight
+
+import "context"
+
+// WithWeightedLimit 限制 Group 同时执行的总代价不超过 capacity：每次执行按
+// cost(key) 占用额度，一次"大报表"加载可以计为十次普通加载。cost 为 nil 时每次
+// 执行计 1，退化为普通的并发上限。K 必须与 Group 的 key 类型一致。
+//
+// 额度不足时 Leader 按到达顺序排队（先到者不会被后到的小代价执行绕过），
+// 排队时间不计入 WithExecTimeout。排队期间 Leader 的 ctx 结束时 Leader 收到
+// *WaitError，仍在等待的 Follower 中的一个以自己的 ctx 接手排队与执行（同 WithHandoff，
+// 未传 fn 时使用 Leader 的 fn）。cost 超过 capacity 时按 capacity 计，cost < 1 时按 1 计。
+// capacity <= 0 表示不限制。
+func WithWeightedLimit[K comparable](capacity int64, cost func(K) int64) Option {
+	return func(o *options) {
+		o.capacity = capacity
+		if cost != nil {
+			o.rawCost = cost
+		}
+	}
+}
+
+// acquire 为 key 的执行取得额度，返回释放函数。
+func (g *Group[K, V]) acquire(ctx context.Context, key K) (func(), error) {
+	n := int64(1)
+	if g.cfg.cost != nil {
+		n = min(max(g.cfg.cost(key), 1), g.cfg.capacity)
+	}
+	if err := g.cfg.sem.Acquire(ctx, n); err != nil {
+		return nil, waitError(ctx)
+	}
+	return func() { g.cfg.sem.Release(n) }, nil
+}
@@ -0,0 +1,100 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+)
+
+func TestWeightedLimit(t *testing.T) {
+	cost := func(key string) int64 {
+		if key == "report" {
+			return 10
+		}
+		return 1
+	}
+	g := NewGroup[string, int](WithWeightedLimit(10, cost))
+
+	started := make(chan string, 4)
+	release := make(chan struct{})
+	fn := func(key string) func(context.Context) (int, error) {
+		return func(context.Context) (int, error) {
+			started <- key
+			<-release
+			return 0, nil
+		}
+	}
+
+	small := g.DoChan(context.Background(), "a", fn("a"))
+	if k := <-started; k != "a" {
+		t.Fatalf("started %q", k)
+	}
+	// 报表占满全部额度，必须等待 a 完成。
+	report := g.DoChan(context.Background(), "report", fn("report"))
+	for g.cfg.sem.TryAcquire(1) {
+		g.cfg.sem.Release(1)
+		runtime.Gosched()
+	}
+	select {
+	case k := <-started:
+		t.Fatalf("%q started while capacity was exhausted", k)
+	default:
+	}
+
+	// 排队的 Leader 可以因自己的 ctx 放弃。
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := g.acquire(ctx, "b"); !errors.Is(err, ErrWaiterCancelled) {
+		t.Fatalf("acquire with cancelled ctx = %v", err)
+	}
+
+	close(release)
+	<-small
+	<-report
+	if k := <-started; k != "report" {
+		t.Fatalf("started %q", k)
+	}
+}
+
+// 排队中的 Leader 放弃时，仍在等待的 Follower 接手执行而不是收到 Leader 的 *WaitError。
+func TestWeightedLimit_CancelledLeaderHandsOff(t *testing.T) {
+	installed := make(chan string, 4)
+	joined := make(chan struct{}, 1)
+	g := NewGroup[string, int](WithWeightedLimit[string](1, nil), WithHooks(Hooks[string]{
+		LeaderInstalled: func(key string) { installed <- key },
+		FollowerJoined:  func(string) { joined <- struct{}{} },
+	}))
+
+	release := make(chan struct{})
+	busy := g.DoChan(context.Background(), "busy", func(context.Context) (int, error) {
+		<-release
+		return 0, nil
+	})
+	<-installed
+
+	var execs int
+	fn := func(context.Context) (int, error) {
+		execs++
+		return 42, nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := g.DoChan(ctx, "k", fn)
+	<-installed
+	follower := g.DoChan(context.Background(), "k", nil)
+	<-joined
+
+	cancel()
+	if r := <-leader; !errors.Is(r.Err, ErrWaiterCancelled) {
+		t.Fatalf("leader err = %v, want ErrWaiterCancelled", r.Err)
+	}
+	close(release)
+	<-busy
+	r := <-follower
+	if r.Err != nil || r.Val != 42 || !r.Leader {
+		t.Fatalf("follower = %+v, want it to take over", r)
+	}
+	if execs != 1 {
+		t.Fatalf("executions = %d, want 1", execs)
+	}
+}
@@ -0,0 +1,173 @@
+package singleflight
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+// WithWorkers 让 Leader 的 fn 提交到至多 n 个内部 worker 上执行，
+// 调用者（包括 Leader）只等待结果。调用方的 goroutine 预算由此与后端并发解耦，
+// 整个 Group 对后端的总负载也有了唯一的上限。
+//
+// worker 按需启动、空闲即退出，不需要关闭。超出上限的执行排队，
+// 按 Leader ctx 上 WithPriority 设置的优先级出队，同优先级严格先到先得：
+// worker 完成一次执行后先取队首，新到的执行不会插队。需要跨优先级也严格
+// 按到达顺序执行时使用 WithFIFO。
+// fn 的 ctx 与 WithAsyncLeader 相同，脱离 Leader 的取消与截止时间（值的复制见
+// WithContextValues），应配合 WithExecTimeout 限制执行时长：Leader 的 ctx 结束时
+// 它以 ErrWaiterCancelled 返回，已提交的执行（包括仍在排队的）照常进行并把结果
+// 交给其余 Follower。n <= 0 表示在调用者上执行。
+func WithWorkers(n int) Option {
+	return func(o *options) { o.workers = n }
+}
+
+// WithFIFO 让 WithWorkers 的排队严格按提交顺序执行，忽略 WithPriority。
+// 优先级会让持续到达的高优先级执行无限期推迟更早的低优先级执行，
+// 有延迟 SLO 要求先到者不被饿死时应使用它。
+func WithFIFO() Option {
+	return func(o *options) { o.fifo = true }
+}
+
+// workerPool 是按需伸缩的有界执行池。排队的执行按优先级从高到低、
+// 同优先级按提交顺序出队；fifo 时所有执行视为同一优先级。
+type workerPool struct {
+	mu      sync.Mutex
+	size    int
+	fifo    bool
+	running int
+	queue   jobQueue
+	seq     uint64
+}
+
+// poolJob 是一次排队的执行。prio 与 index 由 workerPool.mu 保护。
+type poolJob struct {
+	run   func()
+	prio  Priority
+	seq   uint64
+	index int // 在 queue 中的位置，不在队列中时为 -1
+}
+
+func (p *workerPool) submit(j *poolJob) {
+	p.mu.Lock()
+	if p.fifo {
+		j.prio = PriorityNormal
+	}
+	if p.running < p.size {
+		p.running++
+		p.mu.Unlock()
+		go p.work(j)
+		return
+	}
+	p.seq++
+	j.seq = p.seq
+	heap.Push(&p.queue, j)
+	p.mu.Unlock()
+}
+
+// raise 把 j 的优先级提升到至少 prio。j 尚未提交或已开始执行时只记录优先级。
+func (p *workerPool) raise(j *poolJob, prio Priority) {
+	p.mu.Lock()
+	if !p.fifo && prio > j.prio {
+		j.prio = prio
+		if j.index >= 0 {
+			heap.Fix(&p.queue, j.index)
+		}
+	}
+	p.mu.Unlock()
+}
+
+func (p *workerPool) work(j *poolJob) {
+	for {
+		j.run()
+
+		p.mu.Lock()
+		if p.queue.Len() == 0 {
+			p.running--
+			p.mu.Unlock()
+			return
+		}
+		j = heap.Pop(&p.queue).(*poolJob)
+		p.mu.Unlock()
+	}
+}
+
+// jobQueue 实现 heap.Interface。
+type jobQueue []*poolJob
+
+func (q jobQueue) Len() int { return len(q) }
+
+func (q jobQueue) Less(i, j int) bool {
+	if q[i].prio != q[j].prio {
+		return q[i].prio > q[j].prio
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q jobQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *jobQueue) Push(x any) {
+	j := x.(*poolJob)
+	j.index = len(*q)
+	*q = append(*q, j)
+}
+
+func (q *jobQueue) Pop() any {
+	old := *q
+	n := len(old)
+	j := old[n-1]
+	old[n-1] = nil
+	j.index = -1
+	*q = old[:n-1]
+	return j
+}
+
+// leadAsync 把已登记的 c 交给 worker 或新的 goroutine 执行并等待结果。
+// done 为登记时按需分配的 c.done；c 不回收，因为执行完成后 Leader 仍要读取它。
+func (g *Group[K, V]) leadAsync(
+	ctx context.Context,
+	key K,
+	c *call[V],
+	fn func(ctx context.Context) (V, error),
+	co *callOpts[V],
+	done chan struct{},
+) (V, error, flight) {
+	fctx := g.leaderContext(ctx, co)
+	// 排队的执行可能在 Leader 离开之后才开始，不能继承它的取消。
+	if c.job != nil {
+		fctx = g.detach(ctx)
+	}
+	run := func() { g.doCall(c, key, fn, fctx) }
+	if c.job != nil {
+		c.job.run = run
+		g.cfg.pool.submit(c.job)
+	} else {
+		go run()
+	}
+
+	if done == nil {
+		c.wg.Wait()
+	} else {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			if !isClosed(done) {
+				if co != nil && co.park > 0 {
+					g.mu.Lock()
+					c.park = max(c.park, co.park)
+					g.mu.Unlock()
+				}
+				return g.cancelled(ctx, key, co, flight{leader: true})
+			}
+		}
+	}
+
+	if c.panicErr != nil {
+		panic(c.panicErr)
+	}
+	return c.val, c.err, flight{shared: c.waiters > 0, leader: true, waiters: c.waiters, dur: c.dur}
+}
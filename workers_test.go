@@ -0,0 +1,116 @@
+package singleflight
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkers_BoundsExecution(t *testing.T) {
+	g := NewGroup[string, string](WithWorkers(2))
+	var running, peak atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := strconv.Itoa(i)
+			v, err, _ := g.Do(context.Background(), key, func(ctx context.Context) (string, error) {
+				n := running.Add(1)
+				for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+				}
+				time.Sleep(time.Millisecond)
+				running.Add(-1)
+				return key, nil
+			})
+			if err != nil || v != key {
+				t.Errorf("key %s: got %q, %v", key, v, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if p := peak.Load(); p > 2 {
+		t.Fatalf("peak concurrency %d exceeds 2 workers", p)
+	}
+}
+
+func TestWorkers_LeaderCancelStillServesFollowers(t *testing.T) {
+	joined := make(chan struct{})
+	g := NewGroup[string, int](
+		WithWorkers(1),
+		WithHooks(Hooks[string]{FollowerJoined: func(string) { close(joined) }}),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	release := make(chan struct{})
+	leader := g.DoChan(ctx, "k", func(ctx context.Context) (int, error) {
+		close(started)
+		<-release
+		// fn 的 ctx 不随 Leader 取消。
+		return 1, ctx.Err()
+	})
+	<-started
+	follower := g.DoChan(context.Background(), "k", nil)
+	<-joined
+
+	cancel()
+	if r := <-leader; !errors.Is(r.Err, ErrWaiterCancelled) || !r.Leader {
+		t.Fatalf("leader = %+v, want cancelled leader", r)
+	}
+	close(release)
+	if r := <-follower; r.Val != 1 || r.Err != nil {
+		t.Fatalf("follower = %+v", r)
+	}
+}
+
+func TestWorkers_QueuedJobOutlivesLeader(t *testing.T) {
+	joined := make(chan struct{})
+	g := NewGroup[string, int](
+		WithWorkers(1),
+		WithHooks(Hooks[string]{FollowerJoined: func(string) { close(joined) }}),
+	)
+	// 占住唯一的 worker，让 k 的执行排队。
+	busy, release := make(chan struct{}), make(chan struct{})
+	blocker := g.DoChan(context.Background(), "busy", func(context.Context) (int, error) {
+		close(busy)
+		<-release
+		return 0, nil
+	})
+	<-busy
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := g.DoChan(ctx, "k", func(ctx context.Context) (int, error) { return 1, ctx.Err() })
+	for {
+		if info, ok := g.Inspect("k"); ok && info.Waiters == 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	follower := g.DoChan(context.Background(), "k", nil)
+	<-joined
+	cancel()
+	if r := <-leader; !errors.Is(r.Err, ErrWaiterCancelled) {
+		t.Fatalf("leader = %+v, want cancelled leader", r)
+	}
+
+	// 排队的执行在 Leader 离开之后才开始，仍以有效的 ctx 运行并交给 Follower。
+	close(release)
+	<-blocker
+	if r := <-follower; r.Val != 1 || r.Err != nil {
+		t.Fatalf("follower = %+v", r)
+	}
+}
+
+func TestWorkers_PanicPropagatesToCaller(t *testing.T) {
+	g := NewGroup[string, int](WithWorkers(1))
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("panic was not propagated to the leader")
+		}
+	}()
+	g.Do(context.Background(), "k", func(context.Context) (int, error) { panic("boom") })
+}